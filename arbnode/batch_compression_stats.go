@@ -0,0 +1,65 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"sync"
+
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// Encodings counted in BatchCompressionStats.EncodingBreakdown
+const (
+	BatchEncodingCalldata = "calldata"
+	BatchEncodingBlobs    = "blobs"
+	BatchEncodingDAS      = "das"
+)
+
+// batchCompressionStats accumulates the compression of the batches posted since startup
+type batchCompressionStats struct {
+	mutex        sync.Mutex
+	posted       uint64
+	uncompressed uint64
+	compressed   uint64
+	lastRatio    float64
+	encodings    map[string]uint64
+}
+
+func newBatchCompressionStats() *batchCompressionStats {
+	return &batchCompressionStats{encodings: make(map[string]uint64)}
+}
+
+func (s *batchCompressionStats) update(uncompressed, compressed uint64, encoding string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.posted++
+	s.uncompressed += uncompressed
+	s.compressed += compressed
+	s.lastRatio = compressionRatio(uncompressed, compressed)
+	s.encodings[encoding]++
+}
+
+func (s *batchCompressionStats) snapshot() execution.BatchCompressionStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	encodings := make(map[string]uint64, len(s.encodings))
+	for encoding, count := range s.encodings {
+		encodings[encoding] = count
+	}
+	return execution.BatchCompressionStats{
+		TotalBatchesPosted:     s.posted,
+		TotalUncompressedBytes: s.uncompressed,
+		TotalCompressedBytes:   s.compressed,
+		AverageRatio:           compressionRatio(s.uncompressed, s.compressed),
+		LastBatchRatio:         s.lastRatio,
+		EncodingBreakdown:      encodings,
+	}
+}
+
+func compressionRatio(uncompressed, compressed uint64) float64 {
+	if compressed == 0 {
+		return 0
+	}
+	return float64(uncompressed) / float64(compressed)
+}
@@ -0,0 +1,40 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"testing"
+)
+
+func TestBatchCompressionStats(t *testing.T) {
+	stats := newBatchCompressionStats()
+	if got := stats.snapshot(); got.TotalBatchesPosted != 0 || got.AverageRatio != 0 {
+		t.Fatal("stats not empty before the first batch", got)
+	}
+	stats.update(1000, 100, BatchEncodingCalldata)
+	stats.update(600, 200, BatchEncodingBlobs)
+	stats.update(400, 100, BatchEncodingBlobs)
+	got := stats.snapshot()
+	if got.TotalBatchesPosted != 3 || got.TotalUncompressedBytes != 2000 || got.TotalCompressedBytes != 400 {
+		t.Fatal("unexpected totals", got)
+	}
+	if got.AverageRatio != 5 || got.LastBatchRatio != 4 {
+		t.Fatal("unexpected ratios", got.AverageRatio, got.LastBatchRatio)
+	}
+	if got.EncodingBreakdown[BatchEncodingCalldata] != 1 || got.EncodingBreakdown[BatchEncodingBlobs] != 2 || len(got.EncodingBreakdown) != 2 {
+		t.Fatal("unexpected encoding breakdown", got.EncodingBreakdown)
+	}
+	got.EncodingBreakdown[BatchEncodingDAS] = 1
+	if _, ok := stats.snapshot().EncodingBreakdown[BatchEncodingDAS]; ok {
+		t.Fatal("snapshot shares its encoding breakdown with the stats")
+	}
+}
+
+// Updating the stats is on the batch posting path, and should stay well under a microsecond
+func BenchmarkBatchCompressionStatsUpdate(b *testing.B) {
+	stats := newBatchCompressionStats()
+	for i := 0; i < b.N; i++ {
+		stats.update(100_000, 10_000, BatchEncodingBlobs)
+	}
+}
@@ -108,6 +108,7 @@ type BatchPoster struct {
 	dataPoster         *dataposter.DataPoster
 	redisLock          *redislock.Simple
 	messagesPerBatch   *arbmath.MovingAverage[uint64]
+	compressionStats   *batchCompressionStats
 	non4844BatchCount  int // Count of consecutive non-4844 batches posted
 	// This is an atomic variable that should only be accessed atomically.
 	// An estimate of the number of batches we want to post but haven't yet.
@@ -341,6 +342,7 @@ func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, e
 		bridgeAddr:         opts.DeployInfo.Bridge,
 		dapWriter:          opts.DAPWriter,
 		redisLock:          redisLock,
+		compressionStats:   newBatchCompressionStats(),
 	}
 	b.messagesPerBatch, err = arbmath.NewMovingAverage[uint64](20)
 	if err != nil {
@@ -1283,6 +1285,11 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		b.building = nil // a closed batchSegments can't be reused
 		return false, nil
 	}
+	compressedSize := uint64(len(sequencerMsg))
+	encoding := BatchEncodingCalldata
+	if b.building.use4844 {
+		encoding = BatchEncodingBlobs
+	}
 
 	if b.dapWriter != nil {
 		if !b.redisLock.AttemptLock(ctx) {
@@ -1303,6 +1310,9 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 			batchPosterDAFailureCounter.Inc(1)
 			return false, err
 		}
+		if len(sequencerMsg) > 0 && daprovider.IsDASMessageHeaderByte(sequencerMsg[0]) {
+			encoding = BatchEncodingDAS
+		}
 
 		batchPosterDASuccessCounter.Inc(1)
 		batchPosterDALastSuccessfulActionGauge.Update(time.Now().Unix())
@@ -1380,6 +1390,7 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 		return false, err
 	}
 	b.postedFirstBatch = true
+	b.compressionStats.update(uint64(b.building.segments.totalUncompressedSize), compressedSize, encoding)
 	log.Info(
 		"BatchPoster: batch sent",
 		"sequenceNumber", batchPosition.NextSeqNum,
@@ -1452,6 +1463,10 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 	return true, nil
 }
 
+func (b *BatchPoster) CompressionStats() execution.BatchCompressionStats {
+	return b.compressionStats.snapshot()
+}
+
 func (b *BatchPoster) GetBacklogEstimate() uint64 {
 	return b.backlog.Load()
 }
@@ -0,0 +1,93 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/headerreader"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+// BatchPruner prunes the data of batches posted more than RetentionBlocks parent chain blocks
+// before the latest finalized parent chain block, see InboxReader.PruneBatchesBefore.
+type BatchPruner struct {
+	stopwaiter.StopWaiter
+	reader   *InboxReader
+	l1Reader *headerreader.HeaderReader
+	config   BatchPrunerConfigFetcher
+}
+
+type BatchPrunerConfig struct {
+	Enable          bool          `koanf:"enable"`
+	RetentionBlocks uint64        `koanf:"retention-blocks" reload:"hot"`
+	PruneInterval   time.Duration `koanf:"prune-interval" reload:"hot"`
+}
+
+type BatchPrunerConfigFetcher func() *BatchPrunerConfig
+
+var DefaultBatchPrunerConfig = BatchPrunerConfig{
+	Enable:          false,
+	RetentionBlocks: 50_400,
+	PruneInterval:   time.Minute,
+}
+
+func BatchPrunerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBatchPrunerConfig.Enable, "enable pruning of batches posted before the retention horizon")
+	f.Uint64(prefix+".retention-blocks", DefaultBatchPrunerConfig.RetentionBlocks, "number of parent chain blocks before the latest finalized block to keep batches for")
+	f.Duration(prefix+".prune-interval", DefaultBatchPrunerConfig.PruneInterval, "interval for running batch pruner")
+}
+
+func NewBatchPruner(reader *InboxReader, l1Reader *headerreader.HeaderReader, config BatchPrunerConfigFetcher) (*BatchPruner, error) {
+	if l1Reader == nil || !l1Reader.UseFinalityData() {
+		return nil, errors.New("batch pruner requires a parent chain reader using finality data")
+	}
+	return &BatchPruner{
+		reader:   reader,
+		l1Reader: l1Reader,
+		config:   config,
+	}, nil
+}
+
+func (p *BatchPruner) Start(ctxIn context.Context) {
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(func(ctx context.Context) time.Duration {
+		err := p.prune(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("error while pruning batches", "err", err)
+		}
+		return p.config().PruneInterval
+	})
+}
+
+func (p *BatchPruner) prune(ctx context.Context) error {
+	finalized, err := p.l1Reader.LatestFinalizedBlockNr(ctx)
+	if err != nil {
+		return err
+	}
+	retention := p.config().RetentionBlocks
+	if finalized <= retention {
+		return nil
+	}
+	batchCount, err := p.reader.tracker.GetBatchCount()
+	if err != nil {
+		return err
+	}
+	if batchCount == 0 {
+		return nil
+	}
+	pruneBefore, err := p.reader.tracker.firstBatchAtOrAfterParentChainBlock(finalized-retention, batchCount)
+	if err != nil {
+		return err
+	}
+	// The latest batch is kept even if it's before the horizon
+	pruneBefore = arbmath.MinInt(pruneBefore, batchCount-1)
+	return p.reader.PruneBatchesBefore(pruneBefore)
+}
@@ -10,6 +10,7 @@ import (
 	"math"
 	"math/big"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -18,7 +19,9 @@ import (
 	flag "github.com/spf13/pflag"
 
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
@@ -32,6 +35,8 @@ type InboxReaderConfig struct {
 	TargetMessagesRead  uint64        `koanf:"target-messages-read" reload:"hot"`
 	MaxBlocksToRead     uint64        `koanf:"max-blocks-to-read" reload:"hot"`
 	ReadMode            string        `koanf:"read-mode" reload:"hot"`
+	BatchCacheSize      int           `koanf:"batch-cache-size"`
+	PrefetchConcurrency int           `koanf:"prefetch-concurrency"`
 }
 
 type InboxReaderConfigFetcher func() *InboxReaderConfig
@@ -44,6 +49,9 @@ func (c *InboxReaderConfig) Validate() error {
 	if c.ReadMode != "latest" && c.ReadMode != "safe" && c.ReadMode != "finalized" {
 		return fmt.Errorf("inbox reader read-mode is invalid, want: latest or safe or finalized, got: %s", c.ReadMode)
 	}
+	if c.PrefetchConcurrency <= 0 {
+		return errors.New("inbox reader prefetch-concurrency must be positive")
+	}
 	return nil
 }
 
@@ -56,6 +64,8 @@ func InboxReaderConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".target-messages-read", DefaultInboxReaderConfig.TargetMessagesRead, "if adjust-blocks-to-read is enabled, the target number of messages to read at once")
 	f.Uint64(prefix+".max-blocks-to-read", DefaultInboxReaderConfig.MaxBlocksToRead, "if adjust-blocks-to-read is enabled, the maximum number of blocks to read at once")
 	f.String(prefix+".read-mode", DefaultInboxReaderConfig.ReadMode, "mode to only read latest or safe or finalized L1 blocks. Enabling safe or finalized disables feed input and output. Defaults to latest. Takes string input, valid strings- latest, safe, finalized")
+	f.Int(prefix+".batch-cache-size", DefaultInboxReaderConfig.BatchCacheSize, "number of recently fetched sequencer batches to keep in memory")
+	f.Int(prefix+".prefetch-concurrency", DefaultInboxReaderConfig.PrefetchConcurrency, "maximum number of sequencer batches fetched concurrently when prefetching")
 }
 
 var DefaultInboxReaderConfig = InboxReaderConfig{
@@ -67,6 +77,8 @@ var DefaultInboxReaderConfig = InboxReaderConfig{
 	TargetMessagesRead:  500,
 	MaxBlocksToRead:     2000,
 	ReadMode:            "latest",
+	BatchCacheSize:      64,
+	PrefetchConcurrency: 4,
 }
 
 var TestInboxReaderConfig = InboxReaderConfig{
@@ -78,6 +90,8 @@ var TestInboxReaderConfig = InboxReaderConfig{
 	TargetMessagesRead:  500,
 	MaxBlocksToRead:     2000,
 	ReadMode:            "latest",
+	BatchCacheSize:      64,
+	PrefetchConcurrency: 4,
 }
 
 type InboxReader struct {
@@ -99,6 +113,38 @@ type InboxReader struct {
 	// Atomic
 	lastSeenBatchCount atomic.Uint64
 	lastReadBatchCount atomic.Uint64
+
+	batchCacheMutex sync.Mutex
+	batchCache      *containers.LruCache[uint64, cachedSequencerMessage]
+	prefetchSem     chan struct{}
+	// lookupBatch reads a batch from the parent chain on a batch cache miss
+	lookupBatch func(ctx context.Context, seqNum uint64, metadata BatchMetadata) ([]byte, common.Hash, error)
+
+	safeUpdate      msgCountUpdate
+	finalizedUpdate msgCountUpdate
+}
+
+// msgCountUpdate tracks when a safe or finalized message count was first seen at its current value
+type msgCountUpdate struct {
+	mutex sync.Mutex
+	info  execution.SafeMsgInfo
+}
+
+func (u *msgCountUpdate) observe(count arbutil.MessageIndex, parentChainBlock uint64) execution.SafeMsgInfo {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if u.info.UpdatedAt.IsZero() || count != u.info.Count {
+		u.info = execution.SafeMsgInfo{Count: count, UpdatedAtL1Block: parentChainBlock, UpdatedAt: time.Now()}
+	}
+	return u.info
+}
+
+// cachedSequencerMessage is only valid while the batch's accumulator matches,
+// so entries for reorged-out batches are never served.
+type cachedSequencerMessage struct {
+	accumulator common.Hash
+	data        []byte
+	blockHash   common.Hash
 }
 
 func NewInboxReader(tracker *InboxTracker, client arbutil.L1Interface, l1Reader *headerreader.HeaderReader, firstMessageBlock *big.Int, delayedBridge *DelayedBridge, sequencerInbox *SequencerInbox, config InboxReaderConfigFetcher) (*InboxReader, error) {
@@ -106,7 +152,7 @@ func NewInboxReader(tracker *InboxTracker, client arbutil.L1Interface, l1Reader
 	if err != nil {
 		return nil, err
 	}
-	return &InboxReader{
+	reader := &InboxReader{
 		tracker:           tracker,
 		delayedBridge:     delayedBridge,
 		sequencerInbox:    sequencerInbox,
@@ -115,7 +161,11 @@ func NewInboxReader(tracker *InboxTracker, client arbutil.L1Interface, l1Reader
 		firstMessageBlock: firstMessageBlock,
 		caughtUpChan:      make(chan struct{}),
 		config:            config,
-	}, nil
+		batchCache:        containers.NewLruCache[uint64, cachedSequencerMessage](config().BatchCacheSize),
+		prefetchSem:       make(chan struct{}, config().PrefetchConcurrency),
+	}
+	reader.lookupBatch = reader.lookupSequencerMessageBytes
+	return reader, nil
 }
 
 func (r *InboxReader) Start(ctxIn context.Context) error {
@@ -212,6 +262,31 @@ func (r *InboxReader) GetFinalizedMsgCount(ctx context.Context) (arbutil.Message
 	return r.recentParentChainBlockToMsg(ctx, l1block)
 }
 
+// GetSafeMsgInfo returns the safe message count, with when it was first seen at its current value
+func (r *InboxReader) GetSafeMsgInfo(ctx context.Context) (execution.SafeMsgInfo, error) {
+	l1block, err := r.l1Reader.LatestSafeBlockNr(ctx)
+	if err != nil {
+		return execution.SafeMsgInfo{}, err
+	}
+	count, err := r.recentParentChainBlockToMsg(ctx, l1block)
+	if err != nil {
+		return execution.SafeMsgInfo{}, err
+	}
+	return r.safeUpdate.observe(count, l1block), nil
+}
+
+func (r *InboxReader) GetFinalizedMsgInfo(ctx context.Context) (execution.FinalizedMsgInfo, error) {
+	l1block, err := r.l1Reader.LatestFinalizedBlockNr(ctx)
+	if err != nil {
+		return execution.FinalizedMsgInfo{}, err
+	}
+	count, err := r.recentParentChainBlockToMsg(ctx, l1block)
+	if err != nil {
+		return execution.FinalizedMsgInfo{}, err
+	}
+	return execution.FinalizedMsgInfo(r.finalizedUpdate.observe(count, l1block)), nil
+}
+
 func (r *InboxReader) Tracker() *InboxTracker {
 	return r.tracker
 }
@@ -603,11 +678,151 @@ func (r *InboxReader) getNextBlockToRead() (*big.Int, error) {
 	return msgBlock, nil
 }
 
+// GetSequencerMessageBytes returns a copy of the batch's data, so callers may modify it.
 func (r *InboxReader) GetSequencerMessageBytes(ctx context.Context, seqNum uint64) ([]byte, common.Hash, error) {
+	data, blockHash, err := r.sequencerMessageBytes(ctx, seqNum)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return common.CopyBytes(data), blockHash, nil
+}
+
+// sequencerMessageBytes returns the batch's data as cached, which must not be modified
+func (r *InboxReader) sequencerMessageBytes(ctx context.Context, seqNum uint64) ([]byte, common.Hash, error) {
 	metadata, err := r.tracker.GetBatchMetadata(seqNum)
 	if err != nil {
 		return nil, common.Hash{}, err
 	}
+	r.batchCacheMutex.Lock()
+	cached, ok := r.batchCache.Get(seqNum)
+	r.batchCacheMutex.Unlock()
+	if ok && cached.accumulator == metadata.Accumulator {
+		return cached.data, cached.blockHash, nil
+	}
+	data, blockHash, err := r.lookupBatch(ctx, seqNum, metadata)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	r.batchCacheMutex.Lock()
+	r.batchCache.Add(seqNum, cachedSequencerMessage{
+		accumulator: metadata.Accumulator,
+		data:        data,
+		blockHash:   blockHash,
+	})
+	r.batchCacheMutex.Unlock()
+	return data, blockHash, nil
+}
+
+// GetSequencerMessageSize returns the size of the batch's data from its metadata,
+// only fetching the batch if the size wasn't recorded.
+func (r *InboxReader) GetSequencerMessageSize(ctx context.Context, seqNum uint64) (uint64, error) {
+	metadata, err := r.tracker.GetBatchMetadata(seqNum)
+	if err != nil {
+		return 0, err
+	}
+	if metadata.Size > 0 {
+		return metadata.Size, nil
+	}
+	data, _, err := r.sequencerMessageBytes(ctx, seqNum)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(data)), nil
+}
+
+// GetSequencerMessageChunk returns a copy of up to length bytes of the batch's data starting at offset.
+// The batch is still read from the parent chain in full, but it goes through the batch cache,
+// so reading a batch chunk by chunk only fetches it once while callers only hold the chunks.
+func (r *InboxReader) GetSequencerMessageChunk(ctx context.Context, seqNum uint64, offset, length uint64) ([]byte, error) {
+	data, _, err := r.sequencerMessageBytes(ctx, seqNum)
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(len(data))
+	if offset >= size {
+		return nil, fmt.Errorf("%w: offset %d in batch %d of size %d", execution.ErrBatchOffsetOutOfRange, offset, seqNum, size)
+	}
+	end := size
+	if length < size-offset {
+		end = offset + length
+	}
+	chunk := make([]byte, end-offset)
+	copy(chunk, data[offset:end])
+	return chunk, nil
+}
+
+// PruneBatchesBefore prunes the batches before batchNum in the tracker, and drops their data from
+// the batch cache. The cache is the only copy of batch data the node holds, as batches are read
+// from the parent chain.
+func (r *InboxReader) PruneBatchesBefore(batchNum uint64) error {
+	if err := r.tracker.PruneBatchesBefore(batchNum); err != nil {
+		return err
+	}
+	r.batchCacheMutex.Lock()
+	defer r.batchCacheMutex.Unlock()
+	for _, seqNum := range r.batchCache.Keys() {
+		if seqNum < batchNum {
+			r.batchCache.Remove(seqNum)
+		}
+	}
+	return nil
+}
+
+func (r *InboxReader) isSequencerMessageCached(seqNum uint64) bool {
+	r.batchCacheMutex.Lock()
+	defer r.batchCacheMutex.Unlock()
+	return r.batchCache.Contains(seqNum)
+}
+
+// PrefetchSequencerMessages starts fetching batches first through last (inclusive) into the batch cache
+// in the background, and returns a promise that is ready as soon as the prefetch was scheduled.
+// The range is truncated to the size of the batch cache and to the batches known to the tracker,
+// and fetches are bounded by prefetch-concurrency across all prefetch requests.
+func (r *InboxReader) PrefetchSequencerMessages(first, last uint64) containers.PromiseInterface[struct{}] {
+	if last < first {
+		return containers.NewReadyPromise(struct{}{}, fmt.Errorf("invalid prefetch range %d-%d", first, last))
+	}
+	batchCount, err := r.tracker.GetBatchCount()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	if batchCount == 0 || first >= batchCount {
+		return containers.NewReadyPromise(struct{}{}, nil)
+	}
+	last = arbmath.MinInt(last, batchCount-1)
+	cacheSize := r.batchCache.Size()
+	if cacheSize <= 0 {
+		return containers.NewReadyPromise(struct{}{}, nil)
+	}
+	if last-first >= uint64(cacheSize) {
+		last = first + uint64(cacheSize) - 1
+	}
+	err = r.LaunchThreadSafe(func(ctx context.Context) {
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		for seqNum := first; seqNum <= last; seqNum++ {
+			if r.isSequencerMessageCached(seqNum) {
+				continue
+			}
+			select {
+			case r.prefetchSem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(seqNum uint64) {
+				defer wg.Done()
+				defer func() { <-r.prefetchSem }()
+				if _, _, err := r.sequencerMessageBytes(ctx, seqNum); err != nil && ctx.Err() == nil {
+					log.Debug("failed to prefetch sequencer batch", "seqNum", seqNum, "err", err)
+				}
+			}(seqNum)
+		}
+	})
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *InboxReader) lookupSequencerMessageBytes(ctx context.Context, seqNum uint64, metadata BatchMetadata) ([]byte, common.Hash, error) {
 	blockNum := arbmath.UintToBig(metadata.ParentChainBlock)
 	seqBatches, err := r.sequencerInbox.LookupBatchesInRange(ctx, blockNum, blockNum)
 	if err != nil {
@@ -0,0 +1,231 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// newInboxReaderWithCachedBatches returns an inbox reader that serves the given batches from its cache
+func newInboxReaderWithCachedBatches(t *testing.T, batches [][]byte) *InboxReader {
+	var metas []BatchMetadata
+	for i := range batches {
+		metas = append(metas, BatchMetadata{Accumulator: common.BigToHash(common.Big1), ParentChainBlock: uint64(i)})
+	}
+	reader := &InboxReader{
+		tracker:    newTrackerWithBatches(t, metas),
+		batchCache: containers.NewLruCache[uint64, cachedSequencerMessage](len(batches)),
+	}
+	for i, data := range batches {
+		reader.batchCache.Add(uint64(i), cachedSequencerMessage{accumulator: metas[i].Accumulator, data: data})
+	}
+	return reader
+}
+
+func TestGetSequencerMessageChunk(t *testing.T) {
+	ctx := context.Background()
+	data := []byte("0123456789")
+	reader := newInboxReaderWithCachedBatches(t, [][]byte{data})
+
+	var streamed []byte
+	for offset := uint64(0); offset < uint64(len(data)); offset += 3 {
+		chunk, err := reader.GetSequencerMessageChunk(ctx, 0, offset, 3)
+		Require(t, err)
+		if len(chunk) > 3 {
+			Fail(t, "chunk longer than requested", len(chunk))
+		}
+		streamed = append(streamed, chunk...)
+	}
+	if !bytes.Equal(streamed, data) {
+		Fail(t, "streamed chunks don't match batch data", string(streamed))
+	}
+
+	chunk, err := reader.GetSequencerMessageChunk(ctx, 0, 8, 100)
+	Require(t, err)
+	if string(chunk) != "89" {
+		Fail(t, "unexpected chunk at end of batch", string(chunk))
+	}
+	chunk[0] = 'x'
+	if data[8] != '8' {
+		Fail(t, "chunk shares memory with cached batch data")
+	}
+
+	_, err = reader.GetSequencerMessageChunk(ctx, 0, uint64(len(data)), 1)
+	if !errors.Is(err, execution.ErrBatchOffsetOutOfRange) {
+		Fail(t, "expected out of range error, got", err)
+	}
+}
+
+func TestInboxReaderPruneBatchesBefore(t *testing.T) {
+	reader := newInboxReaderWithCachedBatches(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")})
+
+	Require(t, reader.PruneBatchesBefore(2))
+	for seqNum := uint64(0); seqNum < 3; seqNum++ {
+		if reader.isSequencerMessageCached(seqNum) != (seqNum >= 2) {
+			Fail(t, "unexpected cached state of batch", seqNum, "after pruning before 2")
+		}
+	}
+	oldest, err := reader.tracker.OldestAvailableBatch()
+	Require(t, err)
+	if oldest != 2 {
+		Fail(t, "unexpected oldest available batch", oldest)
+	}
+}
+
+func TestGetSequencerMessageSize(t *testing.T) {
+	ctx := context.Background()
+	reader := newInboxReaderWithCachedBatches(t, [][]byte{[]byte("0123456789")})
+
+	// Without a recorded size, the size is taken from the (cached) batch data
+	size, err := reader.GetSequencerMessageSize(ctx, 0)
+	Require(t, err)
+	if size != 10 {
+		Fail(t, "unexpected size from batch data", size)
+	}
+
+	metas := []BatchMetadata{{Accumulator: common.BigToHash(common.Big1), Size: 1234}}
+	reader = &InboxReader{
+		tracker:    newTrackerWithBatches(t, metas),
+		batchCache: containers.NewLruCache[uint64, cachedSequencerMessage](1),
+	}
+	size, err = reader.GetSequencerMessageSize(ctx, 0)
+	Require(t, err)
+	if size != 1234 {
+		Fail(t, "unexpected size from batch metadata", size)
+	}
+
+	_, err = reader.GetSequencerMessageSize(ctx, 1)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) || notYetPostedErr.LatestPostedBatch != 0 {
+		Fail(t, "expected batch not yet posted error, got", err)
+	}
+}
+
+// countingBatchLookup serves batches as read from the parent chain, counting the reads of each
+type countingBatchLookup struct {
+	mutex sync.Mutex
+	reads map[uint64]int
+}
+
+func (l *countingBatchLookup) lookup(ctx context.Context, seqNum uint64, metadata BatchMetadata) ([]byte, common.Hash, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.reads[seqNum]++
+	return []byte(fmt.Sprintf("batch %d accumulator %v", seqNum, metadata.Accumulator)), common.Hash{byte(seqNum)}, nil
+}
+
+func (l *countingBatchLookup) readCount(seqNum uint64) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.reads[seqNum]
+}
+
+func newInboxReaderWithBatchLookup(t *testing.T, batchCount int, cacheSize int) (*InboxReader, *countingBatchLookup) {
+	metas := make([]BatchMetadata, batchCount)
+	for i := range metas {
+		metas[i] = BatchMetadata{Accumulator: common.BigToHash(common.Big1), ParentChainBlock: uint64(i)}
+	}
+	lookup := &countingBatchLookup{reads: make(map[uint64]int)}
+	reader := &InboxReader{
+		tracker:     newTrackerWithBatches(t, metas),
+		batchCache:  containers.NewLruCache[uint64, cachedSequencerMessage](cacheSize),
+		prefetchSem: make(chan struct{}, 2),
+		lookupBatch: lookup.lookup,
+	}
+	return reader, lookup
+}
+
+func TestSequencerMessageCache(t *testing.T) {
+	ctx := context.Background()
+	reader, lookup := newInboxReaderWithBatchLookup(t, 2, 2)
+
+	data, blockHash, err := reader.GetSequencerMessageBytes(ctx, 1)
+	Require(t, err)
+	if blockHash != (common.Hash{1}) {
+		Fail(t, "unexpected batch block hash", blockHash)
+	}
+	expected := string(data)
+	// Modifying the returned data doesn't change the cached batch
+	data[0] = 'x'
+	data, _, err = reader.GetSequencerMessageBytes(ctx, 1)
+	Require(t, err)
+	if string(data) != expected {
+		Fail(t, "cached batch data modified through returned data", string(data))
+	}
+	if reads := lookup.readCount(1); reads != 1 {
+		Fail(t, "batch read", reads, "times, expected to be served from the cache")
+	}
+
+	// A reorg changes the batch's accumulator, so the cached batch is read again
+	reader.tracker.batchMeta.Add(1, BatchMetadata{Accumulator: common.BigToHash(common.Big2), ParentChainBlock: 1})
+	data, _, err = reader.GetSequencerMessageBytes(ctx, 1)
+	Require(t, err)
+	if reads := lookup.readCount(1); reads != 2 {
+		Fail(t, "reorged batch read", reads, "times")
+	}
+	if string(data) == expected {
+		Fail(t, "reorged batch served from the cache", string(data))
+	}
+	_, _, err = reader.GetSequencerMessageBytes(ctx, 1)
+	Require(t, err)
+	if reads := lookup.readCount(1); reads != 2 {
+		Fail(t, "reorged batch not cached again, read", reads, "times")
+	}
+}
+
+func TestPrefetchSequencerMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, lookup := newInboxReaderWithBatchLookup(t, 5, 3)
+	reader.StopWaiter.Start(ctx, reader)
+	defer reader.StopAndWait()
+
+	_, err := reader.PrefetchSequencerMessages(2, 1).Await(ctx)
+	if err == nil {
+		Fail(t, "expected error prefetching an invalid range")
+	}
+
+	// The range is truncated to the size of the batch cache
+	_, err = reader.PrefetchSequencerMessages(1, 10).Await(ctx)
+	Require(t, err)
+	deadline := time.Now().Add(10 * time.Second)
+	for seqNum := uint64(1); seqNum <= 3; seqNum++ {
+		for !reader.isSequencerMessageCached(seqNum) {
+			if time.Now().After(deadline) {
+				Fail(t, "batch", seqNum, "not prefetched")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	for _, seqNum := range []uint64{0, 4} {
+		if reads := lookup.readCount(seqNum); reads != 0 {
+			Fail(t, "batch", seqNum, "outside the prefetched range read", reads, "times")
+		}
+	}
+
+	// Prefetched batches are served from the cache, and not prefetched again
+	_, err = reader.PrefetchSequencerMessages(1, 3).Await(ctx)
+	Require(t, err)
+	for seqNum := uint64(1); seqNum <= 3; seqNum++ {
+		_, _, err := reader.GetSequencerMessageBytes(ctx, seqNum)
+		Require(t, err)
+	}
+	reader.StopAndWait()
+	for seqNum := uint64(1); seqNum <= 3; seqNum++ {
+		if reads := lookup.readCount(seqNum); reads != 1 {
+			Fail(t, "prefetched batch", seqNum, "read", reads, "times")
+		}
+	}
+}
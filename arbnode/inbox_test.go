@@ -6,14 +6,19 @@ package arbnode
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"math/big"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/execution/gethexec"
 	"github.com/offchainlabs/nitro/statetransfer"
 
@@ -256,3 +261,278 @@ func Fail(t *testing.T, printables ...interface{}) {
 	t.Helper()
 	testhelpers.FailImpl(t, printables...)
 }
+
+// testSequencerMessage returns an empty L2 message with timestamp i, to tell messages apart
+func testSequencerMessage(i int) arbostypes.MessageWithMetadata {
+	return arbostypes.MessageWithMetadata{
+		Message: &arbostypes.L1IncomingMessage{
+			Header: &arbostypes.L1IncomingMessageHeader{
+				Kind:      arbostypes.L1MessageType_L2Message,
+				Poster:    l1pricing.BatchPosterAddress,
+				Timestamp: uint64(i),
+			},
+			L2msg: []byte{},
+		},
+		DelayedMessagesRead: 1,
+	}
+}
+
+func TestSequencerWritesAppliedInOrder(t *testing.T) {
+	_, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	pipeline, err := NewPipelinedConsensusSequencer(inbox, inbox.GetMessageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+
+	// Writing past the message count is rejected without writing anything
+	err = inbox.WriteMessageFromSequencer(start+1, testSequencerMessage(0), execution.MessageResult{})
+	if err == nil {
+		Fail(t, "expected error writing past the message count")
+	}
+
+	// Sequential writes are applied in call order
+	for i := 0; i < 4; i++ {
+		Require(t, inbox.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{}))
+	}
+
+	// Concurrent writes through the pipeline are issued in reverse, and still applied in position order
+	var wg sync.WaitGroup
+	for i := 15; i >= 4; i-- {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pipeline.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{})
+			if err != nil {
+				t.Error("pipelined write failed", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	count, err := inbox.GetMessageCount()
+	Require(t, err)
+	if count != start+16 {
+		Fail(t, "unexpected message count", count, "expected", start+16)
+	}
+	for i := 0; i < 16; i++ {
+		msg, err := inbox.GetMessage(start + arbutil.MessageIndex(i))
+		Require(t, err)
+		if msg.Message.Header.Timestamp != uint64(i) {
+			Fail(t, "message", i, "applied out of order, got timestamp", msg.Message.Header.Timestamp)
+		}
+	}
+}
+
+func TestSequencerWritesSurviveRestart(t *testing.T) {
+	_, inbox, arbDb, bc := NewTransactionStreamerForTest(t, common.Address{})
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+	for i := 0; i < 4; i++ {
+		result := execution.MessageResult{BlockHash: common.BigToHash(big.NewInt(int64(i + 1)))}
+		Require(t, inbox.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), result))
+	}
+
+	// A streamer started on the database without the first one shutting down sees every write
+	// that returned, as it would after a crash
+	configFetcher := func() *TransactionStreamerConfig { return &DefaultTransactionStreamerConfig }
+	restarted, err := NewTransactionStreamer(arbDb, bc.Config(), nil, nil, make(chan error, 1), configFetcher, &DefaultSnapSyncConfig)
+	Require(t, err)
+	count, err := restarted.GetMessageCount()
+	Require(t, err)
+	if count != start+4 {
+		Fail(t, "unexpected message count after restart", count, "expected", start+4)
+	}
+	for i := 0; i < 4; i++ {
+		pos := start + arbutil.MessageIndex(i)
+		msg, err := restarted.getMessageWithMetadataAndBlockHash(pos)
+		Require(t, err)
+		if msg.MessageWithMeta.Message.Header.Timestamp != uint64(i) {
+			Fail(t, "message", pos, "has timestamp", msg.MessageWithMeta.Message.Header.Timestamp)
+		}
+		if msg.BlockHash == nil || *msg.BlockHash != common.BigToHash(big.NewInt(int64(i+1))) {
+			Fail(t, "message", pos, "lost its block hash", msg.BlockHash)
+		}
+	}
+}
+
+func TestSequencerWriteMessageCeiling(t *testing.T) {
+	_, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	if _, ok := inbox.MessageCeiling(); ok {
+		Fail(t, "message ceiling set by default")
+	}
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+	config := DefaultTransactionStreamerConfig
+	config.MessageCeiling = uint64(start + 1)
+	inbox.config = func() *TransactionStreamerConfig { return &config }
+	if ceiling, ok := inbox.MessageCeiling(); !ok || ceiling != start+1 {
+		Fail(t, "unexpected message ceiling", ceiling, ok)
+	}
+
+	// Writes up to and including the ceiling are accepted
+	for i := 0; i < 2; i++ {
+		Require(t, inbox.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{}))
+	}
+	var ceilingErr *consensus.ErrMessageCeilingExceeded
+	err = inbox.WriteMessageFromSequencer(start+2, testSequencerMessage(2), execution.MessageResult{})
+	if !errors.As(err, &ceilingErr) || ceilingErr.Pos != start+2 || ceilingErr.Ceiling != start+1 {
+		Fail(t, "expected message ceiling exceeded error, got", err)
+	}
+	count, err := inbox.GetMessageCount()
+	Require(t, err)
+	if count != start+2 {
+		Fail(t, "write beyond the ceiling changed the message count to", count)
+	}
+	if backlog := inbox.SequencerWriteBacklog(); backlog.PendingWrites != 0 || backlog.PendingBytes != 0 {
+		Fail(t, "write beyond the ceiling left a backlog", backlog)
+	}
+}
+
+func TestComputeMessageL1FeeWithoutParentChain(t *testing.T) {
+	_, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	if _, err := inbox.ComputeMessageL1Fee(testSequencerMessage(0)).Await(context.Background()); err == nil {
+		Fail(t, "message fee estimated without a parent chain reader")
+	}
+}
+
+func TestIdempotentSequencerWrites(t *testing.T) {
+	_, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	pipeline, err := NewPipelinedConsensusSequencer(inbox, inbox.GetMessageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+	Require(t, inbox.WriteMessageFromSequencerIdempotent(start, testSequencerMessage(0), execution.MessageResult{}, "first"))
+
+	// Retrying with the same key, directly or through the pipeline, doesn't write again
+	Require(t, inbox.WriteMessageFromSequencerIdempotent(start, testSequencerMessage(0), execution.MessageResult{}, "first"))
+	Require(t, pipeline.WriteMessageFromSequencerIdempotent(start, testSequencerMessage(0), execution.MessageResult{}, "first"))
+	count, err := inbox.GetMessageCount()
+	Require(t, err)
+	if count != start+1 {
+		Fail(t, "retried write applied again, message count", count, "expected", start+1)
+	}
+
+	// Reusing the key for a different message or position conflicts
+	err = inbox.WriteMessageFromSequencerIdempotent(start, testSequencerMessage(1), execution.MessageResult{}, "first")
+	if !errors.Is(err, execution.ErrIdempotencyConflict) {
+		Fail(t, "expected idempotency conflict for a different message, got", err)
+	}
+	err = pipeline.WriteMessageFromSequencerIdempotent(start+1, testSequencerMessage(0), execution.MessageResult{}, "first")
+	if !errors.Is(err, execution.ErrIdempotencyConflict) {
+		Fail(t, "expected idempotency conflict for a different position, got", err)
+	}
+
+	// A repeated write with a different key is a plain conflicting write
+	err = inbox.WriteMessageFromSequencerIdempotent(start, testSequencerMessage(0), execution.MessageResult{}, "second")
+	var conflictErr *consensus.ErrConflictingMessage
+	if !errors.As(err, &conflictErr) {
+		Fail(t, "expected conflicting message, got", err)
+	}
+	Require(t, pipeline.WriteMessageFromSequencerIdempotent(start+1, testSequencerMessage(1), execution.MessageResult{}, "second"))
+
+	// Reorgs forget the keys
+	Require(t, inbox.ReorgTo(start+1))
+	Require(t, inbox.WriteMessageFromSequencerIdempotent(start+1, testSequencerMessage(2), execution.MessageResult{}, "second"))
+	msg, err := inbox.GetMessage(start + 1)
+	Require(t, err)
+	if msg.Message.Header.Timestamp != 2 {
+		Fail(t, "write after reorg not applied, got timestamp", msg.Message.Header.Timestamp)
+	}
+}
+
+func TestSequencerWriteObservers(t *testing.T) {
+	exec, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observed := make(chan arbutil.MessageIndex, 8)
+	inbox.RegisterWriteObserver(func(pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata) {
+		observed <- pos
+	})
+	inbox.RegisterWriteObserver(func(pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata) {
+		panic("observers can't fail writes")
+	})
+	Require(t, inbox.Start(ctx))
+	exec.Start(ctx)
+
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+	for i := 0; i < 4; i++ {
+		Require(t, inbox.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{}))
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case pos := <-observed:
+			if pos != start+arbutil.MessageIndex(i) {
+				Fail(t, "observer called out of order, got pos", pos, "expected", start+arbutil.MessageIndex(i))
+			}
+		case <-time.After(time.Second * 10):
+			Fail(t, "timed out waiting for observer")
+		}
+	}
+}
+
+func TestStuckSequencerWriteObserver(t *testing.T) {
+	exec, inbox, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	config := DefaultTransactionStreamerConfig
+	config.WriteObserverTimeout = 20 * time.Millisecond
+	inbox.config = func() *TransactionStreamerConfig { return &config }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start, err := inbox.GetMessageCount()
+	Require(t, err)
+	release := make(chan struct{})
+	stuckObserved := make(chan arbutil.MessageIndex, 8)
+	inbox.RegisterWriteObserver(func(pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata) {
+		if pos == start {
+			<-release
+		}
+		stuckObserved <- pos
+	})
+	Require(t, inbox.Start(ctx))
+	exec.Start(ctx)
+	// Observers registered after starting are run too
+	observed := make(chan arbutil.MessageIndex, 8)
+	inbox.RegisterWriteObserver(func(pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata) {
+		observed <- pos
+	})
+
+	// A stuck observer doesn't delay the others
+	for i := 0; i < 4; i++ {
+		Require(t, inbox.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{}))
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case pos := <-observed:
+			if pos != start+arbutil.MessageIndex(i) {
+				Fail(t, "observer called out of order, got pos", pos, "expected", start+arbutil.MessageIndex(i))
+			}
+		case <-time.After(time.Second * 10):
+			Fail(t, "observer delayed by a stuck observer")
+		}
+	}
+
+	// Once the stuck observer returns, it's called with new writes, and the writes queued while
+	// it was stuck past its timeout were dropped
+	time.Sleep(config.WriteObserverTimeout * 5)
+	close(release)
+	if pos := <-stuckObserved; pos != start {
+		Fail(t, "unexpected first write observed", pos)
+	}
+	for i := 4; i < 100; i++ {
+		pos := start + arbutil.MessageIndex(i)
+		Require(t, inbox.WriteMessageFromSequencer(pos, testSequencerMessage(i), execution.MessageResult{}))
+		select {
+		case observedPos := <-stuckObserved:
+			if observedPos < start+4 || observedPos > pos {
+				Fail(t, "stuck observer called with dropped write", observedPos)
+			}
+			return
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+	Fail(t, "stuck observer not called after it returned")
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcaster"
 	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/staker"
 	"github.com/offchainlabs/nitro/util/containers"
 )
@@ -43,6 +45,13 @@ type InboxTracker struct {
 
 	batchMetaMutex sync.Mutex
 	batchMeta      *containers.LruCache[uint64, BatchMetadata]
+	batchRanges    *containers.LruCache[uint64, execution.MessageRange] // protected by batchMetaMutex
+	pruneState     batchPruneState                                      // protected by batchMetaMutex
+}
+
+// batchPruneState records which batches had their data pruned. Their metadata is kept.
+type batchPruneState struct {
+	OldestBatch uint64
 }
 
 func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, dapReaders []daprovider.Reader, snapSyncConfig SnapSyncConfig) (*InboxTracker, error) {
@@ -51,6 +60,7 @@ func NewInboxTracker(db ethdb.Database, txStreamer *TransactionStreamer, dapRead
 		txStreamer:     txStreamer,
 		dapReaders:     dapReaders,
 		batchMeta:      containers.NewLruCache[uint64, BatchMetadata](1000),
+		batchRanges:    containers.NewLruCache[uint64, execution.MessageRange](1000),
 		snapSyncConfig: snapSyncConfig,
 	}
 	return tracker, nil
@@ -99,6 +109,23 @@ func (t *InboxTracker) Initialize() error {
 		return err
 	}
 
+	hasKey, err = t.db.Has(batchPruneStateKey)
+	if err != nil {
+		return err
+	}
+	if hasKey {
+		data, err := t.db.Get(batchPruneStateKey)
+		if err != nil {
+			return err
+		}
+		t.batchMetaMutex.Lock()
+		defer t.batchMetaMutex.Unlock()
+		err = rlp.DecodeBytes(data, &t.pruneState)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -117,6 +144,7 @@ func (t *InboxTracker) deleteBatchMetadataStartingAt(dbBatch ethdb.Batch, startI
 		}
 		curIndex := binary.BigEndian.Uint64(bytes.TrimPrefix(curKey, sequencerBatchMetaPrefix))
 		t.batchMeta.Remove(curIndex)
+		t.batchRanges.Remove(curIndex)
 	}
 	return iter.Error()
 }
@@ -167,11 +195,17 @@ type BatchMetadata struct {
 	MessageCount        arbutil.MessageIndex
 	DelayedMessageCount uint64
 	ParentChainBlock    uint64
+	// Size of the batch's data, zero if not known because the batch was added before it was recorded
+	Size uint64 `rlp:"optional"`
 }
 
 func (t *InboxTracker) GetBatchMetadata(seqNum uint64) (BatchMetadata, error) {
 	t.batchMetaMutex.Lock()
 	defer t.batchMetaMutex.Unlock()
+	return t.getBatchMetadataLocked(seqNum)
+}
+
+func (t *InboxTracker) getBatchMetadataLocked(seqNum uint64) (BatchMetadata, error) {
 	metadata, exist := t.batchMeta.Get(seqNum)
 	if exist {
 		return metadata, nil
@@ -182,7 +216,18 @@ func (t *InboxTracker) GetBatchMetadata(seqNum uint64) (BatchMetadata, error) {
 		return BatchMetadata{}, err
 	}
 	if !hasKey {
-		return BatchMetadata{}, fmt.Errorf("%w: no metadata for batch %d", AccumulatorNotFoundErr, seqNum)
+		count, err := t.GetBatchCount()
+		if err != nil {
+			return BatchMetadata{}, err
+		}
+		if seqNum >= count {
+			var latest uint64
+			if count > 0 {
+				latest = count - 1
+			}
+			return BatchMetadata{}, fmt.Errorf("%w: %w", AccumulatorNotFoundErr, &execution.ErrBatchNotYetPosted{BatchNum: seqNum, LatestPostedBatch: latest})
+		}
+		return BatchMetadata{}, fmt.Errorf("%w: %w", AccumulatorNotFoundErr, &execution.ErrBatchNotFound{BatchNum: seqNum})
 	}
 	data, err := t.db.Get(key)
 	if err != nil {
@@ -196,6 +241,39 @@ func (t *InboxTracker) GetBatchMetadata(seqNum uint64) (BatchMetadata, error) {
 	return metadata, nil
 }
 
+// GetBatchMessageRange returns the messages batch seqNum contains, starting at the previous
+// batch's message count.
+func (t *InboxTracker) GetBatchMessageRange(seqNum uint64) (execution.MessageRange, error) {
+	t.batchMetaMutex.Lock()
+	defer t.batchMetaMutex.Unlock()
+	if messageRange, ok := t.batchRanges.Get(seqNum); ok {
+		return messageRange, nil
+	}
+	metadata, err := t.getBatchMetadataLocked(seqNum)
+	if err != nil {
+		return execution.MessageRange{}, err
+	}
+	var start arbutil.MessageIndex
+	if seqNum > 0 {
+		prev, err := t.getBatchMetadataLocked(seqNum - 1)
+		if err != nil {
+			return execution.MessageRange{}, err
+		}
+		start = prev.MessageCount
+	}
+	messageRange := execution.MessageRange{Start: start, End: metadata.MessageCount}
+	t.batchRanges.Add(seqNum, messageRange)
+	return messageRange, nil
+}
+
+// InvalidateBatchMessageRange drops the cached message range of batch seqNum. Reorgs already drop
+// the ranges of the batches they remove.
+func (t *InboxTracker) InvalidateBatchMessageRange(seqNum uint64) {
+	t.batchMetaMutex.Lock()
+	defer t.batchMetaMutex.Unlock()
+	t.batchRanges.Remove(seqNum)
+}
+
 func (t *InboxTracker) GetBatchMessageCount(seqNum uint64) (arbutil.MessageIndex, error) {
 	metadata, err := t.GetBatchMetadata(seqNum)
 	return metadata.MessageCount, err
@@ -225,6 +303,62 @@ func (t *InboxTracker) GetBatchCount() (uint64, error) {
 	return count, nil
 }
 
+func (t *InboxTracker) getPruneState() batchPruneState {
+	t.batchMetaMutex.Lock()
+	defer t.batchMetaMutex.Unlock()
+	return t.pruneState
+}
+
+func (t *InboxTracker) OldestAvailableBatch() (uint64, error) {
+	return t.getPruneState().OldestBatch, nil
+}
+
+// PruneBatchesBefore prunes the data of all batches before batchNum, so fetching it fails with
+// ErrBatchPruned. The tracker doesn't store batch data, so this only records the cutoff, see
+// InboxReader.PruneBatchesBefore for dropping cached data. Their metadata and accumulators are
+// kept, as the inbox reader, the validators and message lookups still need them, so nothing but
+// fetches of batch data has to handle pruned batches.
+func (t *InboxTracker) PruneBatchesBefore(batchNum uint64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	batchCount, err := t.GetBatchCount()
+	if err != nil {
+		return err
+	}
+	if batchNum >= batchCount {
+		return fmt.Errorf("cannot prune batches before %d with batch count %d, the latest batch must be kept", batchNum, batchCount)
+	}
+	if batchNum <= t.getPruneState().OldestBatch {
+		return nil
+	}
+	newState := batchPruneState{OldestBatch: batchNum}
+	stateData, err := rlp.EncodeToBytes(newState)
+	if err != nil {
+		return err
+	}
+
+	t.batchMetaMutex.Lock()
+	defer t.batchMetaMutex.Unlock()
+	if err := t.db.Put(batchPruneStateKey, stateData); err != nil {
+		return err
+	}
+	t.pruneState = newState
+	log.Info("InboxTracker", "prunedBatchesBefore", batchNum)
+	return nil
+}
+
+// checkBatchDataAvailable fails with ErrBatchPruned if the data of batch seqNum was pruned.
+// A fetch that passed the check while the batch is pruned still returns the batch's data,
+// as pruning keeps the metadata the fetch reads it with.
+func (t *InboxTracker) checkBatchDataAvailable(seqNum uint64) error {
+	oldest := t.getPruneState().OldestBatch
+	if seqNum < oldest {
+		return &execution.ErrBatchPruned{OldestAvailable: oldest}
+	}
+	return nil
+}
+
 // err will return unexpected/internal errors
 // bool will be false if batch not found (meaning, block not yet posted on a batch)
 func (t *InboxTracker) FindInboxBatchContainingMessage(pos arbutil.MessageIndex) (uint64, bool, error) {
@@ -273,6 +407,149 @@ func (t *InboxTracker) FindInboxBatchContainingMessage(pos arbutil.MessageIndex)
 	}
 }
 
+// firstBatchAtOrAfterParentChainBlock returns the first batch posted in parentChainBlock or later,
+// or batchCount if there's none.
+func (t *InboxTracker) firstBatchAtOrAfterParentChainBlock(parentChainBlock uint64, batchCount uint64) (uint64, error) {
+	var searchErr error
+	offset := sort.Search(int(batchCount), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		block, err := t.GetBatchParentChainBlock(uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return block >= parentChainBlock
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return uint64(offset), nil
+}
+
+// FindBatchesInParentChainRange returns the sequence numbers of the batches posted in
+// parent chain blocks firstBlock through lastBlock (inclusive), which may be empty.
+// It relies on batches being posted in non-decreasing parent chain block order.
+func (t *InboxTracker) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) ([]uint64, error) {
+	batches := []uint64{}
+	if lastBlock < firstBlock {
+		return batches, nil
+	}
+	batchCount, err := t.GetBatchCount()
+	if err != nil {
+		return nil, err
+	}
+	start, err := t.firstBatchAtOrAfterParentChainBlock(firstBlock, batchCount)
+	if err != nil {
+		return nil, err
+	}
+	for seqNum := start; seqNum < batchCount; seqNum++ {
+		block, err := t.GetBatchParentChainBlock(seqNum)
+		if err != nil {
+			return nil, err
+		}
+		if block > lastBlock {
+			break
+		}
+		batches = append(batches, seqNum)
+	}
+	return batches, nil
+}
+
+// MappingInconsistency is a message whose batch, as found by FindInboxBatchContainingMessage,
+// doesn't line up with the batch metadata.
+type MappingInconsistency struct {
+	Message arbutil.MessageIndex
+	Batch   uint64
+	Found   bool
+	Reason  string
+}
+
+// VerifyMessageBatchMapping checks that every message from first through last (inclusive) is
+// mapped to a batch whose message range contains it, and that batch parent chain blocks don't
+// decrease. It returns the inconsistencies found, which is empty if everything is consistent.
+// This reads the metadata of every batch in the range,
+// so it's meant for debugging and testing.
+func (t *InboxTracker) VerifyMessageBatchMapping(first, last arbutil.MessageIndex) ([]MappingInconsistency, error) {
+	if last < first {
+		return nil, fmt.Errorf("invalid message range %d to %d", first, last)
+	}
+	inconsistencies := []MappingInconsistency{}
+	batchCount, err := t.GetBatchCount()
+	if err != nil {
+		return nil, err
+	}
+	if batchCount == 0 {
+		return inconsistencies, nil
+	}
+	batchedCount, err := t.GetBatchMessageCount(batchCount - 1)
+	if err != nil {
+		return nil, err
+	}
+	checkedBatches := make(map[uint64]struct{})
+	for pos := first; ; pos++ {
+		batch, found, err := t.FindInboxBatchContainingMessage(pos)
+		if err != nil {
+			return nil, err
+		}
+		reason, err := t.checkMessageBatch(pos, batch, found, batchCount, batchedCount, checkedBatches)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			inconsistencies = append(inconsistencies, MappingInconsistency{Message: pos, Batch: batch, Found: found, Reason: reason})
+		}
+		if pos == last {
+			return inconsistencies, nil
+		}
+	}
+}
+
+// checkMessageBatch returns why the batch found for pos is inconsistent, or an empty string if it isn't
+func (t *InboxTracker) checkMessageBatch(pos arbutil.MessageIndex, batch uint64, found bool, batchCount uint64, batchedCount arbutil.MessageIndex, checkedBatches map[uint64]struct{}) (string, error) {
+	if !found {
+		if pos < batchedCount {
+			return fmt.Sprintf("no batch found, but the %d batches contain %d messages", batchCount, batchedCount), nil
+		}
+		return "", nil
+	}
+	if batch >= batchCount {
+		return fmt.Sprintf("batch beyond batch count %d", batchCount), nil
+	}
+	var start arbutil.MessageIndex
+	if batch > 0 {
+		var err error
+		start, err = t.GetBatchMessageCount(batch - 1)
+		if err != nil {
+			return "", err
+		}
+	}
+	end, err := t.GetBatchMessageCount(batch)
+	if err != nil {
+		return "", err
+	}
+	if pos < start || pos >= end {
+		return fmt.Sprintf("batch contains messages %d up to %d", start, end), nil
+	}
+	if _, checked := checkedBatches[batch]; checked || batch == 0 {
+		return "", nil
+	}
+	checkedBatches[batch] = struct{}{}
+	block, err := t.GetBatchParentChainBlock(batch)
+	if err != nil {
+		return "", err
+	}
+	prevBlock, err := t.GetBatchParentChainBlock(batch - 1)
+	if err != nil {
+		return "", err
+	}
+	if block < prevBlock {
+		return fmt.Sprintf("batch posted in parent chain block %d, before the previous batch's block %d", block, prevBlock), nil
+	}
+	return "", nil
+}
+
 func (t *InboxTracker) PopulateFeedBacklog(broadcastServer *broadcaster.Broadcaster) error {
 	batchCount, err := t.GetBatchCount()
 	if err != nil {
@@ -386,6 +663,65 @@ func (t *InboxTracker) GetDelayedMessageBytes(seqNum uint64) ([]byte, error) {
 	return msg.Serialize()
 }
 
+// BatchPostingReportMessage is the posting report of a batch, as read from the delayed inbox
+type BatchPostingReportMessage struct {
+	DelayedSeqNum    uint64
+	Message          *arbostypes.L1IncomingMessage
+	ParentChainBlock uint64
+}
+
+// FindBatchPostingReports returns the posting reports of batches first through last, with a single
+// scan of the delayed inbox. The transaction posting a batch adds its report to the delayed inbox
+// after the delayed messages the batch reads, so they're searched for from those of batch first.
+// The reports not read from the parent chain yet are nil.
+func (t *InboxTracker) FindBatchPostingReports(first, last uint64) ([]*BatchPostingReportMessage, error) {
+	if first == 0 {
+		return nil, errors.New("batch 0 has no posting report")
+	}
+	if last < first {
+		return nil, fmt.Errorf("invalid batch range %d to %d", first, last)
+	}
+	metadata, err := t.GetBatchMetadata(first)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.GetBatchMetadata(last); err != nil {
+		return nil, err
+	}
+	delayedCount, err := t.GetDelayedCount()
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*BatchPostingReportMessage, last-first+1)
+	// next is the batch whose report comes next, as reports are added in batch order
+	next := first
+	for delayedSeqNum := metadata.DelayedMessageCount; delayedSeqNum < delayedCount; delayedSeqNum++ {
+		msg, _, parentChainBlock, err := t.GetDelayedMessageAccumulatorAndParentChainBlockNumber(delayedSeqNum)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Header.Kind != arbostypes.L1MessageType_BatchPostingReport {
+			continue
+		}
+		_, _, _, reportedBatch, _, _, err := arbostypes.ParseBatchPostingReportMessageFields(bytes.NewReader(msg.L2msg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse batch posting report %d: %w", delayedSeqNum, err)
+		}
+		if reportedBatch < next {
+			continue
+		}
+		if reportedBatch > next {
+			return nil, fmt.Errorf("no posting report for batch %d before the report of batch %d", next, reportedBatch)
+		}
+		reports[next-first] = &BatchPostingReportMessage{DelayedSeqNum: delayedSeqNum, Message: msg, ParentChainBlock: parentChainBlock}
+		if next == last {
+			break
+		}
+		next++
+	}
+	return reports, nil
+}
+
 func (t *InboxTracker) AddDelayedMessages(messages []*DelayedInboxMessage, hardReorg bool) error {
 	var nextAcc common.Hash
 	firstDelayedMsgToKeep := uint64(0)
@@ -733,11 +1069,17 @@ func (t *InboxTracker) AddSequencerBatches(ctx context.Context, client arbutil.L
 	lastBatchMeta := prevbatchmeta
 	batchMetas := make(map[uint64]BatchMetadata, len(batches))
 	for _, batch := range batches {
+		// Already serialized by the multiplexer, so this doesn't fetch anything
+		data, err := batch.Serialize(ctx, client)
+		if err != nil {
+			return err
+		}
 		meta := BatchMetadata{
 			Accumulator:         batch.AfterInboxAcc,
 			DelayedMessageCount: batch.AfterDelayedCount,
 			MessageCount:        batchMessageCounts[batch.SequenceNumber],
 			ParentChainBlock:    batch.ParentChainBlockNumber,
+			Size:                uint64(len(data)),
 		}
 		batchMetas[batch.SequenceNumber] = meta
 		metaBytes, err := rlp.EncodeToBytes(meta)
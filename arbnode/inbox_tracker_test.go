@@ -1,9 +1,16 @@
 package arbnode
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/containers"
 )
 
@@ -11,8 +18,9 @@ func TestDeleteBatchMetadata(t *testing.T) {
 	testBytes := []byte("bloop")
 
 	tracker := &InboxTracker{
-		db:        rawdb.NewMemoryDatabase(),
-		batchMeta: containers.NewLruCache[uint64, BatchMetadata](100),
+		db:          rawdb.NewMemoryDatabase(),
+		batchMeta:   containers.NewLruCache[uint64, BatchMetadata](100),
+		batchRanges: containers.NewLruCache[uint64, execution.MessageRange](100),
 	}
 
 	for i := uint64(0); i < 30; i += 1 {
@@ -56,3 +64,280 @@ func TestDeleteBatchMetadata(t *testing.T) {
 	}
 
 }
+
+func newTrackerWithBatches(t *testing.T, metas []BatchMetadata) *InboxTracker {
+	tracker := &InboxTracker{
+		db:          rawdb.NewMemoryDatabase(),
+		batchMeta:   containers.NewLruCache[uint64, BatchMetadata](100),
+		batchRanges: containers.NewLruCache[uint64, execution.MessageRange](100),
+	}
+	for i, meta := range metas {
+		metaBytes, err := rlp.EncodeToBytes(meta)
+		Require(t, err)
+		Require(t, tracker.db.Put(dbKey(sequencerBatchMetaPrefix, uint64(i)), metaBytes))
+	}
+	countData, err := rlp.EncodeToBytes(uint64(len(metas)))
+	Require(t, err)
+	Require(t, tracker.db.Put(sequencerBatchCountKey, countData))
+	return tracker
+}
+
+func TestGetBatchMetadataErrors(t *testing.T) {
+	tracker := newTrackerWithBatches(t, make([]BatchMetadata, 3))
+	Require(t, tracker.db.Delete(dbKey(sequencerBatchMetaPrefix, 1)))
+
+	_, err := tracker.GetBatchMetadata(1)
+	var notFoundErr *execution.ErrBatchNotFound
+	if !errors.As(err, &notFoundErr) || notFoundErr.BatchNum != 1 {
+		Fail(t, "expected batch not found for missing metadata, got", err)
+	}
+	_, err = tracker.GetBatchMetadata(5)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) || notYetPostedErr.BatchNum != 5 || notYetPostedErr.LatestPostedBatch != 2 {
+		Fail(t, "expected batch not yet posted beyond the batch count, got", err)
+	}
+	if errors.As(err, &notFoundErr) || !errors.Is(err, AccumulatorNotFoundErr) {
+		Fail(t, "unexpected error types for batch not yet posted", err)
+	}
+}
+
+func TestFindBatchesInParentChainRange(t *testing.T) {
+	blocks := []uint64{10, 10, 12, 15, 15, 15, 20}
+	var metas []BatchMetadata
+	for i, block := range blocks {
+		metas = append(metas, BatchMetadata{
+			MessageCount:     arbutil.MessageIndex(i + 1),
+			ParentChainBlock: block,
+		})
+	}
+	tracker := newTrackerWithBatches(t, metas)
+
+	checkRange := func(first, last uint64, expected []uint64) {
+		t.Helper()
+		batches, err := tracker.FindBatchesInParentChainRange(first, last)
+		Require(t, err)
+		if batches == nil {
+			Fail(t, "nil result for range", first, last)
+		}
+		if len(batches) != len(expected) {
+			Fail(t, "unexpected batches for range", first, last, "got", batches, "expected", expected)
+		}
+		for i := range expected {
+			if batches[i] != expected[i] {
+				Fail(t, "unexpected batches for range", first, last, "got", batches, "expected", expected)
+			}
+		}
+	}
+	checkRange(0, 9, nil)
+	checkRange(10, 10, []uint64{0, 1})
+	checkRange(11, 14, []uint64{2})
+	checkRange(13, 14, nil)
+	checkRange(12, 15, []uint64{2, 3, 4, 5})
+	checkRange(0, 100, []uint64{0, 1, 2, 3, 4, 5, 6})
+	checkRange(21, 30, nil)
+	checkRange(20, 10, nil)
+}
+
+func TestPruneBatchesBefore(t *testing.T) {
+	var metas []BatchMetadata
+	for i := 0; i < 10; i++ {
+		metas = append(metas, BatchMetadata{
+			Accumulator:      common.Hash{byte(i + 1)},
+			MessageCount:     arbutil.MessageIndex((i + 1) * 10),
+			ParentChainBlock: uint64(100 + i),
+		})
+	}
+	tracker := newTrackerWithBatches(t, metas)
+
+	Require(t, tracker.PruneBatchesBefore(4))
+	oldest, err := tracker.OldestAvailableBatch()
+	Require(t, err)
+	if oldest != 4 {
+		Fail(t, "unexpected oldest available batch", oldest)
+	}
+
+	// Only the data of pruned batches is unavailable
+	err = tracker.checkBatchDataAvailable(3)
+	var prunedErr *execution.ErrBatchPruned
+	if !errors.As(err, &prunedErr) || prunedErr.OldestAvailable != 4 {
+		Fail(t, "expected batch pruned error, got", err)
+	}
+	Require(t, tracker.checkBatchDataAvailable(4))
+
+	// The metadata and accumulators of pruned batches are kept
+	for i := uint64(0); i < 4; i++ {
+		acc, err := tracker.GetBatchAcc(i)
+		Require(t, err)
+		if acc != metas[i].Accumulator {
+			Fail(t, "unexpected accumulator of pruned batch", i, acc)
+		}
+	}
+	// Message 39 is the last message of batch 3
+	batch, found, err := tracker.FindInboxBatchContainingMessage(39)
+	Require(t, err)
+	if !found || batch != 3 {
+		Fail(t, "unexpected batch for a message of a pruned batch", batch, found)
+	}
+	batches, err := tracker.FindBatchesInParentChainRange(103, 105)
+	Require(t, err)
+	if len(batches) != 3 || batches[0] != 3 || batches[2] != 5 {
+		Fail(t, "unexpected batches of a range including pruned batches", batches)
+	}
+	inconsistencies, err := tracker.VerifyMessageBatchMapping(0, 99)
+	Require(t, err)
+	if len(inconsistencies) != 0 {
+		Fail(t, "unexpected inconsistencies after pruning", inconsistencies)
+	}
+
+	// Pruning is idempotent and must keep the latest batch
+	Require(t, tracker.PruneBatchesBefore(2))
+	if err := tracker.PruneBatchesBefore(10); err == nil {
+		Fail(t, "expected error pruning the latest batch")
+	}
+
+	// The prune state survives a restart
+	restarted := &InboxTracker{
+		db:        tracker.db,
+		batchMeta: containers.NewLruCache[uint64, BatchMetadata](100),
+	}
+	Require(t, restarted.Initialize())
+	oldest, err = restarted.OldestAvailableBatch()
+	Require(t, err)
+	if oldest != 4 {
+		Fail(t, "prune state not persisted, oldest available batch", oldest)
+	}
+}
+
+// The inbox reader and the validators read the metadata of old batches, e.g. to check
+// accumulators on reorgs, so pruning while they do mustn't fail their reads.
+func TestPruneBatchesDuringReads(t *testing.T) {
+	var metas []BatchMetadata
+	for i := 0; i < 50; i++ {
+		metas = append(metas, BatchMetadata{
+			Accumulator:      common.Hash{byte(i + 1)},
+			MessageCount:     arbutil.MessageIndex((i + 1) * 2),
+			ParentChainBlock: uint64(100 + i),
+		})
+	}
+	tracker := newTrackerWithBatches(t, metas)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	done := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				for i := uint64(0); i < uint64(len(metas)); i++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					acc, err := tracker.GetBatchAcc(i)
+					if err == nil && acc != metas[i].Accumulator {
+						err = fmt.Errorf("batch %d has accumulator %v", i, acc)
+					}
+					if err == nil {
+						var found bool
+						_, found, err = tracker.FindInboxBatchContainingMessage(arbutil.MessageIndex(i * 2))
+						if err == nil && !found {
+							err = fmt.Errorf("no batch found for message %d", i*2)
+						}
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	for batch := uint64(1); batch < uint64(len(metas)); batch++ {
+		Require(t, tracker.PruneBatchesBefore(batch))
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		Fail(t, "read failed while pruning", err)
+	}
+	if err := tracker.checkBatchDataAvailable(uint64(len(metas)) - 2); err == nil {
+		Fail(t, "data of a pruned batch available")
+	}
+}
+
+func TestVerifyMessageBatchMapping(t *testing.T) {
+	metas := []BatchMetadata{
+		{MessageCount: 2, ParentChainBlock: 10},
+		{MessageCount: 5, ParentChainBlock: 11},
+		{MessageCount: 6, ParentChainBlock: 11},
+	}
+	tracker := newTrackerWithBatches(t, metas)
+	// Messages past the last batch aren't batched yet, which is consistent
+	inconsistencies, err := tracker.VerifyMessageBatchMapping(0, 8)
+	Require(t, err)
+	if len(inconsistencies) != 0 {
+		Fail(t, "unexpected inconsistencies", inconsistencies)
+	}
+
+	// A batch whose message count went backwards, and that was posted before the previous batch
+	metas = append(metas, BatchMetadata{MessageCount: 4, ParentChainBlock: 9}, BatchMetadata{MessageCount: 8, ParentChainBlock: 12})
+	tracker = newTrackerWithBatches(t, metas)
+	inconsistencies, err = tracker.VerifyMessageBatchMapping(0, 7)
+	Require(t, err)
+	if len(inconsistencies) == 0 {
+		Fail(t, "corrupted batch metadata not detected")
+	}
+	for _, inconsistency := range inconsistencies {
+		if inconsistency.Reason == "" {
+			Fail(t, "inconsistency without a reason", inconsistency)
+		}
+	}
+}
+
+func TestGetBatchMessageRange(t *testing.T) {
+	metas := []BatchMetadata{
+		{MessageCount: 1, ParentChainBlock: 10},
+		{MessageCount: 5, ParentChainBlock: 11},
+		{MessageCount: 5, ParentChainBlock: 12},
+		{MessageCount: 9, ParentChainBlock: 13},
+	}
+	tracker := newTrackerWithBatches(t, metas)
+	var prev execution.MessageRange
+	for seqNum := range metas {
+		messageRange, err := tracker.GetBatchMessageRange(uint64(seqNum))
+		Require(t, err)
+		if messageRange.End != metas[seqNum].MessageCount || messageRange.Start != prev.End {
+			Fail(t, "batch", seqNum, "range", messageRange, "isn't contiguous with previous range", prev)
+		}
+		prev = messageRange
+	}
+	_, err := tracker.GetBatchMessageRange(uint64(len(metas)))
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) {
+		Fail(t, "expected batch not yet posted error, got", err)
+	}
+
+	// Deleting batches in a reorg drops their cached ranges
+	batch := tracker.db.NewBatch()
+	Require(t, tracker.deleteBatchMetadataStartingAt(batch, 3))
+	Require(t, batch.Write())
+	if tracker.batchRanges.Contains(3) || !tracker.batchRanges.Contains(2) {
+		Fail(t, "reorg didn't drop exactly the deleted batches' cached ranges")
+	}
+	tracker.InvalidateBatchMessageRange(2)
+	if tracker.batchRanges.Contains(2) {
+		Fail(t, "range still cached after invalidating it")
+	}
+
+	// Pruning keeps the metadata message ranges are computed from
+	tracker = newTrackerWithBatches(t, metas)
+	Require(t, tracker.PruneBatchesBefore(2))
+	messageRange, err := tracker.GetBatchMessageRange(2)
+	Require(t, err)
+	if messageRange.Start != 5 || messageRange.End != 5 {
+		Fail(t, "unexpected range of the oldest batch after pruning", messageRange)
+	}
+}
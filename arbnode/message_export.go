@@ -0,0 +1,175 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// The export format is messageExportMagic followed by one record per message: the message
+// position as a big endian uint64, the length of the message as a big endian uint32, and the
+// message, RLP encoded as it's stored in the database. Positions are consecutive.
+var messageExportMagic = []byte("NITROMSGv1\n")
+
+const maxExportedMessageSize = 64 * 1024 * 1024
+
+// importMessagesChunk is how many imported messages are added at once
+const importMessagesChunk = 1024
+
+var ErrInvalidMessageExport = errors.New("invalid message export")
+
+func writeExportedMessage(w io.Writer, pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) error {
+	msgBytes, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	var header [12]byte
+	binary.BigEndian.PutUint64(header[:8], uint64(pos))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(msgBytes)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(msgBytes)
+	return err
+}
+
+// ReadExportedMessages reads the messages written by ExportMessages, calling fn for each one in order.
+// It returns how many messages were read, and fails with ErrInvalidMessageExport if r was truncated
+// or isn't an export.
+func ReadExportedMessages(r io.Reader, fn func(pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) error) (uint64, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(messageExportMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, messageExportMagic) {
+		return 0, fmt.Errorf("%w: missing header", ErrInvalidMessageExport)
+	}
+	var count uint64
+	var nextPos arbutil.MessageIndex
+	for {
+		var header [12]byte
+		_, err := io.ReadFull(br, header[:])
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("%w: truncated after %d messages", ErrInvalidMessageExport, count)
+		}
+		pos := arbutil.MessageIndex(binary.BigEndian.Uint64(header[:8]))
+		size := binary.BigEndian.Uint32(header[8:])
+		if count > 0 && pos != nextPos {
+			return count, fmt.Errorf("%w: message %d follows message %d", ErrInvalidMessageExport, pos, nextPos-1)
+		}
+		if size > maxExportedMessageSize {
+			return count, fmt.Errorf("%w: message %d is %d bytes", ErrInvalidMessageExport, pos, size)
+		}
+		msgBytes := make([]byte, size)
+		if _, err := io.ReadFull(br, msgBytes); err != nil {
+			return count, fmt.Errorf("%w: truncated in message %d", ErrInvalidMessageExport, pos)
+		}
+		var msg arbostypes.MessageWithMetadata
+		if err := rlp.DecodeBytes(msgBytes, &msg); err != nil {
+			return count, fmt.Errorf("%w: decoding message %d: %w", ErrInvalidMessageExport, pos, err)
+		}
+		if err := fn(pos, &msg); err != nil {
+			return count, err
+		}
+		count++
+		nextPos = pos + 1
+	}
+}
+
+// ExportMessages streams messages first through last to w, and resolves with how many were exported.
+// The range is cut short at the message count, so a last beyond it exports up to the latest message.
+func (s *TransactionStreamer) ExportMessages(ctx context.Context, first, last arbutil.MessageIndex, w io.Writer) containers.PromiseInterface[uint64] {
+	if first > last {
+		return containers.NewReadyPromise[uint64](0, fmt.Errorf("invalid message export range %d to %d", first, last))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	promise := containers.NewPromise[uint64](cancel)
+	go func() {
+		defer cancel()
+		count, err := s.exportMessages(ctx, first, last, w)
+		if err != nil {
+			promise.ProduceError(err)
+		} else {
+			promise.Produce(count)
+		}
+	}()
+	return &promise
+}
+
+func (s *TransactionStreamer) exportMessages(ctx context.Context, first, last arbutil.MessageIndex, w io.Writer) (uint64, error) {
+	msgCount, err := s.GetMessageCount()
+	if err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(messageExportMagic); err != nil {
+		return 0, err
+	}
+	var count uint64
+	for pos := first; pos <= last && pos < msgCount; pos++ {
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+		msg, err := s.GetMessage(pos)
+		if err != nil {
+			return count, fmt.Errorf("exporting message %d: %w", pos, err)
+		}
+		if err := writeExportedMessage(bw, pos, msg); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, bw.Flush()
+}
+
+// ImportMessages adds the messages exported by ExportMessages, and returns how many were imported.
+// The first message must be at or before the message count, and messages already present are
+// checked like messages read from the feed, so they may be reorged.
+func (s *TransactionStreamer) ImportMessages(ctx context.Context, r io.Reader) (uint64, error) {
+	var chunkPos arbutil.MessageIndex
+	var chunk []arbostypes.MessageWithMetadata
+	var imported uint64
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := s.AddMessages(chunkPos, false, chunk); err != nil {
+			return fmt.Errorf("importing messages %d to %d: %w", chunkPos, chunkPos+arbutil.MessageIndex(len(chunk))-1, err)
+		}
+		imported += uint64(len(chunk))
+		chunkPos += arbutil.MessageIndex(len(chunk))
+		chunk = nil
+		return nil
+	}
+	_, err := ReadExportedMessages(r, func(pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if imported == 0 && len(chunk) == 0 {
+			chunkPos = pos
+		}
+		chunk = append(chunk, *msg)
+		if len(chunk) >= importMessagesChunk {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return imported, err
+	}
+	return imported, flush()
+}
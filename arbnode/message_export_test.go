@@ -0,0 +1,92 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestExportImportMessages(t *testing.T) {
+	ctx := context.Background()
+	_, source, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	_, dest, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+
+	start, err := source.GetMessageCount()
+	Require(t, err)
+	for i := 0; i < 8; i++ {
+		Require(t, source.WriteMessageFromSequencer(start+arbutil.MessageIndex(i), testSequencerMessage(i), execution.MessageResult{}))
+	}
+
+	// The range is cut short at the message count
+	var buf bytes.Buffer
+	count, err := source.ExportMessages(ctx, start, start+100, &buf).Await(ctx)
+	Require(t, err)
+	if count != 8 {
+		Fail(t, "exported", count, "messages, expected 8")
+	}
+
+	var positions []arbutil.MessageIndex
+	read, err := ReadExportedMessages(bytes.NewReader(buf.Bytes()), func(pos arbutil.MessageIndex, msg *arbostypes.MessageWithMetadata) error {
+		positions = append(positions, pos)
+		if msg.Message.Header.Timestamp != uint64(pos-start) {
+			Fail(t, "message", pos, "has timestamp", msg.Message.Header.Timestamp)
+		}
+		return nil
+	})
+	Require(t, err)
+	if read != 8 || positions[0] != start || positions[7] != start+7 {
+		Fail(t, "unexpected messages read", read, positions)
+	}
+
+	destStart, err := dest.GetMessageCount()
+	Require(t, err)
+	if destStart != start {
+		Fail(t, "streamers start at different message counts", start, destStart)
+	}
+	imported, err := dest.ImportMessages(ctx, bytes.NewReader(buf.Bytes()))
+	Require(t, err)
+	if imported != 8 {
+		Fail(t, "imported", imported, "messages, expected 8")
+	}
+	destCount, err := dest.GetMessageCount()
+	Require(t, err)
+	if destCount != start+8 {
+		Fail(t, "unexpected message count after import", destCount)
+	}
+	msg, err := dest.GetMessage(start + 5)
+	Require(t, err)
+	if msg.Message.Header.Timestamp != 5 {
+		Fail(t, "imported message has timestamp", msg.Message.Header.Timestamp)
+	}
+
+	// A range entirely beyond the message count exports nothing
+	buf.Reset()
+	count, err = source.ExportMessages(ctx, start+100, start+200, &buf).Await(ctx)
+	Require(t, err)
+	if count != 0 {
+		Fail(t, "exported", count, "messages beyond the message count")
+	}
+
+	// Truncated exports are rejected
+	buf.Reset()
+	_, err = source.ExportMessages(ctx, start, start+7, &buf).Await(ctx)
+	Require(t, err)
+	_, err = ReadExportedMessages(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), func(arbutil.MessageIndex, *arbostypes.MessageWithMetadata) error { return nil })
+	if !errors.Is(err, ErrInvalidMessageExport) {
+		Fail(t, "expected a truncated export to be invalid, got", err)
+	}
+	_, err = ReadExportedMessages(bytes.NewReader([]byte("not an export")), func(arbutil.MessageIndex, *arbostypes.MessageWithMetadata) error { return nil })
+	if !errors.Is(err, ErrInvalidMessageExport) {
+		Fail(t, "expected data without the header to be invalid, got", err)
+	}
+}
@@ -4,12 +4,16 @@
 package arbnode
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	flag "github.com/spf13/pflag"
@@ -33,6 +37,7 @@ import (
 	"github.com/offchainlabs/nitro/broadcastclients"
 	"github.com/offchainlabs/nitro/broadcaster"
 	"github.com/offchainlabs/nitro/cmd/chaininfo"
+	"github.com/offchainlabs/nitro/consensus"
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/execution/gethexec"
@@ -41,11 +46,14 @@ import (
 	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
 	"github.com/offchainlabs/nitro/staker"
 	"github.com/offchainlabs/nitro/staker/validatorwallet"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/util/contracts"
 	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/redisutil"
 	"github.com/offchainlabs/nitro/util/rpcclient"
 	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
 
@@ -83,6 +91,7 @@ type Config struct {
 	DelayedSequencer    DelayedSequencerConfig      `koanf:"delayed-sequencer" reload:"hot"`
 	BatchPoster         BatchPosterConfig           `koanf:"batch-poster" reload:"hot"`
 	MessagePruner       MessagePrunerConfig         `koanf:"message-pruner" reload:"hot"`
+	BatchPruner         BatchPrunerConfig           `koanf:"batch-pruner" reload:"hot"`
 	BlockValidator      staker.BlockValidatorConfig `koanf:"block-validator" reload:"hot"`
 	Feed                broadcastclient.FeedConfig  `koanf:"feed" reload:"hot"`
 	Staker              staker.L1ValidatorConfig    `koanf:"staker" reload:"hot"`
@@ -91,6 +100,8 @@ type Config struct {
 	SyncMonitor         SyncMonitorConfig           `koanf:"sync-monitor"`
 	Dangerous           DangerousConfig             `koanf:"dangerous"`
 	TransactionStreamer TransactionStreamerConfig   `koanf:"transaction-streamer" reload:"hot"`
+	SequencerPipeline   PipelinedSequencerConfig    `koanf:"sequencer-pipeline"`
+	SequencerAuditLog   SequencerAuditLogConfig     `koanf:"sequencer-audit-log"`
 	Maintenance         MaintenanceConfig           `koanf:"maintenance" reload:"hot"`
 	ResourceMgmt        resourcemanager.Config      `koanf:"resource-mgmt" reload:"hot"`
 	// SnapSyncConfig is only used for testing purposes, these should not be configured in production.
@@ -129,6 +140,12 @@ func (c *Config) Validate() error {
 	if err := c.Staker.Validate(); err != nil {
 		return err
 	}
+	if err := c.SequencerPipeline.Validate(); err != nil {
+		return err
+	}
+	if err := c.SequencerAuditLog.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -149,6 +166,7 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 	DelayedSequencerConfigAddOptions(prefix+".delayed-sequencer", f)
 	BatchPosterConfigAddOptions(prefix+".batch-poster", f)
 	MessagePrunerConfigAddOptions(prefix+".message-pruner", f)
+	BatchPrunerConfigAddOptions(prefix+".batch-pruner", f)
 	staker.BlockValidatorConfigAddOptions(prefix+".block-validator", f)
 	broadcastclient.FeedConfigAddOptions(prefix+".feed", f, feedInputEnable, feedOutputEnable)
 	staker.L1ValidatorConfigAddOptions(prefix+".staker", f)
@@ -157,6 +175,8 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 	SyncMonitorConfigAddOptions(prefix+".sync-monitor", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	TransactionStreamerConfigAddOptions(prefix+".transaction-streamer", f)
+	PipelinedSequencerConfigAddOptions(prefix+".sequencer-pipeline", f)
+	SequencerAuditLogConfigAddOptions(prefix+".sequencer-audit-log", f)
 	MaintenanceConfigAddOptions(prefix+".maintenance", f)
 }
 
@@ -167,6 +187,7 @@ var ConfigDefault = Config{
 	DelayedSequencer:    DefaultDelayedSequencerConfig,
 	BatchPoster:         DefaultBatchPosterConfig,
 	MessagePruner:       DefaultMessagePrunerConfig,
+	BatchPruner:         DefaultBatchPrunerConfig,
 	BlockValidator:      staker.DefaultBlockValidatorConfig,
 	Feed:                broadcastclient.FeedConfigDefault,
 	Staker:              staker.DefaultL1ValidatorConfig,
@@ -175,6 +196,8 @@ var ConfigDefault = Config{
 	SyncMonitor:         DefaultSyncMonitorConfig,
 	Dangerous:           DefaultDangerousConfig,
 	TransactionStreamer: DefaultTransactionStreamerConfig,
+	SequencerPipeline:   DefaultPipelinedSequencerConfig,
+	SequencerAuditLog:   DefaultSequencerAuditLogConfig,
 	ResourceMgmt:        resourcemanager.DefaultConfig,
 	Maintenance:         DefaultMaintenanceConfig,
 	SnapSyncTest:        DefaultSnapSyncConfig,
@@ -256,6 +279,8 @@ type Node struct {
 	Execution               execution.FullExecutionClient
 	L1Reader                *headerreader.HeaderReader
 	TxStreamer              *TransactionStreamer
+	SequencerPipeline       *PipelinedConsensusSequencer
+	SequencerAuditLog       *SequencerAuditLog
 	DeployInfo              *chaininfo.RollupAddresses
 	BlobReader              daprovider.BlobReader
 	InboxReader             *InboxReader
@@ -263,6 +288,7 @@ type Node struct {
 	DelayedSequencer        *DelayedSequencer
 	BatchPoster             *BatchPoster
 	MessagePruner           *MessagePruner
+	BatchPruner             *BatchPruner
 	BlockValidator          *staker.BlockValidator
 	StatelessBlockValidator *staker.StatelessBlockValidator
 	Staker                  *staker.Staker
@@ -274,6 +300,13 @@ type Node struct {
 	SyncMonitor             *SyncMonitor
 	configFetcher           ConfigFetcher
 	ctx                     context.Context
+	// stopWaiter runs the consensus calls that don't complete right away
+	stopWaiter stopwaiter.StopWaiter
+	// closed is set by Close, failing the consensus calls after it
+	closed atomic.Bool
+	// closing is closed by Close, failing the consensus calls in flight
+	closing     chan struct{}
+	closingInit sync.Once
 }
 
 type SnapSyncConfig struct {
@@ -433,6 +466,21 @@ func createNodeImpl(
 	if err != nil {
 		return nil, err
 	}
+	var sequencerPipeline *PipelinedConsensusSequencer
+	if config.SequencerPipeline.Enable {
+		sequencerPipeline, err = NewPipelinedConsensusSequencer(txStreamer, txStreamer.GetMessageCount, &config.SequencerPipeline)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var sequencerAuditLog *SequencerAuditLog
+	if config.SequencerAuditLog.Enable {
+		sequencerAuditLog, err = NewSequencerAuditLog(&config.SequencerAuditLog)
+		if err != nil {
+			return nil, err
+		}
+		txStreamer.SetSequencerAuditLog(sequencerAuditLog)
+	}
 	var coordinator *SeqCoordinator
 	var bpVerifier *contracts.AddressVerifier
 	if deployInfo != nil && l1client != nil {
@@ -487,6 +535,8 @@ func createNodeImpl(
 			Execution:               exec,
 			L1Reader:                nil,
 			TxStreamer:              txStreamer,
+			SequencerPipeline:       sequencerPipeline,
+			SequencerAuditLog:       sequencerAuditLog,
 			DeployInfo:              nil,
 			BlobReader:              blobReader,
 			InboxReader:             nil,
@@ -494,6 +544,7 @@ func createNodeImpl(
 			DelayedSequencer:        nil,
 			BatchPoster:             nil,
 			MessagePruner:           nil,
+			BatchPruner:             nil,
 			BlockValidator:          nil,
 			StatelessBlockValidator: nil,
 			Staker:                  nil,
@@ -607,6 +658,14 @@ func createNodeImpl(
 		}
 	}
 
+	var batchPruner *BatchPruner
+	if config.BatchPruner.Enable {
+		batchPruner, err = NewBatchPruner(inboxReader, l1Reader, func() *BatchPrunerConfig { return &configFetcher.Get().BatchPruner })
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var stakerObj *staker.Staker
 	var messagePruner *MessagePruner
 	var stakerAddr common.Address
@@ -722,6 +781,8 @@ func createNodeImpl(
 		Execution:               exec,
 		L1Reader:                l1Reader,
 		TxStreamer:              txStreamer,
+		SequencerPipeline:       sequencerPipeline,
+		SequencerAuditLog:       sequencerAuditLog,
 		DeployInfo:              deployInfo,
 		BlobReader:              blobReader,
 		InboxReader:             inboxReader,
@@ -729,6 +790,7 @@ func createNodeImpl(
 		DelayedSequencer:        delayedSequencer,
 		BatchPoster:             batchPoster,
 		MessagePruner:           messagePruner,
+		BatchPruner:             batchPruner,
 		BlockValidator:          blockValidator,
 		StatelessBlockValidator: statelessBlockValidator,
 		Staker:                  stakerObj,
@@ -804,13 +866,17 @@ func (n *Node) Start(ctx context.Context) error {
 			return fmt.Errorf("error initializing exec client: %w", err)
 		}
 	}
-	n.SyncMonitor.Initialize(n.InboxReader, n.TxStreamer, n.SeqCoordinator)
+	n.SyncMonitor.Initialize(n.InboxReader, n.TxStreamer, n.SeqCoordinator, n.BroadcastClients)
+	n.stopWaiter.Start(ctx, n)
 	err := n.Stack.Start()
 	if err != nil {
 		return fmt.Errorf("error starting geth stack: %w", err)
 	}
 	if execClient != nil {
-		execClient.SetConsensusClient(n)
+		err = execClient.SetConsensusClient(ctx, n)
+		if err != nil {
+			return fmt.Errorf("error setting consensus client: %w", err)
+		}
 	}
 	err = n.Execution.Start(ctx)
 	if err != nil {
@@ -876,6 +942,9 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.MessagePruner != nil {
 		n.MessagePruner.Start(ctx)
 	}
+	if n.BatchPruner != nil {
+		n.BatchPruner.Start(ctx)
+	}
 	if n.Staker != nil {
 		err = n.Staker.Initialize(ctx)
 		if err != nil {
@@ -928,6 +997,59 @@ func (n *Node) Start(ctx context.Context) error {
 	return nil
 }
 
+// Close is part of the consensus client interface. The execution node uses this node in-process,
+// so there's nothing to release, but every consensus call after it fails with execution.ErrClientClosed.
+// Calls still in flight, like FindBatchesContainingKind or DrainSequencerQueue, are cancelled and
+// fail with execution.ErrClientClosed too. A pipelined sequencer write failed this way can't be
+// cancelled, so it may still be committed. The node itself is stopped by StopAndWait.
+func (n *Node) Close() error {
+	if !n.closed.Swap(true) {
+		close(n.closingChan())
+	}
+	return nil
+}
+
+// closedPromise is returned by the consensus calls once the node was closed.
+func closedPromise[T any]() containers.PromiseInterface[T] {
+	var empty T
+	return containers.NewReadyPromise(empty, execution.ErrClientClosed)
+}
+
+func (n *Node) closingChan() chan struct{} {
+	n.closingInit.Do(func() { n.closing = make(chan struct{}) })
+	return n.closing
+}
+
+// ifOpen guards a consensus call: it fails with execution.ErrClientClosed if the node was
+// closed, and otherwise makes the call. If the call's promise isn't ready yet, Close cancels
+// it and fails it with execution.ErrClientClosed.
+func ifOpen[T any](n *Node, call func() containers.PromiseInterface[T]) containers.PromiseInterface[T] {
+	if n.closed.Load() {
+		return closedPromise[T]()
+	}
+	source := call()
+	if source.Ready() {
+		return source
+	}
+	closing := n.closingChan()
+	promise := containers.NewPromise[T](source.Cancel)
+	go func() {
+		select {
+		case <-source.ReadyChan():
+			result, err := source.Current()
+			if err != nil {
+				promise.ProduceError(err)
+			} else {
+				promise.Produce(result)
+			}
+		case <-closing:
+			source.Cancel()
+			promise.ProduceError(execution.ErrClientClosed)
+		}
+	}()
+	return &promise
+}
+
 func (n *Node) StopAndWait() {
 	if n.MaintenanceRunner != nil && n.MaintenanceRunner.Started() {
 		n.MaintenanceRunner.StopAndWait()
@@ -941,6 +1063,7 @@ func (n *Node) StopAndWait() {
 		n.SeqCoordinator.PrepareForShutdown()
 	}
 	n.Stack.StopRPC() // does nothing if not running
+	n.stopWaiter.StopAndWait()
 	if n.DelayedSequencer != nil && n.DelayedSequencer.Started() {
 		n.DelayedSequencer.StopAndWait()
 	}
@@ -950,6 +1073,9 @@ func (n *Node) StopAndWait() {
 	if n.MessagePruner != nil && n.MessagePruner.Started() {
 		n.MessagePruner.StopAndWait()
 	}
+	if n.BatchPruner != nil && n.BatchPruner.Started() {
+		n.BatchPruner.StopAndWait()
+	}
 	if n.BroadcastServer != nil && n.BroadcastServer.Started() {
 		n.BroadcastServer.StopAndWait()
 	}
@@ -974,6 +1100,11 @@ func (n *Node) StopAndWait() {
 	if n.TxStreamer.Started() {
 		n.TxStreamer.StopAndWait()
 	}
+	if n.SequencerAuditLog != nil {
+		if err := n.SequencerAuditLog.Close(); err != nil {
+			log.Error("error closing sequencer audit log", "err", err)
+		}
+	}
 	if n.SeqCoordinator != nil && n.SeqCoordinator.Started() {
 		// Just stops the redis client (most other stuff was stopped earlier)
 		n.SeqCoordinator.StopAndWait()
@@ -990,50 +1121,660 @@ func (n *Node) StopAndWait() {
 	}
 }
 
-func (n *Node) FetchBatch(ctx context.Context, batchNum uint64) ([]byte, common.Hash, error) {
-	return n.InboxReader.GetSequencerMessageBytes(ctx, batchNum)
+func (n *Node) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.FetchedBatch] {
+		if err := n.InboxTracker.checkBatchDataAvailable(batchNum); err != nil {
+			return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+		}
+		data, blockHash, err := n.InboxReader.GetSequencerMessageBytes(ctx, batchNum)
+		return containers.NewReadyPromise(execution.FetchedBatch{Data: data, BlockHash: blockHash}, err)
+	})
+}
+
+func (n *Node) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	return ifOpen(n, func() containers.PromiseInterface[[]byte] {
+		if err := n.InboxTracker.checkBatchDataAvailable(batchNum); err != nil {
+			return containers.NewReadyPromise[[]byte](nil, err)
+		}
+		return containers.NewReadyPromise(n.InboxReader.GetSequencerMessageChunk(ctx, batchNum, offset, length))
+	})
+}
+
+func (n *Node) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		if err := n.InboxTracker.checkBatchDataAvailable(batchNum); err != nil {
+			return containers.NewReadyPromise[uint64](0, err)
+		}
+		return containers.NewReadyPromise(n.InboxReader.GetSequencerMessageSize(ctx, batchNum))
+	})
+}
+
+func (n *Node) GetBatchCount() containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		return containers.NewReadyPromise(n.InboxTracker.GetBatchCount())
+	})
+}
+
+func (n *Node) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		if n.InboxReader == nil {
+			return containers.NewReadyPromise(struct{}{}, errors.New("inbox reader not set up"))
+		}
+		oldest, err := n.InboxTracker.OldestAvailableBatch()
+		if err != nil {
+			return containers.NewReadyPromise(struct{}{}, err)
+		}
+		// Pruned batches are skipped, only invalid ranges are left to the inbox reader to reject
+		if first < oldest && first <= last {
+			if last < oldest {
+				return containers.NewReadyPromise(struct{}{}, nil)
+			}
+			first = oldest
+		}
+		return n.InboxReader.PrefetchSequencerMessages(first, last)
+	})
+}
+
+func (n *Node) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.BatchLookup] {
+		batch, found, err := n.InboxTracker.FindInboxBatchContainingMessage(message)
+		return containers.NewReadyPromise(execution.BatchLookup{Batch: batch, Found: found}, err)
+	})
+}
+
+func (n *Node) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		return containers.NewReadyPromise(n.InboxTracker.GetBatchParentChainBlock(seqNum))
+	})
+}
+
+func (n *Node) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.BatchParentChainBlocks] {
+		return containers.NewReadyPromise(consensus.CollectBatchParentChainBlocks(first, last, n.InboxTracker.GetBatchParentChainBlock))
+	})
+}
+
+func (n *Node) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.MessageRange] {
+		return containers.NewReadyPromise(n.InboxTracker.GetBatchMessageRange(batchNum))
+	})
+}
+
+func (n *Node) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.L1Info] {
+		return containers.NewReadyPromise(n.messageL1Info(ctx, pos))
+	})
+}
+
+func (n *Node) messageL1Info(ctx context.Context, pos arbutil.MessageIndex) (execution.L1Info, error) {
+	batchNum, found, err := n.InboxTracker.FindInboxBatchContainingMessage(pos)
+	if err != nil {
+		return execution.L1Info{}, err
+	}
+	if !found {
+		return execution.L1Info{Pending: true}, nil
+	}
+	block, err := n.InboxTracker.GetBatchParentChainBlock(batchNum)
+	if err != nil {
+		return execution.L1Info{}, err
+	}
+	if n.L1Reader == nil {
+		return execution.L1Info{}, errors.New("parent chain reader not enabled")
+	}
+	header, err := n.L1Reader.LastHeaderWithError()
+	if err != nil || header == nil || header.Number.Uint64() != block {
+		header, err = n.L1Reader.Client().HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+		if err != nil {
+			return execution.L1Info{}, fmt.Errorf("getting parent chain block %d header: %w", block, err)
+		}
+	}
+	return execution.L1Info{
+		BatchNum:    batchNum,
+		L1Block:     block,
+		L1BlockTime: header.Time,
+		L1BlockHash: header.Hash(),
+	}, nil
+}
+
+func (n *Node) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[[]uint64] {
+		return containers.NewReadyPromise(n.InboxTracker.FindBatchesInParentChainRange(firstBlock, lastBlock))
+	})
+}
+
+// VerifyMessageBatchMapping reads the metadata of every batch in the range, so like
+// FindBatchesContainingKind it runs in the background rather than returning a ready promise.
+func (n *Node) VerifyMessageBatchMapping(first, last arbutil.MessageIndex) containers.PromiseInterface[[]MappingInconsistency] {
+	return ifOpen(n, func() containers.PromiseInterface[[]MappingInconsistency] {
+		return stopwaiter.LaunchPromiseThread[[]MappingInconsistency](&n.stopWaiter, func(context.Context) ([]MappingInconsistency, error) {
+			return n.InboxTracker.VerifyMessageBatchMapping(first, last)
+		})
+	})
+}
+
+func (n *Node) PruneBatchesBefore(batchNum uint64) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		if n.InboxReader != nil {
+			return containers.NewReadyPromise(struct{}{}, n.InboxReader.PruneBatchesBefore(batchNum))
+		}
+		return containers.NewReadyPromise(struct{}{}, n.InboxTracker.PruneBatchesBefore(batchNum))
+	})
+}
+
+func (n *Node) OldestAvailableBatch() containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		return containers.NewReadyPromise(n.InboxTracker.OldestAvailableBatch())
+	})
+}
+
+// Deprecated: use SyncProgressSnapshot.
+func (n *Node) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	return ifOpen(n, func() containers.PromiseInterface[map[string]interface{}] {
+		return containers.NewReadyPromise(syncProgressMap(n.syncProgressSnapshot(context.Background())), nil)
+	})
+}
+
+func (n *Node) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.SyncProgressSnapshot] {
+		return containers.NewReadyPromise(n.syncProgressSnapshot(ctx))
+	})
+}
+
+func (n *Node) syncProgressSnapshot(ctx context.Context) (execution.SyncProgressSnapshot, error) {
+	snapshot, err := n.SyncMonitor.SyncProgressSnapshot(ctx)
+	if err != nil {
+		return snapshot, err
+	}
+	lag, err := n.batchPostingLag()
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.PostingLag = &lag
+	return snapshot, nil
+}
+
+func (n *Node) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.CatchUpEstimate] {
+		return containers.NewReadyPromise(n.SyncMonitor.CatchUpEstimate())
+	})
+}
+
+func (n *Node) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.CapabilitySet] {
+		capabilities := []execution.ConsensusCapability{
+			execution.CapabilityBatchChunks,
+			execution.CapabilityBatchRanges,
+			execution.CapabilityBatchPruning,
+			execution.CapabilitySequencerDeadlines,
+		}
+		if n.SequencerPipeline != nil {
+			capabilities = append(capabilities, execution.CapabilitySequencerPipeline)
+		}
+		if n.BatchPoster != nil {
+			capabilities = append(capabilities, execution.CapabilityCompressionStats)
+		}
+		return containers.NewReadyPromise(execution.CapabilitySet{
+			ProtocolVersion: execution.ConsensusProtocolVersion,
+			Capabilities:    capabilities,
+		}, nil)
+	})
+}
+
+func (n *Node) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.BatchCompressionStats] {
+		if n.BatchPoster == nil {
+			return containers.NewReadyPromise(execution.BatchCompressionStats{}, execution.ErrBatchPosterNotEnabled)
+		}
+		return containers.NewReadyPromise(n.BatchPoster.CompressionStats(), nil)
+	})
+}
+
+func (n *Node) FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[[]uint64] {
+		return stopwaiter.LaunchPromiseThread[[]uint64](&n.stopWaiter, func(ctx context.Context) ([]uint64, error) {
+			return consensus.FindBatchesContainingKind(ctx, first, last, kind, n.InboxTracker.GetBatchMessageRange, n.TxStreamer.GetMessage)
+		})
+	})
+}
+
+func (n *Node) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.SyncMode] {
+		return containers.NewReadyPromise(n.SyncMonitor.SyncMode(), nil)
+	})
+}
+
+func (n *Node) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.PostingLag] {
+		return containers.NewReadyPromise(n.batchPostingLag())
+	})
+}
+
+func (n *Node) batchPostingLag() (execution.PostingLag, error) {
+	batchCount, err := n.InboxTracker.GetBatchCount()
+	if err != nil {
+		return execution.PostingLag{}, err
+	}
+	var posted arbutil.MessageIndex
+	if batchCount > 0 {
+		posted, err = n.InboxTracker.GetBatchMessageCount(batchCount - 1)
+		if err != nil {
+			return execution.PostingLag{}, err
+		}
+	}
+	msgCount, err := n.TxStreamer.GetMessageCount()
+	if err != nil {
+		return execution.PostingLag{}, err
+	}
+	var timestamp uint64
+	if posted < msgCount {
+		msg, err := n.TxStreamer.GetMessage(posted)
+		if err != nil {
+			return execution.PostingLag{}, err
+		}
+		if msg.Message != nil && msg.Message.Header != nil {
+			timestamp = msg.Message.Header.Timestamp
+		}
+	}
+	return consensus.NewPostingLag(n.BatchPoster != nil, msgCount, posted, timestamp, time.Now()), nil
+}
+
+func (n *Node) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		return containers.NewReadyPromise(n.messageIndexToBlockNumber(pos))
+	})
+}
+
+func (n *Node) messageIndexToBlockNumber(pos arbutil.MessageIndex) (uint64, error) {
+	count, err := n.TxStreamer.GetMessageCount()
+	if err != nil {
+		return 0, err
+	}
+	if pos >= count {
+		return 0, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count}
+	}
+	return arbutil.MessageIndexToBlockNumber(pos, n.TxStreamer.chainConfig.ArbitrumChainParams.GenesisBlockNum)
+}
+
+func (n *Node) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	return ifOpen(n, func() containers.PromiseInterface[arbutil.MessageIndex] {
+		return containers.NewReadyPromise(n.blockNumberToMessageIndex(block))
+	})
 }
 
-func (n *Node) FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error) {
-	return n.InboxTracker.FindInboxBatchContainingMessage(message)
+func (n *Node) blockNumberToMessageIndex(block uint64) (arbutil.MessageIndex, error) {
+	pos, err := arbutil.BlockNumberToMessageIndex(block, n.TxStreamer.chainConfig.ArbitrumChainParams.GenesisBlockNum)
+	if err != nil {
+		return 0, err
+	}
+	count, err := n.TxStreamer.GetMessageCount()
+	if err != nil {
+		return 0, err
+	}
+	if pos >= count {
+		return 0, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count}
+	}
+	return pos, nil
 }
 
-func (n *Node) GetBatchParentChainBlock(seqNum uint64) (uint64, error) {
-	return n.InboxTracker.GetBatchParentChainBlock(seqNum)
+func (n *Node) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	return ifOpen(n, func() containers.PromiseInterface[common.Hash] {
+		count, err := n.TxStreamer.GetMessageCount()
+		if err != nil {
+			return containers.NewReadyPromise(common.Hash{}, err)
+		}
+		if pos >= count {
+			return containers.NewReadyPromise(common.Hash{}, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count})
+		}
+		msg, err := n.TxStreamer.GetMessage(pos)
+		if isErrNotFound(err) {
+			// Reorged away since the message count was read
+			count, err = n.TxStreamer.GetMessageCount()
+			if err == nil {
+				err = &consensus.ErrMessageBeyondHead{Pos: pos, Head: count}
+			}
+		}
+		if err != nil {
+			return containers.NewReadyPromise(common.Hash{}, err)
+		}
+		hash, err := msg.Hash(pos, n.TxStreamer.chainConfig.ChainID.Uint64())
+		return containers.NewReadyPromise(hash, err)
+	})
 }
 
-func (n *Node) FullSyncProgressMap() map[string]interface{} {
-	return n.SyncMonitor.FullSyncProgressMap()
+func (n *Node) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.CheckpointInfo] {
+		return containers.NewReadyPromise(n.checkpointInfo(ctx))
+	})
 }
 
-func (n *Node) Synced() bool {
-	return n.SyncMonitor.Synced()
+func (n *Node) checkpointInfo(ctx context.Context) (execution.CheckpointInfo, error) {
+	count, err := n.InboxReader.GetFinalizedMsgCount(ctx)
+	if err != nil {
+		return execution.CheckpointInfo{}, err
+	}
+	if count == 0 {
+		return execution.CheckpointInfo{}, errors.New("no finalized message to checkpoint yet")
+	}
+	result, err := n.TxStreamer.ResultAtCount(count)
+	if err != nil {
+		return execution.CheckpointInfo{}, err
+	}
+	return execution.CheckpointInfo{Pos: count - 1, BlockHash: result.BlockHash, SendRoot: result.SendRoot}, nil
 }
 
-func (n *Node) SyncTargetMessageCount() arbutil.MessageIndex {
-	return n.SyncMonitor.SyncTargetMessageCount()
+// VerifyExecutionCheckpoint doesn't need to start delivery: the transaction streamer always
+// delivers the message after the execution head, so a verified node is fed from pos+1.
+func (n *Node) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		return containers.NewReadyPromise(struct{}{}, n.verifyExecutionCheckpoint(pos, blockHash))
+	})
+}
+
+func (n *Node) verifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) error {
+	count, err := n.TxStreamer.GetMessageCount()
+	if err != nil {
+		return err
+	}
+	if pos >= count {
+		return &consensus.ErrMessageBeyondHead{Pos: pos, Head: count}
+	}
+	if pos+1 < count {
+		_, err := n.TxStreamer.GetMessage(pos + 1)
+		if isErrNotFound(err) {
+			return &consensus.ErrSnapshotTooOld{Pos: pos}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	result, err := n.TxStreamer.ResultAtCount(pos + 1)
+	if err != nil {
+		return err
+	}
+	if result.BlockHash != blockHash {
+		return &consensus.ErrCheckpointMismatch{Pos: pos, ExpectedBlockHash: result.BlockHash, BlockHash: blockHash}
+	}
+	return nil
+}
+
+// batchPostingReport is the posting report of batch seqNum, given its report message if it was read
+func (n *Node) batchPostingReport(ctx context.Context, seqNum uint64, report *BatchPostingReportMessage) (execution.PostingReportInfo, error) {
+	if report == nil {
+		return execution.PostingReportInfo{Pending: true, BatchNum: seqNum}, nil
+	}
+	delayedSeqNum, msg := report.DelayedSeqNum, report.Message
+	batchMessageCount, err := n.InboxTracker.GetBatchMessageCount(seqNum)
+	if err != nil {
+		return execution.PostingReportInfo{}, err
+	}
+	pos, found, err := n.TxStreamer.findMessageReadingDelayed(delayedSeqNum, batchMessageCount)
+	if err != nil {
+		return execution.PostingReportInfo{}, err
+	}
+	if !found {
+		return execution.PostingReportInfo{Pending: true, BatchNum: seqNum}, nil
+	}
+	_, batchPoster, _, _, l1BaseFee, extraGas, err := arbostypes.ParseBatchPostingReportMessageFields(bytes.NewReader(msg.L2msg))
+	if err != nil {
+		return execution.PostingReportInfo{}, fmt.Errorf("failed to parse batch posting report %d: %w", delayedSeqNum, err)
+	}
+	if msg.BatchGasCost == nil {
+		// Delayed messages stored in the legacy format don't have the gas cost of their batch
+		if n.InboxReader == nil {
+			return execution.PostingReportInfo{}, errors.New("inbox reader not enabled")
+		}
+		err := msg.FillInBatchGasCost(func(batchNum uint64) ([]byte, error) {
+			data, _, err := n.InboxReader.GetSequencerMessageBytes(ctx, batchNum)
+			return data, err
+		})
+		if err != nil {
+			return execution.PostingReportInfo{}, err
+		}
+	}
+	return execution.PostingReportInfo{
+		BatchNum:      seqNum,
+		Pos:           pos,
+		DelayedSeqNum: delayedSeqNum,
+		BatchPoster:   batchPoster,
+		DataGas:       arbmath.SaturatingUAdd(*msg.BatchGasCost, extraGas),
+		L1BaseFee:     l1BaseFee,
+		L1Block:       report.ParentChainBlock,
+	}, nil
+}
+
+func (n *Node) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.PostingReportInfo] {
+		return stopwaiter.LaunchPromiseThread[execution.PostingReportInfo](&n.stopWaiter, func(ctx context.Context) (execution.PostingReportInfo, error) {
+			reports, err := n.InboxTracker.FindBatchPostingReports(seqNum, seqNum)
+			if err != nil {
+				return execution.PostingReportInfo{}, err
+			}
+			return n.batchPostingReport(ctx, seqNum, reports[0])
+		})
+	})
+}
+
+func (n *Node) GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	return ifOpen(n, func() containers.PromiseInterface[[]execution.PostingReportInfo] {
+		if first > last || last-first >= execution.MaxBatchPostingReportRange {
+			return containers.NewReadyPromise[[]execution.PostingReportInfo](nil, fmt.Errorf("invalid batch posting report range %d to %d, at most %d batches are allowed", first, last, execution.MaxBatchPostingReportRange))
+		}
+		return stopwaiter.LaunchPromiseThread[[]execution.PostingReportInfo](&n.stopWaiter, func(ctx context.Context) ([]execution.PostingReportInfo, error) {
+			found, err := n.InboxTracker.FindBatchPostingReports(first, last)
+			if err != nil {
+				return nil, err
+			}
+			reports := make([]execution.PostingReportInfo, 0, len(found))
+			for i, reportMsg := range found {
+				seqNum := first + uint64(i)
+				report, err := n.batchPostingReport(ctx, seqNum, reportMsg)
+				if err == nil {
+					err = ctx.Err()
+				}
+				if err != nil {
+					return nil, fmt.Errorf("batch %d: %w", seqNum, err)
+				}
+				reports = append(reports, report)
+			}
+			return reports, nil
+		})
+	})
+}
+
+// QueryWriteAudit returns the sequencer audit log entries of writes from fromPos through toPos,
+// and of the reorgs that removed any of them.
+func (n *Node) QueryWriteAudit(fromPos, toPos arbutil.MessageIndex) ([]SequencerAuditEntry, error) {
+	if n.SequencerAuditLog == nil {
+		return nil, errors.New("sequencer audit log not enabled")
+	}
+	return n.SequencerAuditLog.Query(fromPos, toPos)
+}
+
+func (n *Node) Synced() containers.PromiseInterface[bool] {
+	return ifOpen(n, func() containers.PromiseInterface[bool] {
+		return containers.NewReadyPromise(n.SyncMonitor.Synced(), nil)
+	})
+}
+
+func (n *Node) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.HealthStatus] {
+		return containers.NewReadyPromise(n.SyncMonitor.Healthy(), nil)
+	})
+}
+
+// ExportMessages streams messages first through last to w, see TransactionStreamer.ExportMessages.
+func (n *Node) ExportMessages(ctx context.Context, first, last arbutil.MessageIndex, w io.Writer) containers.PromiseInterface[uint64] {
+	return ifOpen(n, func() containers.PromiseInterface[uint64] {
+		return n.TxStreamer.ExportMessages(ctx, first, last, w)
+	})
+}
+
+func (n *Node) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.PingResult] {
+		count, err := n.TxStreamer.GetMessageCount()
+		if err != nil {
+			return containers.NewReadyPromise(execution.PingResult{}, err)
+		}
+		return containers.NewReadyPromise(execution.PingResult{MessageCount: count, Time: time.Now()}, nil)
+	})
+}
+
+func (n *Node) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.SyncTarget] {
+		return containers.NewReadyPromise(n.SyncMonitor.SyncTarget(), nil)
+	})
+}
+
+func (n *Node) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.ChainSpec] {
+		return containers.NewReadyPromise(n.chainSpec(ctx))
+	})
+}
+
+func (n *Node) chainSpec(ctx context.Context) (execution.ChainSpec, error) {
+	chainConfig := n.TxStreamer.chainConfig
+	spec := execution.ChainSpec{
+		ChainID:         chainConfig.ChainID.Uint64(),
+		GenesisBlockNum: chainConfig.ArbitrumChainParams.GenesisBlockNum,
+	}
+	if n.L1Reader != nil {
+		parentChainID, err := n.L1Reader.Client().ChainID(ctx)
+		if err != nil {
+			return execution.ChainSpec{}, fmt.Errorf("getting parent chain ID: %w", err)
+		}
+		spec.ParentChainID = parentChainID.Uint64()
+	}
+	return spec, nil
 }
 
 // TODO: switch from pulling to pushing safe/finalized
-func (n *Node) GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
-	return n.InboxReader.GetSafeMsgCount(ctx)
+func (n *Node) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.SafeMsgInfo] {
+		return containers.NewReadyPromise(n.InboxReader.GetSafeMsgInfo(ctx))
+	})
 }
 
-func (n *Node) GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error) {
-	return n.InboxReader.GetFinalizedMsgCount(ctx)
+func (n *Node) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.FinalizedMsgInfo] {
+		return containers.NewReadyPromise(n.InboxReader.GetFinalizedMsgInfo(ctx))
+	})
 }
 
-func (n *Node) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error {
-	return n.TxStreamer.WriteMessageFromSequencer(pos, msgWithMeta, msgResult)
+func (n *Node) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.MsgCountWithHash] {
+		count, err := n.InboxReader.GetSafeMsgCount(ctx)
+		if err != nil {
+			return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+		}
+		return containers.NewReadyPromise(n.msgCountWithHash(count))
+	})
 }
 
-func (n *Node) ExpectChosenSequencer() error {
-	return n.TxStreamer.ExpectChosenSequencer()
+func (n *Node) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.MsgCountWithHash] {
+		count, err := n.InboxReader.GetFinalizedMsgCount(ctx)
+		if err != nil {
+			return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+		}
+		return containers.NewReadyPromise(n.msgCountWithHash(count))
+	})
 }
 
-func (n *Node) ValidatedMessageCount() (arbutil.MessageIndex, error) {
-	if n.BlockValidator == nil {
-		return 0, errors.New("validator not set up")
+func (n *Node) msgCountWithHash(count arbutil.MessageIndex) (execution.MsgCountWithHash, error) {
+	if count == 0 {
+		return execution.MsgCountWithHash{}, nil
+	}
+	msg, err := n.TxStreamer.getMessageWithMetadataAndBlockHash(count - 1)
+	if err != nil {
+		return execution.MsgCountWithHash{}, err
 	}
-	return n.BlockValidator.GetValidated(), nil
+	return execution.MsgCountWithHash{Count: count, BlockHash: msg.BlockHash}, nil
+}
+
+func (n *Node) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		return containers.NewReadyPromise(struct{}{}, n.SyncMonitor.SetLagThreshold(severity, messages, onExceed, onRecovery))
+	})
+}
+
+func (n *Node) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		return containers.NewReadyPromise(struct{}{}, n.SyncMonitor.ClearLagThreshold())
+	})
+}
+
+// Without the sequencer pipeline, the sequencer writes block until the message was written, as the
+// sequencer needs the write done before it sequences the next message, so their promises are always
+// ready. The pipeline returns as soon as it accepted the write, and resolves the promise on commit.
+func (n *Node) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.WriteMessageFromSequencer(pos, msgWithMeta, msgResult)
+		}
+		return containers.NewReadyPromise(struct{}{}, n.TxStreamer.WriteMessageFromSequencer(pos, msgWithMeta, msgResult))
+	})
+}
+
+func (n *Node) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key)
+		}
+		return containers.NewReadyPromise(struct{}{}, n.TxStreamer.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key))
+	})
+}
+
+func (n *Node) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	return ifOpen(n, func() containers.PromiseInterface[time.Time] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.WriteMessageFromSequencerWithDeadline(pos, msgWithMeta, msgResult, deadline)
+		}
+		return containers.NewReadyPromise(n.TxStreamer.WriteMessageFromSequencerWithDeadline(pos, msgWithMeta, msgResult, deadline))
+	})
+}
+
+func (n *Node) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.BacklogStatus] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.SequencerWriteBacklog()
+		}
+		return containers.NewReadyPromise(n.TxStreamer.SequencerWriteBacklog(), nil)
+	})
+}
+
+func (n *Node) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.DrainResult] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.DrainSequencerQueue(ctx)
+		}
+		return n.TxStreamer.DrainSequencerQueue(ctx)
+	})
+}
+
+func (n *Node) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	return ifOpen(n, func() containers.PromiseInterface[execution.MessageFeeEstimate] {
+		return n.TxStreamer.ComputeMessageL1Fee(msgWithMeta)
+	})
+}
+
+func (n *Node) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	return ifOpen(n, func() containers.PromiseInterface[struct{}] {
+		if n.SequencerPipeline != nil {
+			return n.SequencerPipeline.ExpectChosenSequencer()
+		}
+		return containers.NewReadyPromise(struct{}{}, n.TxStreamer.ExpectChosenSequencer())
+	})
+}
+
+func (n *Node) RegisterWriteObserver(observer SequencerWriteObserver) {
+	n.TxStreamer.RegisterWriteObserver(observer)
+}
+
+func (n *Node) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	return ifOpen(n, func() containers.PromiseInterface[arbutil.MessageIndex] {
+		if n.BlockValidator == nil {
+			return containers.NewReadyPromise[arbutil.MessageIndex](0, errors.New("validator not set up"))
+		}
+		return containers.NewReadyPromise(n.BlockValidator.GetValidated(), nil)
+	})
 }
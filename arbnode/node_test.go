@@ -0,0 +1,62 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestNodeClose(t *testing.T) {
+	ctx := context.Background()
+	// Nothing is set up, so the calls would panic if they reached the node's components
+	n := &Node{}
+	var client execution.FullConsensusClient = n
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal("closing again failed", err)
+	}
+	if _, err := client.GetBatchCount().Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected ErrClientClosed from GetBatchCount, got", err)
+	}
+	if _, err := client.FetchBatch(ctx, 0).Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected ErrClientClosed from FetchBatch, got", err)
+	}
+	if _, err := client.Ping(ctx).Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected ErrClientClosed from Ping, got", err)
+	}
+	if _, err := client.ExpectChosenSequencer().Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected ErrClientClosed from ExpectChosenSequencer, got", err)
+	}
+}
+
+func TestNodeCloseFailsCallsInFlight(t *testing.T) {
+	ctx := context.Background()
+	inner := &recordingSequencer{gate: make(chan struct{})}
+	defer close(inner.gate)
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+	n := &Node{SequencerPipeline: pipeline}
+
+	// The write is stuck committing, so neither it nor the drain waiting for it can resolve
+	write := n.WriteMessageFromSequencer(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	drain := n.DrainSequencerQueue(ctx)
+	if write.Ready() || drain.Ready() {
+		Fail(t, "calls resolved before the write was committed")
+	}
+	Require(t, n.Close())
+	if _, err := write.Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		Fail(t, "expected ErrClientClosed from the write in flight, got", err)
+	}
+	if _, err := drain.Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		Fail(t, "expected ErrClientClosed from the drain in flight, got", err)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+type SequencerAuditLogConfig struct {
+	Enable       bool   `koanf:"enable"`
+	Directory    string `koanf:"directory"`
+	MaxFileSize  int64  `koanf:"max-file-size"`
+	MaxTotalSize int64  `koanf:"max-total-size"`
+}
+
+var DefaultSequencerAuditLogConfig = SequencerAuditLogConfig{
+	Enable:       false,
+	Directory:    "",
+	MaxFileSize:  64 * 1024 * 1024,
+	MaxTotalSize: 1024 * 1024 * 1024,
+}
+
+func SequencerAuditLogConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultSequencerAuditLogConfig.Enable, "append every sequencer write and reorg to an audit log")
+	f.String(prefix+".directory", DefaultSequencerAuditLogConfig.Directory, "directory to write the sequencer audit log to")
+	f.Int64(prefix+".max-file-size", DefaultSequencerAuditLogConfig.MaxFileSize, "size in bytes after which a new audit log file is started")
+	f.Int64(prefix+".max-total-size", DefaultSequencerAuditLogConfig.MaxTotalSize, "size in bytes of audit log files to retain, the oldest files are deleted beyond it")
+}
+
+func (c *SequencerAuditLogConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Directory == "" {
+		return errors.New("sequencer audit log directory must be set")
+	}
+	if c.MaxFileSize <= 0 {
+		return errors.New("sequencer audit log max-file-size must be positive")
+	}
+	if c.MaxTotalSize < c.MaxFileSize {
+		return errors.New("sequencer audit log max-total-size must be at least max-file-size")
+	}
+	return nil
+}
+
+type SequencerAuditKind string
+
+const (
+	SequencerAuditWrite SequencerAuditKind = "write"
+	SequencerAuditReorg SequencerAuditKind = "reorg"
+)
+
+// SequencerAuditEntry is a sequencer write of the message at Pos, or a reorg from
+// PrevMessageCount messages back to Pos messages.
+type SequencerAuditEntry struct {
+	Time                time.Time            `json:"time"`
+	Kind                SequencerAuditKind   `json:"kind"`
+	Pos                 arbutil.MessageIndex `json:"pos"`
+	MessageHash         *common.Hash         `json:"messageHash,omitempty"`
+	DelayedMessagesRead uint64               `json:"delayedMessagesRead,omitempty"`
+	PrevMessageCount    arbutil.MessageIndex `json:"prevMessageCount,omitempty"`
+}
+
+// covers returns whether the entry affects any position from fromPos through toPos
+func (e *SequencerAuditEntry) covers(fromPos, toPos arbutil.MessageIndex) bool {
+	if e.Kind == SequencerAuditReorg {
+		return e.Pos <= toPos && e.PrevMessageCount > fromPos
+	}
+	return e.Pos >= fromPos && e.Pos <= toPos
+}
+
+const sequencerAuditFilePattern = "sequencer-audit-%06d.jsonl"
+
+// SequencerAuditLog appends the sequencer writes and reorgs of the transaction streamer to files
+// in config.Directory, one JSON entry per line. A new file is started once the current one
+// exceeds config.MaxFileSize, and the oldest files are deleted once all of them exceed
+// config.MaxTotalSize. Entries are appended under the streamer's insertion mutex, so they're
+// in the order the writes and reorgs were applied.
+// A failure to write the log is logged once and stops it, rather than failing sequencer writes.
+type SequencerAuditLog struct {
+	config *SequencerAuditLogConfig
+
+	mutex     sync.Mutex
+	files     []string
+	sizes     map[string]int64
+	totalSize int64
+	fileIndex int
+	file      *os.File
+	err       error
+}
+
+func NewSequencerAuditLog(config *SequencerAuditLogConfig) (*SequencerAuditLog, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.Directory, 0o755); err != nil {
+		return nil, err
+	}
+	existing, err := filepath.Glob(filepath.Join(config.Directory, "sequencer-audit-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(existing)
+	l := &SequencerAuditLog{
+		config: config,
+		sizes:  make(map[string]int64),
+	}
+	for _, path := range existing {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		l.files = append(l.files, path)
+		l.sizes[path] = info.Size()
+		l.totalSize += info.Size()
+	}
+	// Never append to a file of an earlier run
+	if len(existing) > 0 {
+		var last int
+		if _, err := fmt.Sscanf(filepath.Base(existing[len(existing)-1]), sequencerAuditFilePattern, &last); err != nil {
+			return nil, fmt.Errorf("unexpected sequencer audit log file %s: %w", existing[len(existing)-1], err)
+		}
+		l.fileIndex = last + 1
+	}
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+	if err := l.removeOldFiles(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// The mutex must be held
+func (l *SequencerAuditLog) openFile() error {
+	path := filepath.Join(l.config.Directory, fmt.Sprintf(sequencerAuditFilePattern, l.fileIndex))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	l.file = file
+	l.files = append(l.files, path)
+	l.sizes[path] = 0
+	return nil
+}
+
+// The mutex must be held
+func (l *SequencerAuditLog) removeOldFiles() error {
+	for len(l.files) > 1 && l.totalSize > l.config.MaxTotalSize {
+		oldest := l.files[0]
+		if err := os.Remove(oldest); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		l.totalSize -= l.sizes[oldest]
+		delete(l.sizes, oldest)
+		l.files = l.files[1:]
+	}
+	return nil
+}
+
+func (l *SequencerAuditLog) append(entry SequencerAuditEntry) {
+	line, err := json.Marshal(&entry)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.err != nil {
+		return
+	}
+	if err == nil {
+		line = append(line, '\n')
+		_, err = l.file.Write(line)
+	}
+	if err == nil {
+		current := l.files[len(l.files)-1]
+		l.sizes[current] += int64(len(line))
+		l.totalSize += int64(len(line))
+		if l.sizes[current] >= l.config.MaxFileSize {
+			err = l.file.Close()
+			l.file = nil
+			if err == nil {
+				l.fileIndex++
+				err = l.openFile()
+			}
+		}
+	}
+	if err == nil {
+		err = l.removeOldFiles()
+	}
+	if err != nil {
+		log.Error("failed writing sequencer audit log, audit log stopped", "kind", entry.Kind, "pos", entry.Pos, "err", err)
+		l.err = err
+	}
+}
+
+func (l *SequencerAuditLog) recordWrite(pos arbutil.MessageIndex, msgHash common.Hash, delayedMessagesRead uint64) {
+	l.append(SequencerAuditEntry{
+		Time:                time.Now(),
+		Kind:                SequencerAuditWrite,
+		Pos:                 pos,
+		MessageHash:         &msgHash,
+		DelayedMessagesRead: delayedMessagesRead,
+	})
+}
+
+func (l *SequencerAuditLog) recordReorg(count, prevCount arbutil.MessageIndex) {
+	l.append(SequencerAuditEntry{
+		Time:             time.Now(),
+		Kind:             SequencerAuditReorg,
+		Pos:              count,
+		PrevMessageCount: prevCount,
+	})
+}
+
+// Err returns the error that stopped the audit log, if any.
+func (l *SequencerAuditLog) Err() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.err
+}
+
+// Query returns the retained entries affecting any position from fromPos through toPos, oldest first:
+// the writes of those positions, and the reorgs that removed any of them.
+// The files are read without blocking writes to the log.
+func (l *SequencerAuditLog) Query(fromPos, toPos arbutil.MessageIndex) ([]SequencerAuditEntry, error) {
+	if fromPos > toPos {
+		return nil, fmt.Errorf("invalid audit query range %d to %d", fromPos, toPos)
+	}
+	l.mutex.Lock()
+	files := append([]string(nil), l.files...)
+	l.mutex.Unlock()
+	var entries []SequencerAuditEntry
+	for i, path := range files {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// Deleted by retention since the files were listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(file)
+		for {
+			var entry SequencerAuditEntry
+			err = decoder.Decode(&entry)
+			if err != nil {
+				break
+			}
+			if entry.covers(fromPos, toPos) {
+				entries = append(entries, entry)
+			}
+		}
+		file.Close()
+		// The file being written may end in a partially written entry
+		if errors.Is(err, io.EOF) || (errors.Is(err, io.ErrUnexpectedEOF) && i == len(files)-1) {
+			continue
+		}
+		return nil, fmt.Errorf("reading sequencer audit log %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func (l *SequencerAuditLog) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.err == nil {
+		l.err = errors.New("sequencer audit log closed")
+	}
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
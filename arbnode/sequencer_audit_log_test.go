@@ -0,0 +1,96 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func TestSequencerAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	config := SequencerAuditLogConfig{Enable: true, Directory: dir, MaxFileSize: 1000, MaxTotalSize: 100_000}
+	auditLog, err := NewSequencerAuditLog(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pos := arbutil.MessageIndex(0); pos < 20; pos++ {
+		auditLog.recordWrite(pos, common.Hash{byte(pos)}, uint64(pos)/2)
+	}
+	auditLog.recordReorg(15, 20)
+	auditLog.recordWrite(15, common.HexToHash("0xff"), 7)
+	if err := auditLog.Err(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatal("audit log not rotated, files:", files)
+	}
+
+	entries, err := auditLog.Query(14, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatal("unexpected number of entries", entries)
+	}
+	for i, pos := range []arbutil.MessageIndex{14, 15, 16} {
+		if entries[i].Kind != SequencerAuditWrite || entries[i].Pos != pos || entries[i].DelayedMessagesRead != uint64(pos)/2 {
+			t.Fatal("unexpected write entry", entries[i])
+		}
+	}
+	if entries[3].Kind != SequencerAuditReorg || entries[3].Pos != 15 || entries[3].PrevMessageCount != 20 {
+		t.Fatal("unexpected reorg entry", entries[3])
+	}
+	if entries[4].Pos != 15 || entries[4].MessageHash == nil || *entries[4].MessageHash != common.HexToHash("0xff") {
+		t.Fatal("unexpected rewrite entry", entries[4])
+	}
+	if entries, err := auditLog.Query(0, 0); err != nil || len(entries) != 1 {
+		t.Fatal("unexpected entries for the first position", entries, err)
+	}
+	if _, err := auditLog.Query(2, 1); err == nil {
+		t.Fatal("query of an empty range succeeded")
+	}
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening starts a new file, and drops the oldest ones beyond the retention budget
+	config.MaxTotalSize = 2000
+	auditLog, err = NewSequencerAuditLog(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	var total int64
+	remaining, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range remaining {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += info.Size()
+	}
+	if total > config.MaxTotalSize || len(remaining) >= len(files)+1 {
+		t.Fatal("old audit log files not removed", remaining, total)
+	}
+	if entries, err := auditLog.Query(0, 0); err != nil || len(entries) != 0 {
+		t.Fatal("entries of removed files returned", entries, err)
+	}
+	auditLog.recordWrite(21, common.Hash{}, 0)
+	if entries, err := auditLog.Query(21, 21); err != nil || len(entries) != 1 {
+		t.Fatal("entry written after reopening not returned", entries, err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+func sequencerMessageSize(msgWithMeta *arbostypes.MessageWithMetadata) int64 {
+	if msgWithMeta.Message == nil {
+		return 0
+	}
+	return int64(len(msgWithMeta.Message.L2msg))
+}
+
+// sequencerDrainer tracks the sequencer writes in flight so they can be drained.
+// Writes call begin before they're accepted, and end once they committed or failed.
+type sequencerDrainer struct {
+	mutex          sync.Mutex
+	draining       int
+	inFlight       int
+	inFlightBytes  int64
+	committed      int
+	committedBytes int64
+	// closed and replaced whenever a write ends
+	ended chan struct{}
+}
+
+func newSequencerDrainer() *sequencerDrainer {
+	return &sequencerDrainer{ended: make(chan struct{})}
+}
+
+func (d *sequencerDrainer) begin(size int64) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.draining > 0 {
+		return execution.ErrDraining
+	}
+	d.inFlight++
+	d.inFlightBytes += size
+	return nil
+}
+
+func (d *sequencerDrainer) end(size int64, committed bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.inFlight--
+	d.inFlightBytes -= size
+	if committed {
+		d.committed++
+		d.committedBytes += size
+	}
+	close(d.ended)
+	d.ended = make(chan struct{})
+}
+
+func (d *sequencerDrainer) pendingBytes() int64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.inFlightBytes
+}
+
+// drain rejects new writes until the writes in flight ended, or ctx is cancelled
+func (d *sequencerDrainer) drain(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	ctx, cancel := context.WithCancel(ctx)
+	promise := containers.NewPromise[execution.DrainResult](cancel)
+	start := time.Now()
+	d.mutex.Lock()
+	d.draining++
+	startCommitted, startCommittedBytes := d.committed, d.committedBytes
+	d.mutex.Unlock()
+	go func() {
+		defer cancel()
+		for {
+			d.mutex.Lock()
+			if d.inFlight == 0 {
+				d.draining--
+				result := execution.DrainResult{
+					MessagesDrained: d.committed - startCommitted,
+					BytesFlushed:    d.committedBytes - startCommittedBytes,
+					Duration:        time.Since(start),
+				}
+				d.mutex.Unlock()
+				promise.Produce(result)
+				return
+			}
+			ended := d.ended
+			d.mutex.Unlock()
+			select {
+			case <-ended:
+			case <-ctx.Done():
+				d.mutex.Lock()
+				d.draining--
+				d.mutex.Unlock()
+				promise.ProduceError(ctx.Err())
+				return
+			}
+		}
+	}()
+	return &promise
+}
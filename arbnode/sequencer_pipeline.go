@@ -0,0 +1,417 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type PipelinedSequencerConfig struct {
+	Enable        bool          `koanf:"enable"`
+	WindowSize    int           `koanf:"window-size"`
+	MaxWait       time.Duration `koanf:"max-wait"`
+	HighWaterMark int           `koanf:"high-water-mark"`
+}
+
+var DefaultPipelinedSequencerConfig = PipelinedSequencerConfig{
+	Enable:        false,
+	WindowSize:    64,
+	MaxWait:       time.Second,
+	HighWaterMark: 0,
+}
+
+func PipelinedSequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultPipelinedSequencerConfig.Enable, "accept concurrent sequencer writes for a window of positions, committing them in order")
+	f.Int(prefix+".window-size", DefaultPipelinedSequencerConfig.WindowSize, "number of positions past the message count that sequencer writes may be accepted for")
+	f.Duration(prefix+".max-wait", DefaultPipelinedSequencerConfig.MaxWait, "maximum time a sequencer write waits for the writes of earlier positions")
+	f.Int(prefix+".high-water-mark", DefaultPipelinedSequencerConfig.HighWaterMark, "number of pending sequencer writes above which new writes are rejected with a backpressure error (0 = window-size only)")
+}
+
+func (c *PipelinedSequencerConfig) Validate() error {
+	if c.Enable && c.WindowSize <= 0 {
+		return errors.New("sequencer pipeline window-size must be positive")
+	}
+	if c.Enable && c.MaxWait <= 0 {
+		return errors.New("sequencer pipeline max-wait must be positive")
+	}
+	if c.HighWaterMark < 0 {
+		return errors.New("sequencer pipeline high-water-mark must not be negative")
+	}
+	return nil
+}
+
+var ErrSequencerWindowFull = errors.New("sequencer write position beyond pipeline window")
+var ErrSequencerPipelineAborted = errors.New("an earlier sequencer write in the pipeline failed")
+var ErrSequencerPipelineTimeout = errors.New("timed out waiting for earlier sequencer writes in the pipeline")
+
+type pipelineSlot struct {
+	aborted bool
+	// the earliest failed position this slot waited for, set if aborted
+	failedPos arbutil.MessageIndex
+	enqueued  time.Time
+}
+
+// number of recent sequencer writes the write latency estimate is averaged over
+const writeLatencyWindow = 100
+
+type writeLatencyTracker struct {
+	mutex   sync.Mutex
+	average *arbmath.MovingAverage[time.Duration]
+}
+
+func newWriteLatencyTracker() (*writeLatencyTracker, error) {
+	average, err := arbmath.NewMovingAverage[time.Duration](writeLatencyWindow)
+	if err != nil {
+		return nil, err
+	}
+	return &writeLatencyTracker{average: average}, nil
+}
+
+func (w *writeLatencyTracker) update(latency time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.average.Update(latency)
+}
+
+func (w *writeLatencyTracker) estimate() time.Duration {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.average.Average()
+}
+
+// pipelineBackend is the sequencer a PipelinedConsensusSequencer commits to, such as the
+// TransactionStreamer. Its writes block until the message was written.
+type pipelineBackend interface {
+	WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error
+	WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) error
+	WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) (time.Time, error)
+	ExpectChosenSequencer() error
+	ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate]
+}
+
+// PipelinedConsensusSequencer accepts WriteMessageFromSequencer calls for any position within
+// WindowSize of the next position to commit, validates them concurrently, and commits them
+// to the inner sequencer in strict position order.
+// A write is accepted or rejected before the call returns, and its promise resolves once its own
+// message was committed (or failed). Accepted writes can't be cancelled.
+// If a write fails, times out or misses its deadline, all pending writes for later positions fail
+// with ErrSequencerPipelineAborted wrapping *consensus.ErrEarlierWriteFailed, since they can't be
+// committed until the failed position is written. A write that waits longer than MaxWait for
+// earlier positions fails with ErrSequencerPipelineTimeout.
+// There is no compression stage: the inner sequencer stores messages uncompressed, and they're
+// only compressed when the batch poster builds batches from many of them, so the write path has
+// nothing to compress per message.
+// If HighWaterMark is set, a write arriving while that many writes are pending fails with
+// execution.ErrBackpressure.
+// While the pipeline is drained, a pending write waiting for a position that was rejected with
+// execution.ErrDraining fails once it waited MaxWait, so draining takes at most MaxWait longer
+// than the slowest commit.
+type PipelinedConsensusSequencer struct {
+	inner         pipelineBackend
+	messageCount  func() (arbutil.MessageIndex, error)
+	windowSize    int
+	maxWait       time.Duration
+	highWaterMark int
+	latency       *writeLatencyTracker
+	drainer       *sequencerDrainer
+
+	mutex   sync.Mutex
+	next    arbutil.MessageIndex
+	pending map[arbutil.MessageIndex]*pipelineSlot
+	// closed and replaced whenever next advances or a write fails
+	changed chan struct{}
+}
+
+var _ execution.ConsensusSequencer = (*PipelinedConsensusSequencer)(nil)
+
+func NewPipelinedConsensusSequencer(inner pipelineBackend, messageCount func() (arbutil.MessageIndex, error), config *PipelinedSequencerConfig) (*PipelinedConsensusSequencer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	latency, err := newWriteLatencyTracker()
+	if err != nil {
+		return nil, err
+	}
+	s := &PipelinedConsensusSequencer{
+		inner:         inner,
+		messageCount:  messageCount,
+		windowSize:    config.WindowSize,
+		maxWait:       config.MaxWait,
+		highWaterMark: config.HighWaterMark,
+		latency:       latency,
+		drainer:       newSequencerDrainer(),
+		pending:       make(map[arbutil.MessageIndex]*pipelineSlot),
+		changed:       make(chan struct{}),
+	}
+	return s, nil
+}
+
+func (s *PipelinedConsensusSequencer) WindowSize() int {
+	return s.windowSize
+}
+
+func (s *PipelinedConsensusSequencer) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, s.inner.ExpectChosenSequencer())
+}
+
+func (s *PipelinedConsensusSequencer) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	return s.inner.ComputeMessageL1Fee(msgWithMeta)
+}
+
+// SequencerWriteBacklog reports the writes pending in the pipeline, and their average latency
+// from being accepted to being committed.
+func (s *PipelinedConsensusSequencer) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	s.mutex.Lock()
+	pending := len(s.pending)
+	s.mutex.Unlock()
+	return containers.NewReadyPromise(execution.BacklogStatus{
+		PendingWrites: pending,
+		PendingBytes:  s.drainer.pendingBytes(),
+		WriteLatency:  s.latency.estimate(),
+	}, nil)
+}
+
+// PendingSequencerWrite is a write pending in the pipeline, as reported by DumpWriteQueue.
+// Committing is set for the write of the next position, which is being committed to the inner
+// sequencer or is about to be.
+type PendingSequencerWrite struct {
+	Pos        arbutil.MessageIndex `json:"pos"`
+	Enqueued   time.Time            `json:"enqueued"`
+	Committing bool                 `json:"committing"`
+	Aborted    bool                 `json:"aborted"`
+}
+
+// DumpWriteQueue returns the writes pending in the pipeline in position order, to debug a wedged
+// sequencer. The pipeline's mutex is never held while committing to the inner sequencer, so this
+// doesn't block on a stuck commit.
+func (s *PipelinedConsensusSequencer) DumpWriteQueue() []PendingSequencerWrite {
+	s.mutex.Lock()
+	writes := make([]PendingSequencerWrite, 0, len(s.pending))
+	for pos, slot := range s.pending {
+		writes = append(writes, PendingSequencerWrite{
+			Pos:        pos,
+			Enqueued:   slot.enqueued,
+			Committing: pos == s.next,
+			Aborted:    slot.aborted,
+		})
+	}
+	s.mutex.Unlock()
+	sort.Slice(writes, func(i, j int) bool { return writes[i].Pos < writes[j].Pos })
+	return writes
+}
+
+func (s *PipelinedConsensusSequencer) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	return s.drainer.drain(ctx)
+}
+
+func (s *PipelinedConsensusSequencer) enqueue(pos arbutil.MessageIndex) (*pipelineSlot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.pending) == 0 {
+		// Nothing in flight, so the message count may have moved by other means (e.g. a reorg)
+		msgCount, err := s.messageCount()
+		if err != nil {
+			return nil, err
+		}
+		s.next = msgCount
+	}
+	if pos < s.next {
+		return nil, &consensus.ErrConflictingMessage{Pos: pos, Expected: s.next}
+	}
+	if pos >= s.next+arbutil.MessageIndex(s.windowSize) {
+		return nil, fmt.Errorf("%w: pos %d next %d window %d", ErrSequencerWindowFull, pos, s.next, s.windowSize)
+	}
+	if _, exists := s.pending[pos]; exists {
+		return nil, &consensus.ErrConflictingMessage{Pos: pos, Expected: s.next}
+	}
+	if s.highWaterMark > 0 && len(s.pending) >= s.highWaterMark {
+		return nil, fmt.Errorf("%w: %d pending writes", execution.ErrBackpressure, len(s.pending))
+	}
+	slot := &pipelineSlot{enqueued: time.Now()}
+	s.pending[pos] = slot
+	return slot, nil
+}
+
+// The mutex must be held
+func (s *PipelinedConsensusSequencer) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// The mutex must be held
+func (s *PipelinedConsensusSequencer) failLocked(pos arbutil.MessageIndex) {
+	delete(s.pending, pos)
+	for pendingPos, slot := range s.pending {
+		if pendingPos > pos && (!slot.aborted || pos < slot.failedPos) {
+			slot.aborted = true
+			slot.failedPos = pos
+		}
+	}
+	s.notifyLocked()
+}
+
+// waitForTurn blocks until pos is the next position to commit, or deadline (if not zero) passes.
+// On a timeout, the pending writes for later positions are failed as well.
+func (s *PipelinedConsensusSequencer) waitForTurn(pos arbutil.MessageIndex, slot *pipelineSlot, deadline time.Time) error {
+	wait := s.maxWait
+	deadlineFirst := !deadline.IsZero() && time.Until(deadline) < wait
+	if deadlineFirst {
+		wait = time.Until(deadline)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		s.mutex.Lock()
+		if slot.aborted {
+			delete(s.pending, pos)
+			failedPos := slot.failedPos
+			s.mutex.Unlock()
+			return fmt.Errorf("%w: %w", ErrSequencerPipelineAborted, &consensus.ErrEarlierWriteFailed{Pos: pos, FailedPos: failedPos})
+		}
+		if s.next == pos {
+			s.mutex.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mutex.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			s.mutex.Lock()
+			s.failLocked(pos)
+			s.mutex.Unlock()
+			if deadlineFirst {
+				return &execution.ErrCommitDeadlineExceeded{Pos: pos, Deadline: deadline}
+			}
+			return fmt.Errorf("%w: pos %d", ErrSequencerPipelineTimeout, pos)
+		}
+	}
+}
+
+func validateSequencerMessage(pos arbutil.MessageIndex, msgWithMeta *arbostypes.MessageWithMetadata) error {
+	if msgWithMeta.Message == nil {
+		return fmt.Errorf("sequencer message %d is missing its message", pos)
+	}
+	if msgWithMeta.Message.Header == nil {
+		return fmt.Errorf("sequencer message %d is missing its header", pos)
+	}
+	if len(msgWithMeta.Message.L2msg) > arbostypes.MaxL2MessageSize {
+		return fmt.Errorf("sequencer message %d of size %d exceeds max size %d", pos, len(msgWithMeta.Message.L2msg), arbostypes.MaxL2MessageSize)
+	}
+	return nil
+}
+
+func discardCommitTime(time.Time) (struct{}, error) {
+	return struct{}{}, nil
+}
+
+func (s *PipelinedConsensusSequencer) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	// The write's promise always resolves, so the mapping can't outlive it
+	return containers.Map(context.Background(), s.writeMessage(pos, msgWithMeta, msgResult, time.Time{}, ""), discardCommitTime)
+}
+
+// WriteMessageFromSequencerIdempotent passes a retry of an already committed write straight to the
+// inner sequencer, which recognizes its key. A retry of a write still pending in the pipeline fails
+// with *consensus.ErrConflictingMessage, like any other write for a pending position.
+func (s *PipelinedConsensusSequencer) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	if key == "" {
+		return containers.NewReadyPromise(struct{}{}, errors.New("idempotent sequencer write requires a key"))
+	}
+	return containers.Map(context.Background(), s.writeMessage(pos, msgWithMeta, msgResult, time.Time{}, key), discardCommitTime)
+}
+
+// WriteMessageFromSequencerWithDeadline also fails with *execution.ErrCommitDeadlineExceeded if the
+// deadline passes while waiting for earlier positions. Such a write is dropped from the pipeline.
+func (s *PipelinedConsensusSequencer) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	return s.writeMessage(pos, msgWithMeta, msgResult, deadline, "")
+}
+
+// writeMessage writes idempotently if key isn't empty, in which case deadline must be zero.
+// The write is accepted into the pipeline before it returns, and committed in the background.
+func (s *PipelinedConsensusSequencer) writeMessage(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time, key string) containers.PromiseInterface[time.Time] {
+	size := sequencerMessageSize(&msgWithMeta)
+	if err := s.drainer.begin(size); err != nil {
+		return containers.NewReadyPromise(time.Time{}, err)
+	}
+	slot, err := s.enqueue(pos)
+	var conflictErr *consensus.ErrConflictingMessage
+	// Positions before next are committed, so the inner sequencer can't write this again
+	retry := key != "" && errors.As(err, &conflictErr) && conflictErr.Pos < conflictErr.Expected
+	if err != nil && !retry {
+		s.drainer.end(size, false)
+		return containers.NewReadyPromise(time.Time{}, err)
+	}
+	promise := containers.NewPromise[time.Time](nil)
+	go func() {
+		var committed time.Time
+		var err error
+		if retry {
+			err = s.inner.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key)
+			committed = time.Now()
+		} else {
+			committed, err = s.commitInOrder(pos, msgWithMeta, msgResult, slot, deadline, key)
+		}
+		s.drainer.end(size, err == nil && !retry)
+		if err != nil {
+			promise.ProduceError(err)
+		} else {
+			promise.Produce(committed)
+		}
+	}()
+	return &promise
+}
+
+// commitInOrder validates the accepted write for pos, and commits it once the writes of all
+// earlier positions were committed
+func (s *PipelinedConsensusSequencer) commitInOrder(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, slot *pipelineSlot, deadline time.Time, key string) (time.Time, error) {
+	if err := validateSequencerMessage(pos, &msgWithMeta); err != nil {
+		s.mutex.Lock()
+		s.failLocked(pos)
+		s.mutex.Unlock()
+		return time.Time{}, err
+	}
+
+	if err := s.waitForTurn(pos, slot, deadline); err != nil {
+		return time.Time{}, err
+	}
+
+	// Only the write for s.next gets here, so commits happen one at a time and in order
+	var committed time.Time
+	var err error
+	if key != "" {
+		err = s.inner.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key)
+		committed = time.Now()
+	} else if deadline.IsZero() {
+		err = s.inner.WriteMessageFromSequencer(pos, msgWithMeta, msgResult)
+		committed = time.Now()
+	} else {
+		committed, err = s.inner.WriteMessageFromSequencerWithDeadline(pos, msgWithMeta, msgResult, deadline)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil {
+		s.failLocked(pos)
+		return time.Time{}, err
+	}
+	delete(s.pending, pos)
+	s.next = pos + 1
+	s.notifyLocked()
+	s.latency.update(committed.Sub(slot.enqueued))
+	return committed, nil
+}
@@ -0,0 +1,392 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type recordingSequencer struct {
+	mutex   sync.Mutex
+	written []arbutil.MessageIndex
+	failAt  map[arbutil.MessageIndex]error
+	keys    map[string]arbutil.MessageIndex
+	// if set, writes wait for it to be closed
+	gate chan struct{}
+}
+
+func (s *recordingSequencer) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.failAt[pos]; err != nil {
+		return err
+	}
+	if arbutil.MessageIndex(len(s.written)) != pos {
+		return errors.New("out of order write")
+	}
+	s.written = append(s.written, pos)
+	return nil
+}
+
+func (s *recordingSequencer) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) (time.Time, error) {
+	if !time.Now().Before(deadline) {
+		return time.Time{}, &execution.ErrCommitDeadlineExceeded{Pos: pos, Deadline: deadline}
+	}
+	if err := s.WriteMessageFromSequencer(pos, msgWithMeta, msgResult); err != nil {
+		return time.Time{}, err
+	}
+	return time.Now(), nil
+}
+
+func (s *recordingSequencer) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) error {
+	s.mutex.Lock()
+	written, ok := s.keys[key]
+	s.mutex.Unlock()
+	if ok && written == pos {
+		return nil
+	}
+	if ok {
+		return execution.ErrIdempotencyConflict
+	}
+	if err := s.WriteMessageFromSequencer(pos, msgWithMeta, msgResult); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]arbutil.MessageIndex)
+	}
+	s.keys[key] = pos
+	return nil
+}
+
+func (s *recordingSequencer) SequencerWriteBacklog() execution.BacklogStatus {
+	return execution.BacklogStatus{}
+}
+
+func (s *recordingSequencer) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	return containers.NewReadyPromise(execution.DrainResult{}, nil)
+}
+
+func (s *recordingSequencer) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	return containers.NewReadyPromise(execution.MessageFeeEstimate{}, nil)
+}
+
+func (s *recordingSequencer) ExpectChosenSequencer() error {
+	return nil
+}
+
+func (s *recordingSequencer) messageCount() (arbutil.MessageIndex, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return arbutil.MessageIndex(len(s.written)), nil
+}
+
+func pendingWrites(t *testing.T, pipeline *PipelinedConsensusSequencer) int {
+	t.Helper()
+	return backlogOf(t, pipeline).PendingWrites
+}
+
+func backlogOf(t *testing.T, pipeline *PipelinedConsensusSequencer) execution.BacklogStatus {
+	t.Helper()
+	backlog, err := pipeline.SequencerWriteBacklog().Await(context.Background())
+	Require(t, err)
+	return backlog
+}
+
+func TestPipelinedSequencerCommitsInOrder(t *testing.T) {
+	inner := &recordingSequencer{}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	ctx := context.Background()
+	// Issue writes in reverse order, all within the window, without waiting for them to commit
+	writes := make([]containers.PromiseInterface[struct{}], 16)
+	for i := 15; i >= 0; i-- {
+		writes[i] = pipeline.WriteMessageFromSequencer(arbutil.MessageIndex(i), arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	}
+	for pos, write := range writes {
+		if _, err := write.Await(ctx); err != nil {
+			Fail(t, "write failed", pos, err)
+		}
+	}
+	if len(inner.written) != 16 {
+		Fail(t, "unexpected number of writes", len(inner.written))
+	}
+
+	_, err = pipeline.WriteMessageFromSequencer(16+16, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+	if !errors.Is(err, ErrSequencerWindowFull) {
+		Fail(t, "expected window full error, got", err)
+	}
+	_, err = pipeline.WriteMessageFromSequencer(3, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+	if err == nil {
+		Fail(t, "expected error writing committed position")
+	}
+}
+
+func TestPipelinedSequencerAbortsAfterFailure(t *testing.T) {
+	errInner := errors.New("inner failure")
+	inner := &recordingSequencer{failAt: map[arbutil.MessageIndex]error{1: errInner}}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 8, MaxWait: time.Millisecond * 100})
+	Require(t, err)
+
+	ctx := context.Background()
+	writes := make([]containers.PromiseInterface[struct{}], 4)
+	for i := 3; i >= 0; i-- {
+		writes[i] = pipeline.WriteMessageFromSequencer(arbutil.MessageIndex(i), arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	}
+	results := make([]error, len(writes))
+	for i, write := range writes {
+		_, results[i] = write.Await(ctx)
+	}
+	if results[0] != nil {
+		Fail(t, "unexpected error for first write", results[0])
+	}
+	if !errors.Is(results[1], errInner) {
+		Fail(t, "expected inner error for failing write, got", results[1])
+	}
+	for _, result := range results[2:] {
+		// Later writes are either aborted, or arrive after the failure and time out
+		if !errors.Is(result, ErrSequencerPipelineAborted) && !errors.Is(result, ErrSequencerPipelineTimeout) {
+			Fail(t, "unexpected result for write after failed position", result)
+		}
+	}
+
+	inner.mutex.Lock()
+	delete(inner.failAt, 1)
+	inner.mutex.Unlock()
+	_, err = pipeline.WriteMessageFromSequencer(1, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+	Require(t, err)
+	if len(inner.written) != 2 {
+		Fail(t, "unexpected number of writes", len(inner.written))
+	}
+}
+
+func TestPipelinedSequencerDeadline(t *testing.T) {
+	inner := &recordingSequencer{}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 8, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	ctx := context.Background()
+	// Position 0 is never written, so the write for 1 can't start before its deadline
+	deadline := time.Now().Add(20 * time.Millisecond)
+	_, err = pipeline.WriteMessageFromSequencerWithDeadline(1, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, deadline).Await(ctx)
+	var deadlineErr *execution.ErrCommitDeadlineExceeded
+	if !errors.As(err, &deadlineErr) || deadlineErr.Pos != 1 || !deadlineErr.Deadline.Equal(deadline) {
+		Fail(t, "expected deadline exceeded error, got", err)
+	}
+	if time.Now().Before(deadline) {
+		Fail(t, "write failed before its deadline")
+	}
+
+	start := time.Now()
+	committed, err := pipeline.WriteMessageFromSequencerWithDeadline(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, time.Now().Add(time.Minute)).Await(ctx)
+	Require(t, err)
+	if committed.Before(start) {
+		Fail(t, "commit time before write started", committed, start)
+	}
+	// The expired write left nothing behind, so position 1 can be written again
+	_, err = pipeline.WriteMessageFromSequencerWithDeadline(1, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, time.Now().Add(time.Minute)).Await(ctx)
+	Require(t, err)
+	if len(inner.written) != 2 {
+		Fail(t, "unexpected number of writes", len(inner.written))
+	}
+}
+
+func TestPipelinedSequencerTimeoutFailsLaterWrites(t *testing.T) {
+	inner := &recordingSequencer{}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 8, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	ctx := context.Background()
+	// Position 0 is never written, so the write for 2 waits until the write for 1 misses its deadline
+	later := pipeline.WriteMessageFromSequencer(2, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	if pending := pendingWrites(t, pipeline); pending != 1 {
+		Fail(t, "unexpected pending writes", pending)
+	}
+	start := time.Now()
+	_, err = pipeline.WriteMessageFromSequencerWithDeadline(1, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, start.Add(20*time.Millisecond)).Await(ctx)
+	var deadlineErr *execution.ErrCommitDeadlineExceeded
+	if !errors.As(err, &deadlineErr) {
+		Fail(t, "expected deadline exceeded error, got", err)
+	}
+	_, err = later.Await(ctx)
+	var earlierErr *consensus.ErrEarlierWriteFailed
+	if !errors.Is(err, ErrSequencerPipelineAborted) || !errors.As(err, &earlierErr) || earlierErr.Pos != 2 || earlierErr.FailedPos != 1 {
+		Fail(t, "expected the later write to fail after the timed out one, got", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second*10 {
+		Fail(t, "later write waited for its own timeout", elapsed)
+	}
+	if pending := pendingWrites(t, pipeline); pending != 0 {
+		Fail(t, "unexpected pending writes after timeout", pending)
+	}
+}
+
+func TestPipelinedSequencerBackpressure(t *testing.T) {
+	inner := &recordingSequencer{}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Millisecond * 200, HighWaterMark: 3})
+	Require(t, err)
+
+	// Writes for positions 1 through 3 wait for position 0, filling the pipeline up to its high-water mark
+	ctx := context.Background()
+	var writes []containers.PromiseInterface[struct{}]
+	for pos := arbutil.MessageIndex(1); pos <= 3; pos++ {
+		writes = append(writes, pipeline.WriteMessageFromSequencer(pos, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}))
+	}
+	if pending := pendingWrites(t, pipeline); pending != 3 {
+		Fail(t, "unexpected pending writes", pending)
+	}
+	for _, pos := range []arbutil.MessageIndex{4, 0} {
+		_, err = pipeline.WriteMessageFromSequencer(pos, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+		if !errors.Is(err, execution.ErrBackpressure) {
+			Fail(t, "expected backpressure error, got", err)
+		}
+	}
+	for _, write := range writes {
+		// The first write to time out fails the writes for later positions
+		if _, result := write.Await(ctx); !errors.Is(result, ErrSequencerPipelineTimeout) && !errors.Is(result, ErrSequencerPipelineAborted) {
+			Fail(t, "unexpected result for write waiting on rejected position", result)
+		}
+	}
+
+	// Once the backlog drained, writes are accepted again and their latency is reported
+	_, err = pipeline.WriteMessageFromSequencer(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+	Require(t, err)
+	backlog := backlogOf(t, pipeline)
+	if backlog.PendingWrites != 0 || backlog.WriteLatency <= 0 {
+		Fail(t, "unexpected backlog after write", backlog)
+	}
+}
+
+func TestPipelinedSequencerDumpWriteQueue(t *testing.T) {
+	inner := &recordingSequencer{gate: make(chan struct{})}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+
+	ctx := context.Background()
+	// The write of position 0 is stuck committing, and the later writes wait for it
+	results := make([]containers.PromiseInterface[struct{}], 3)
+	for i := 2; i >= 0; i-- {
+		results[i] = pipeline.WriteMessageFromSequencer(arbutil.MessageIndex(i), arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{})
+	}
+	writes := pipeline.DumpWriteQueue()
+	if len(writes) != 3 {
+		Fail(t, "unexpected pending writes", writes)
+	}
+	for i, write := range writes {
+		if write.Pos != arbutil.MessageIndex(i) || write.Committing != (i == 0) || write.Aborted || write.Enqueued.IsZero() {
+			Fail(t, "unexpected pending write", write)
+		}
+	}
+
+	close(inner.gate)
+	for pos, result := range results {
+		if _, err := result.Await(ctx); err != nil {
+			Fail(t, "write failed", pos, err)
+		}
+	}
+	if writes := pipeline.DumpWriteQueue(); len(writes) != 0 {
+		Fail(t, "writes still pending after committing", writes)
+	}
+}
+
+func TestPipelinedSequencerIdempotentRetry(t *testing.T) {
+	inner := &recordingSequencer{}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second})
+	Require(t, err)
+
+	ctx := context.Background()
+	_, err = pipeline.WriteMessageFromSequencerIdempotent(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, "key").Await(ctx)
+	Require(t, err)
+	// The retry is for a committed position, so it goes to the inner sequencer rather than failing as a conflict
+	_, err = pipeline.WriteMessageFromSequencerIdempotent(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, "key").Await(ctx)
+	Require(t, err)
+	if len(inner.written) != 1 {
+		Fail(t, "retried write applied again", inner.written)
+	}
+	var conflictErr *consensus.ErrConflictingMessage
+	if _, err := pipeline.WriteMessageFromSequencer(0, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx); !errors.As(err, &conflictErr) {
+		Fail(t, "expected conflicting message for a plain repeated write, got", err)
+	}
+	_, err = pipeline.WriteMessageFromSequencerIdempotent(1, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}, "key").Await(ctx)
+	if !errors.Is(err, execution.ErrIdempotencyConflict) {
+		Fail(t, "expected idempotency conflict, got", err)
+	}
+}
+
+func TestPipelinedSequencerDrain(t *testing.T) {
+	ctx := context.Background()
+	inner := &recordingSequencer{gate: make(chan struct{})}
+	pipeline, err := NewPipelinedConsensusSequencer(inner, inner.messageCount, &PipelinedSequencerConfig{Enable: true, WindowSize: 16, MaxWait: time.Second * 10})
+	Require(t, err)
+	msg := arbostypes.MessageWithMetadata{
+		Message: &arbostypes.L1IncomingMessage{Header: &arbostypes.L1IncomingMessageHeader{}, L2msg: []byte("hello")},
+	}
+
+	// Writes for positions 0 and 1 are pending, the first one committing
+	var writes []containers.PromiseInterface[struct{}]
+	for pos := arbutil.MessageIndex(0); pos < 2; pos++ {
+		writes = append(writes, pipeline.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}))
+	}
+	if backlog := backlogOf(t, pipeline); backlog.PendingWrites != 2 || backlog.PendingBytes != 10 {
+		Fail(t, "unexpected pending bytes", backlog)
+	}
+	drain := pipeline.DrainSequencerQueue(ctx)
+	if _, err := pipeline.WriteMessageFromSequencer(2, msg, execution.MessageResult{}).Await(ctx); !errors.Is(err, execution.ErrDraining) {
+		Fail(t, "expected draining error, got", err)
+	}
+	select {
+	case <-drain.ReadyChan():
+		Fail(t, "drain finished with writes pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(inner.gate)
+	result, err := drain.Await(ctx)
+	Require(t, err)
+	if result.MessagesDrained != 2 || result.BytesFlushed != 10 || result.Duration <= 0 {
+		Fail(t, "unexpected drain result", result)
+	}
+	for _, write := range writes {
+		_, err = write.Await(ctx)
+		Require(t, err)
+	}
+
+	// Writes are accepted again after draining, and a drain with nothing pending finishes right away
+	_, err = pipeline.WriteMessageFromSequencer(2, msg, execution.MessageResult{}).Await(ctx)
+	Require(t, err)
+	result, err = pipeline.DrainSequencerQueue(ctx).Await(ctx)
+	Require(t, err)
+	if result.MessagesDrained != 0 {
+		Fail(t, "unexpected drain result without pending writes", result)
+	}
+
+	// Cancelling the drain accepts writes again
+	inner.gate = make(chan struct{})
+	write := pipeline.WriteMessageFromSequencer(3, msg, execution.MessageResult{})
+	cancelCtx, cancel := context.WithCancel(ctx)
+	drain = pipeline.DrainSequencerQueue(cancelCtx)
+	cancel()
+	if _, err := drain.Await(ctx); !errors.Is(err, context.Canceled) {
+		Fail(t, "expected cancelled drain, got", err)
+	}
+	close(inner.gate)
+	_, err = write.Await(ctx)
+	Require(t, err)
+	_, err = pipeline.WriteMessageFromSequencer(4, msg, execution.MessageResult{}).Await(ctx)
+	Require(t, err)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type sequencerWriteKey struct {
+	pos  arbutil.MessageIndex
+	hash common.Hash
+}
+
+// sequencerWriteKeys remembers the idempotency keys of recent sequencer writes, with the position
+// and message hash each was written with, so that retried writes can be recognized.
+type sequencerWriteKeys struct {
+	mutex sync.Mutex
+	keys  *containers.LruCache[string, sequencerWriteKey]
+}
+
+func newSequencerWriteKeys(size int) *sequencerWriteKeys {
+	return &sequencerWriteKeys{keys: containers.NewLruCache[string, sequencerWriteKey](size)}
+}
+
+// check returns true if key was already written with pos and hash, and fails with
+// execution.ErrIdempotencyConflict if it was written with anything else.
+func (k *sequencerWriteKeys) check(key string, pos arbutil.MessageIndex, hash common.Hash) (bool, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	written, ok := k.keys.Get(key)
+	if !ok {
+		return false, nil
+	}
+	if written.pos != pos {
+		return false, fmt.Errorf("%w: key %q was written at pos %d, not %d", execution.ErrIdempotencyConflict, key, written.pos, pos)
+	}
+	if written.hash != hash {
+		return false, fmt.Errorf("%w: key %q was written at pos %d with a different message", execution.ErrIdempotencyConflict, key, pos)
+	}
+	return true, nil
+}
+
+func (k *sequencerWriteKeys) add(key string, pos arbutil.MessageIndex, hash common.Hash) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.keys.Add(key, sequencerWriteKey{pos: pos, hash: hash})
+}
+
+// clear forgets every key, as after a reorg the messages they were written with may be gone
+func (k *sequencerWriteKeys) clear() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.keys.Clear()
+}
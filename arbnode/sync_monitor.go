@@ -2,11 +2,17 @@ package arbnode
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclients"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/headerreader"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 )
@@ -17,39 +23,146 @@ type SyncMonitor struct {
 	inboxReader *InboxReader
 	txStreamer  *TransactionStreamer
 	coordinator *SeqCoordinator
+	feed        *broadcastclients.BroadcastClients
 	initialized bool
+	// snapSynced is set once a node that started from a snapshot first caught up
+	snapSynced atomic.Bool
 
 	syncTargetLock sync.Mutex
-	nextSyncTarget arbutil.MessageIndex
-	syncTarget     arbutil.MessageIndex
+	nextSyncTarget execution.SyncTarget
+	syncTarget     execution.SyncTarget
+
+	lagThresholdsLock sync.Mutex
+	lagThresholds     map[execution.LagSeverity]*lagThreshold
+	// lagCallbacks are the threshold callbacks not yet run by runLagCallbacks, in the order fired
+	lagCallbacks      []func()
+	lagCallbacksReady chan struct{}
+
+	healthLock         sync.Mutex
+	lastProcessedCount arbutil.MessageIndex
+	lastProgressTime   time.Time
+	lastBatchSeenCount uint64
+	lastBatchSeenTime  time.Time
+	catchUpRate        catchUpRate
+	syncTargetRate     catchUpRate
+	batchReadRate      catchUpRate
+}
+
+type lagThreshold struct {
+	messages   arbutil.MessageIndex
+	onExceed   func(lag arbutil.MessageIndex)
+	onRecovery func()
+	breached   bool
 }
 
 func NewSyncMonitor(config func() *SyncMonitorConfig) *SyncMonitor {
 	return &SyncMonitor{
-		config: config,
+		config:            config,
+		lagThresholds:     make(map[execution.LagSeverity]*lagThreshold),
+		lagCallbacksReady: make(chan struct{}, 1),
 	}
 }
 
 type SyncMonitorConfig struct {
-	MsgLag time.Duration `koanf:"msg-lag"`
+	MsgLag            time.Duration        `koanf:"msg-lag"`
+	MaxL1HeaderAge    time.Duration        `koanf:"max-l1-header-age"`
+	MaxFeedLag        arbutil.MessageIndex `koanf:"max-feed-lag"`
+	MaxDeliveryStall  time.Duration        `koanf:"max-delivery-stall"`
+	MaxBatchAge       time.Duration        `koanf:"max-batch-age"`
+	CatchUpRateWindow time.Duration        `koanf:"catch-up-rate-window"`
 }
 
 var DefaultSyncMonitorConfig = SyncMonitorConfig{
-	MsgLag: time.Second,
+	MsgLag:            time.Second,
+	MaxL1HeaderAge:    time.Minute * 5,
+	MaxFeedLag:        1000,
+	MaxDeliveryStall:  time.Minute,
+	MaxBatchAge:       0,
+	CatchUpRateWindow: time.Minute * 5,
 }
 
 var TestSyncMonitorConfig = SyncMonitorConfig{
-	MsgLag: time.Millisecond * 10,
+	MsgLag:            time.Millisecond * 10,
+	MaxL1HeaderAge:    time.Minute * 5,
+	MaxFeedLag:        1000,
+	MaxDeliveryStall:  time.Second * 10,
+	MaxBatchAge:       0,
+	CatchUpRateWindow: time.Second * 10,
 }
 
 func SyncMonitorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".msg-lag", DefaultSyncMonitorConfig.MsgLag, "allowed msg lag while still considered in sync")
+	f.Duration(prefix+".max-l1-header-age", DefaultSyncMonitorConfig.MaxL1HeaderAge, "maximum age of the last parent chain header while still considered healthy")
+	f.Uint64(prefix+".max-feed-lag", uint64(DefaultSyncMonitorConfig.MaxFeedLag), "maximum number of messages processing may lag behind the sync target while still considered healthy")
+	f.Duration(prefix+".max-delivery-stall", DefaultSyncMonitorConfig.MaxDeliveryStall, "maximum time without processing new messages while behind the sync target that is still considered healthy")
+	f.Duration(prefix+".max-batch-age", DefaultSyncMonitorConfig.MaxBatchAge, "maximum time since a new batch was seen while still considered healthy (0 = disabled)")
+	f.Duration(prefix+".catch-up-rate-window", DefaultSyncMonitorConfig.CatchUpRateWindow, "time window the message processing, batch reading and sync target rates of the sync estimates are averaged over")
+}
+
+type catchUpSample struct {
+	time  time.Time
+	count uint64
+}
+
+// catchUpRate averages the rate of an increasing count, such as processed messages, over a
+// sliding time window, so that bursts and brief stalls don't make the catch-up estimate oscillate.
+type catchUpRate struct {
+	samples []catchUpSample
+}
+
+func (r *catchUpRate) update(now time.Time, count uint64, window time.Duration) {
+	if len(r.samples) > 0 && count < r.samples[len(r.samples)-1].count {
+		// a reorg, the old samples are meaningless now
+		r.samples = r.samples[:0]
+	}
+	r.samples = append(r.samples, catchUpSample{time: now, count: count})
+	drop := 0
+	for drop < len(r.samples)-2 && now.Sub(r.samples[drop+1].time) >= window {
+		drop++
+	}
+	r.samples = r.samples[drop:]
+}
+
+// rate returns the count's increase per second across the window, or zero with fewer than two samples
+func (r *catchUpRate) rate() float64 {
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first := r.samples[0]
+	last := r.samples[len(r.samples)-1]
+	elapsed := last.time.Sub(first.time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(last.count-first.count) / elapsed
+}
+
+// Estimates of the time to sync are capped at this, anything longer isn't meaningful
+const maxTimeToSync = time.Hour * 24 * 30
+
+// estimateTimeToSync estimates how long processing the remaining messages takes, with messages
+// processed at msgRate while the sync target advances at targetRate, both per second.
+// It returns false if processing doesn't outpace the sync target.
+func estimateTimeToSync(remaining arbutil.MessageIndex, msgRate, targetRate float64) (time.Duration, bool) {
+	if remaining == 0 {
+		return 0, true
+	}
+	closingRate := msgRate - targetRate
+	if closingRate <= 0 {
+		return 0, false
+	}
+	seconds := float64(remaining) / closingRate
+	if seconds >= maxTimeToSync.Seconds() {
+		return maxTimeToSync, true
+	}
+	return time.Duration(seconds * float64(time.Second)), true
 }
 
-func (s *SyncMonitor) Initialize(inboxReader *InboxReader, txStreamer *TransactionStreamer, coordinator *SeqCoordinator) {
+func (s *SyncMonitor) Initialize(inboxReader *InboxReader, txStreamer *TransactionStreamer, coordinator *SeqCoordinator, feed *broadcastclients.BroadcastClients) {
 	s.inboxReader = inboxReader
 	s.txStreamer = txStreamer
 	s.coordinator = coordinator
+	s.feed = feed
 	s.initialized = true
 }
 
@@ -59,23 +172,232 @@ func (s *SyncMonitor) updateSyncTarget(ctx context.Context) time.Duration {
 		log.Warn("failed readin max msg count", "err", err)
 		return s.config().MsgLag
 	}
+	syncTarget := s.advanceSyncTarget(nextSyncTarget).Count
+	if s.Synced() {
+		s.snapSynced.Store(true)
+	}
+	s.checkLagThresholds(syncTarget)
+	s.updateHealth(syncTarget)
+	return s.config().MsgLag
+}
+
+// advanceSyncTarget makes the previously read target current, and returns it. The target is
+// only established once it was read twice, giving messages read in between time to be processed.
+func (s *SyncMonitor) advanceSyncTarget(next execution.SyncTarget) execution.SyncTarget {
 	s.syncTargetLock.Lock()
 	defer s.syncTargetLock.Unlock()
 	s.syncTarget = s.nextSyncTarget
-	s.nextSyncTarget = nextSyncTarget
-	return s.config().MsgLag
+	s.nextSyncTarget = next
+	return s.syncTarget
+}
+
+// updateHealth records when messages were last processed and when a new batch was last seen
+func (s *SyncMonitor) updateHealth(syncTarget arbutil.MessageIndex) {
+	processed, err := s.txStreamer.GetProcessedMessageCount()
+	if err != nil {
+		log.Warn("failed reading processed msg count", "err", err)
+		return
+	}
+	now := time.Now()
+	s.healthLock.Lock()
+	defer s.healthLock.Unlock()
+	window := s.config().CatchUpRateWindow
+	s.catchUpRate.update(now, uint64(processed), window)
+	s.syncTargetRate.update(now, uint64(syncTarget), window)
+	if processed > s.lastProcessedCount || processed >= syncTarget {
+		s.lastProcessedCount = processed
+		s.lastProgressTime = now
+	}
+	if s.inboxReader != nil {
+		s.batchReadRate.update(now, s.inboxReader.GetLastReadBatchCount(), window)
+		batchSeen := s.inboxReader.GetLastSeenBatchCount()
+		if batchSeen > s.lastBatchSeenCount {
+			s.lastBatchSeenCount = batchSeen
+			s.lastBatchSeenTime = now
+		}
+	}
+}
+
+func (s *SyncMonitor) Healthy() execution.HealthStatus {
+	if !s.initialized || !s.Started() {
+		notStarted := execution.ComponentHealth{Detail: "sync monitor not started"}
+		return execution.HealthStatus{
+			ParentChain:     notStarted,
+			Feed:            notStarted,
+			MessageDelivery: notStarted,
+			Batches:         notStarted,
+		}
+	}
+	config := s.config()
+	now := time.Now()
+	status := execution.HealthStatus{}
+
+	status.ParentChain = execution.ComponentHealth{Healthy: true, Detail: "not configured"}
+	if s.inboxReader != nil && s.inboxReader.l1Reader != nil {
+		header, err := s.inboxReader.l1Reader.LastHeaderWithError()
+		if err != nil {
+			status.ParentChain = execution.ComponentHealth{Detail: fmt.Sprintf("error reading headers: %v", err)}
+		} else if header == nil {
+			status.ParentChain = execution.ComponentHealth{Detail: "no header read yet"}
+		} else if age := now.Sub(time.Unix(int64(header.Time), 0)); age > config.MaxL1HeaderAge {
+			status.ParentChain = execution.ComponentHealth{Detail: fmt.Sprintf("last header %v is %v old", header.Number, age)}
+		} else {
+			status.ParentChain = execution.ComponentHealth{Healthy: true}
+		}
+	}
+
+	syncTarget := s.SyncTargetMessageCount()
+	processed, err := s.txStreamer.GetProcessedMessageCount()
+	var lag arbutil.MessageIndex
+	if err == nil && syncTarget > processed {
+		lag = syncTarget - processed
+	}
+
+	status.Feed = execution.ComponentHealth{Healthy: true, Detail: "not configured"}
+	if s.feed != nil {
+		if s.feed.Connected() <= 0 {
+			status.Feed = execution.ComponentHealth{Detail: "no connected feed"}
+		} else if lag > config.MaxFeedLag {
+			status.Feed = execution.ComponentHealth{Detail: fmt.Sprintf("message lag %d exceeds %d", lag, config.MaxFeedLag)}
+		} else {
+			status.Feed = execution.ComponentHealth{Healthy: true}
+		}
+	}
+
+	s.healthLock.Lock()
+	lastProgressTime := s.lastProgressTime
+	lastBatchSeenTime := s.lastBatchSeenTime
+	s.healthLock.Unlock()
+
+	if err != nil {
+		status.MessageDelivery = execution.ComponentHealth{Detail: fmt.Sprintf("error reading processed msg count: %v", err)}
+	} else if stall := now.Sub(lastProgressTime); lag > 0 && stall > config.MaxDeliveryStall {
+		status.MessageDelivery = execution.ComponentHealth{Detail: fmt.Sprintf("no messages processed for %v with lag %d", stall, lag)}
+	} else {
+		status.MessageDelivery = execution.ComponentHealth{Healthy: true}
+	}
+
+	if !lastBatchSeenTime.IsZero() {
+		status.TimeSinceLastBatch = now.Sub(lastBatchSeenTime)
+	}
+	status.Batches = execution.ComponentHealth{Healthy: true}
+	if config.MaxBatchAge > 0 && s.inboxReader != nil {
+		if lastBatchSeenTime.IsZero() {
+			status.Batches = execution.ComponentHealth{Detail: "no batch seen yet"}
+		} else if status.TimeSinceLastBatch > config.MaxBatchAge {
+			status.Batches = execution.ComponentHealth{Detail: fmt.Sprintf("no new batch seen for %v", status.TimeSinceLastBatch)}
+		}
+	}
+
+	status.Healthy = status.ParentChain.Healthy && status.Feed.Healthy && status.MessageDelivery.Healthy && status.Batches.Healthy
+	return status
+}
+
+func (s *SyncMonitor) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) error {
+	if severity != execution.LagSeverityWarn && severity != execution.LagSeverityCritical {
+		return fmt.Errorf("unknown lag severity %v", severity)
+	}
+	if onExceed == nil {
+		return errors.New("lag threshold requires an onExceed callback")
+	}
+	s.lagThresholdsLock.Lock()
+	defer s.lagThresholdsLock.Unlock()
+	s.lagThresholds[severity] = &lagThreshold{
+		messages:   messages,
+		onExceed:   onExceed,
+		onRecovery: onRecovery,
+	}
+	return nil
+}
+
+func (s *SyncMonitor) ClearLagThreshold() error {
+	s.lagThresholdsLock.Lock()
+	defer s.lagThresholdsLock.Unlock()
+	s.lagThresholds = make(map[execution.LagSeverity]*lagThreshold)
+	return nil
+}
+
+// checkLagThresholds fires the callbacks of thresholds whose breached state changed.
+// The callbacks are queued for runLagCallbacks, so slow callbacks don't hold up updateSyncTarget.
+func (s *SyncMonitor) checkLagThresholds(syncTarget arbutil.MessageIndex) {
+	s.lagThresholdsLock.Lock()
+	if len(s.lagThresholds) == 0 {
+		s.lagThresholdsLock.Unlock()
+		return
+	}
+	s.lagThresholdsLock.Unlock()
+	processed, err := s.txStreamer.GetProcessedMessageCount()
+	if err != nil {
+		log.Warn("failed reading processed msg count", "err", err)
+		return
+	}
+	var lag arbutil.MessageIndex
+	if syncTarget > processed {
+		lag = syncTarget - processed
+	}
+	var callbacks []func()
+	s.lagThresholdsLock.Lock()
+	for severity, threshold := range s.lagThresholds {
+		if !threshold.breached && lag > threshold.messages {
+			threshold.breached = true
+			log.Warn("message lag exceeded threshold", "severity", severity, "lag", lag, "threshold", threshold.messages)
+			onExceed := threshold.onExceed
+			callbacks = append(callbacks, func() { onExceed(lag) })
+		} else if threshold.breached && lag <= threshold.messages {
+			threshold.breached = false
+			log.Info("message lag recovered below threshold", "severity", severity, "lag", lag, "threshold", threshold.messages)
+			if threshold.onRecovery != nil {
+				callbacks = append(callbacks, threshold.onRecovery)
+			}
+		}
+	}
+	s.lagCallbacks = append(s.lagCallbacks, callbacks...)
+	s.lagThresholdsLock.Unlock()
+	if len(callbacks) > 0 {
+		select {
+		case s.lagCallbacksReady <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// runLagCallbacks runs the queued lag threshold callbacks one at a time, in the order they were
+// fired, so a recovery is never seen before the exceeding it follows. Callbacks are called
+// without holding the lock, so they may update the thresholds.
+func (s *SyncMonitor) runLagCallbacks(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.lagCallbacksReady:
+		}
+		s.lagThresholdsLock.Lock()
+		callbacks := s.lagCallbacks
+		s.lagCallbacks = nil
+		s.lagThresholdsLock.Unlock()
+		for _, callback := range callbacks {
+			if ctx.Err() != nil {
+				return
+			}
+			callback()
+		}
+	}
 }
 
 func (s *SyncMonitor) SyncTargetMessageCount() arbutil.MessageIndex {
+	return s.SyncTarget().Count
+}
+
+func (s *SyncMonitor) SyncTarget() execution.SyncTarget {
 	s.syncTargetLock.Lock()
 	defer s.syncTargetLock.Unlock()
 	return s.syncTarget
 }
 
-func (s *SyncMonitor) maxMessageCount() (arbutil.MessageIndex, error) {
+func (s *SyncMonitor) maxMessageCount() (execution.SyncTarget, error) {
 	msgCount, err := s.txStreamer.GetMessageCount()
 	if err != nil {
-		return 0, err
+		return execution.SyncTarget{}, err
 	}
 
 	pending := s.txStreamer.FeedPendingMessageCount()
@@ -83,13 +405,14 @@ func (s *SyncMonitor) maxMessageCount() (arbutil.MessageIndex, error) {
 		msgCount = pending
 	}
 
+	var batchMsgCount arbutil.MessageIndex
 	if s.inboxReader != nil {
 		batchProcessed := s.inboxReader.GetLastReadBatchCount()
 
 		if batchProcessed > 0 {
-			batchMsgCount, err := s.inboxReader.Tracker().GetBatchMessageCount(batchProcessed - 1)
+			batchMsgCount, err = s.inboxReader.Tracker().GetBatchMessageCount(batchProcessed - 1)
 			if err != nil {
-				return msgCount, err
+				return execution.SyncTarget{Count: msgCount}, err
 			}
 			if batchMsgCount > msgCount {
 				msgCount = batchMsgCount
@@ -97,78 +420,141 @@ func (s *SyncMonitor) maxMessageCount() (arbutil.MessageIndex, error) {
 		}
 	}
 
+	var coordinatorMessageCount arbutil.MessageIndex
 	if s.coordinator != nil {
-		coordinatorMessageCount, err := s.coordinator.GetRemoteMsgCount() //NOTE: this creates a remote call
+		coordinatorMessageCount, err = s.coordinator.GetRemoteMsgCount() //NOTE: this creates a remote call
 		if err != nil {
-			return msgCount, err
+			return execution.SyncTarget{Count: msgCount}, err
 		}
 		if coordinatorMessageCount > msgCount {
 			msgCount = coordinatorMessageCount
 		}
 	}
 
-	return msgCount, nil
+	target := execution.SyncTarget{Established: true, Count: msgCount}
+	if s.feed != nil && pending >= msgCount {
+		target.SourcePeers += int(s.feed.Connected())
+	}
+	if s.coordinator != nil && coordinatorMessageCount >= msgCount {
+		target.SourcePeers++
+		target.Confidence = execution.SyncConfidencePeerMajority
+	}
+	if s.inboxReader != nil && batchMsgCount >= msgCount {
+		target.Confidence = execution.SyncConfidenceL1Confirmed
+	}
+	return target, nil
 }
 
+// FullSyncProgressMap is SyncProgressSnapshot as a map, keyed by its JSON field names.
+//
+// Deprecated: use SyncProgressSnapshot.
 func (s *SyncMonitor) FullSyncProgressMap() map[string]interface{} {
-	res := make(map[string]interface{})
+	return syncProgressMap(s.SyncProgressSnapshot(context.Background()))
+}
 
-	if !s.initialized {
-		res["err"] = "uninitialized"
-		return res
+// syncProgressMap is snapshot as a map, with an "err" entry if it couldn't be read in full
+func syncProgressMap(snapshot execution.SyncProgressSnapshot, err error) map[string]interface{} {
+	res, mapErr := snapshot.Map()
+	if mapErr != nil {
+		return map[string]interface{}{"err": mapErr.Error()}
 	}
-
-	syncTarget := s.SyncTargetMessageCount()
-	res["syncTargetMsgCount"] = syncTarget
-
-	msgCount, err := s.txStreamer.GetMessageCount()
 	if err != nil {
-		res["msgCountError"] = err.Error()
-		return res
+		res["err"] = err.Error()
 	}
-	res["msgCount"] = msgCount
-
-	res["feedPendingMessageCount"] = s.txStreamer.FeedPendingMessageCount()
-
-	if s.inboxReader != nil {
-		batchSeen := s.inboxReader.GetLastSeenBatchCount()
-		res["batchSeen"] = batchSeen
-
-		batchProcessed := s.inboxReader.GetLastReadBatchCount()
-		res["batchProcessed"] = batchProcessed
+	return res
+}
 
-		processedBatchMsgs, err := s.inboxReader.Tracker().GetBatchMessageCount(batchProcessed - 1)
-		if err != nil {
-			res["batchMetadataError"] = err.Error()
-		} else {
-			res["messageOfProcessedBatch"] = processedBatchMsgs
-		}
+func (s *SyncMonitor) SyncProgressSnapshot(ctx context.Context) (execution.SyncProgressSnapshot, error) {
+	snapshot := execution.SyncProgressSnapshot{
+		Version:  execution.SyncProgressSnapshotVersion,
+		SyncMode: execution.SyncModeNone,
+	}
+	if !s.initialized {
+		return snapshot, nil
+	}
+	snapshot.SyncMode = s.SyncMode()
+	snapshot.TargetMsgCount = s.SyncTargetMessageCount()
+	processed, err := s.txStreamer.GetProcessedMessageCount()
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.ProcessedMsgCount = processed
+	if snapshot.TargetMsgCount > processed {
+		snapshot.RemainingMsgCount = snapshot.TargetMsgCount - processed
+	}
+	s.healthLock.Lock()
+	snapshot.MsgThroughput = s.catchUpRate.rate()
+	snapshot.BatchThroughput = s.batchReadRate.rate()
+	targetRate := s.syncTargetRate.rate()
+	s.healthLock.Unlock()
+	if timeToSync, ok := estimateTimeToSync(snapshot.RemainingMsgCount, snapshot.MsgThroughput, targetRate); ok {
+		snapshot.EstimatedTimeToSync = &timeToSync
+	}
 
-		l1reader := s.inboxReader.l1Reader
-		if l1reader != nil {
-			header, err := l1reader.LastHeaderWithError()
-			if err != nil {
-				res["lastL1HeaderErr"] = err
-			}
-			if header != nil {
-				res["lastL1BlockNum"] = header.Number
-				res["lastl1BlockHash"] = header.Hash()
-			}
-		}
+	if s.inboxReader == nil || s.inboxReader.l1Reader == nil {
+		return snapshot, nil
+	}
+	l1Reader := s.inboxReader.l1Reader
+	header, err := l1Reader.LastHeaderWithError()
+	if err != nil {
+		return snapshot, err
+	}
+	if header != nil {
+		snapshot.L1Block = header.Number.Uint64()
+	}
+	if !l1Reader.UseFinalityData() {
+		return snapshot, nil
+	}
+	safe, err := s.inboxReader.GetSafeMsgCount(ctx)
+	if err != nil && !errors.Is(err, headerreader.ErrBlockNumberNotSupported) {
+		return snapshot, err
+	}
+	snapshot.SafeMsgCount = safe
+	finalized, err := s.inboxReader.GetFinalizedMsgCount(ctx)
+	if err != nil && !errors.Is(err, headerreader.ErrBlockNumberNotSupported) {
+		return snapshot, err
 	}
+	snapshot.FinalizedMsgCount = finalized
+	return snapshot, nil
+}
 
-	if s.coordinator != nil {
-		coordinatorMessageCount, err := s.coordinator.GetRemoteMsgCount() //NOTE: this creates a remote call
-		if err != nil {
-			res["coordinatorMsgCountError"] = err.Error()
-		} else {
-			res["coordinatorMessageCount"] = coordinatorMessageCount
-		}
+// SyncMode is SyncModeSnap while a node started from a snapshot catches up for the first time, and
+// SyncModeFull whenever it falls behind after that, or if it didn't start from a snapshot.
+// Catching up is noticed by updateSyncTarget.
+func (s *SyncMonitor) SyncMode() execution.SyncMode {
+	if !s.initialized || s.Synced() {
+		return execution.SyncModeNone
 	}
+	if s.inboxReader != nil && s.inboxReader.tracker.snapSyncConfig.Enabled && !s.snapSynced.Load() {
+		return execution.SyncModeSnap
+	}
+	return execution.SyncModeFull
+}
 
-	return res
+func (s *SyncMonitor) CatchUpEstimate() (execution.CatchUpEstimate, error) {
+	var estimate execution.CatchUpEstimate
+	if !s.initialized {
+		return estimate, nil
+	}
+	syncTarget := s.SyncTargetMessageCount()
+	processed, err := s.txStreamer.GetProcessedMessageCount()
+	if err != nil {
+		return estimate, err
+	}
+	if syncTarget > processed {
+		estimate.MessagesRemaining = syncTarget - processed
+	}
+	s.healthLock.Lock()
+	estimate.Rate = s.catchUpRate.rate()
+	targetRate := s.syncTargetRate.rate()
+	s.healthLock.Unlock()
+	estimate.TimeToSync, _ = estimateTimeToSync(estimate.MessagesRemaining, estimate.Rate, targetRate)
+	return estimate, nil
 }
 
+// SyncProgressMap is FullSyncProgressMap, or empty once synced.
+//
+// Deprecated: use SyncProgressSnapshot.
 func (s *SyncMonitor) SyncProgressMap() map[string]interface{} {
 	if s.Synced() {
 		return make(map[string]interface{})
@@ -179,6 +565,10 @@ func (s *SyncMonitor) SyncProgressMap() map[string]interface{} {
 
 func (s *SyncMonitor) Start(ctx_in context.Context) {
 	s.StopWaiter.Start(ctx_in, s)
+	s.healthLock.Lock()
+	s.lastProgressTime = time.Now()
+	s.healthLock.Unlock()
+	s.LaunchThread(s.runLagCallbacks)
 	s.CallIteratively(s.updateSyncTarget)
 }
 
@@ -189,14 +579,17 @@ func (s *SyncMonitor) Synced() bool {
 	if !s.Started() {
 		return false
 	}
-	syncTarget := s.SyncTargetMessageCount()
+	syncTarget := s.SyncTarget()
+	if !syncTarget.Established {
+		return false
+	}
 
 	msgCount, err := s.txStreamer.GetMessageCount()
 	if err != nil {
 		return false
 	}
 
-	if syncTarget > msgCount {
+	if syncTarget.Count > msgCount {
 		return false
 	}
 
@@ -0,0 +1,338 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclients"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestCatchUpRate(t *testing.T) {
+	window := time.Minute
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rate catchUpRate
+	rate.update(start, 0, window)
+	if rate.rate() != 0 {
+		t.Fatal("rate reported with a single sample", rate.rate())
+	}
+
+	// A burst in the middle of the window is averaged over the whole window
+	for i := 1; i <= 60; i++ {
+		processed := uint64(i) * 10
+		if i > 30 {
+			processed += 600
+		}
+		rate.update(start.Add(time.Duration(i)*time.Second), processed, window)
+	}
+	if got := rate.rate(); got != 20 {
+		t.Fatal("unexpected rate over the window", got)
+	}
+
+	// Samples older than the window stop counting
+	for i := 61; i <= 120; i++ {
+		rate.update(start.Add(time.Duration(i)*time.Second), 1200+uint64(i-60)*5, window)
+	}
+	if got := rate.rate(); got != 5 {
+		t.Fatal("old samples still counted", got)
+	}
+
+	// A reorg discards the samples
+	rate.update(start.Add(121*time.Second), 10, window)
+	if rate.rate() != 0 {
+		t.Fatal("rate reported across a reorg", rate.rate())
+	}
+}
+
+func TestEstimateTimeToSync(t *testing.T) {
+	window := time.Minute
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	var processedRate, targetRate catchUpRate
+	processed := uint64(0)
+	target := uint64(10_000)
+	sample := func(second int) {
+		now := start.Add(time.Duration(second) * time.Second)
+		processedRate.update(now, processed, window)
+		targetRate.update(now, target, window)
+	}
+	estimate := func() (time.Duration, bool) {
+		return estimateTimeToSync(arbutil.MessageIndex(target-processed), processedRate.rate(), targetRate.rate())
+	}
+	sample(0)
+	if _, ok := estimate(); ok {
+		t.Fatal("estimate without any throughput")
+	}
+
+	// Catching up at 100 messages per second while the target advances at 20
+	for i := 1; i <= 60; i++ {
+		processed += 100
+		target += 20
+		sample(i)
+	}
+	// 5200 messages remain, closing in at 80 per second
+	if got, ok := estimate(); !ok || got != 65*time.Second {
+		t.Fatal("unexpected estimate while catching up", got, ok)
+	}
+
+	// A brief stall slows the estimate down without losing it
+	for i := 61; i <= 65; i++ {
+		target += 20
+		sample(i)
+	}
+	got, ok := estimate()
+	if !ok || got <= 60*time.Second || got > 5*time.Minute {
+		t.Fatal("unexpected estimate during a brief stall", got, ok)
+	}
+
+	// Once the target outpaces processing across the window, there's no estimate
+	for i := 66; i <= 130; i++ {
+		processed += 10
+		target += 20
+		sample(i)
+	}
+	if got, ok := estimate(); ok {
+		t.Fatal("estimate while the target outpaces processing", got)
+	}
+
+	// Barely outpacing the target is capped
+	if got, ok := estimateTimeToSync(1_000_000, 1.000001, 1); !ok || got != maxTimeToSync {
+		t.Fatal("estimate not capped", got, ok)
+	}
+	if got, ok := estimateTimeToSync(0, 0, 10); !ok || got != 0 {
+		t.Fatal("unexpected estimate when synced", got, ok)
+	}
+}
+
+func TestSyncTargetEstablished(t *testing.T) {
+	monitor := NewSyncMonitor(func() *SyncMonitorConfig { return &TestSyncMonitorConfig })
+	if target := monitor.SyncTarget(); target.Established {
+		t.Fatal("sync target established before it was read", target)
+	}
+	if monitor.Synced() {
+		t.Fatal("synced before initialization")
+	}
+
+	// The first read target only becomes current on the next read
+	first := execution.SyncTarget{Established: true}
+	if target := monitor.advanceSyncTarget(first); target.Established {
+		t.Fatal("sync target established after a single read", target)
+	}
+	if target := monitor.advanceSyncTarget(execution.SyncTarget{Established: true, Count: 5}); target != first {
+		t.Fatal("unexpected sync target after two reads", target)
+	}
+	if target := monitor.SyncTarget(); !target.Established || target.Count != 0 {
+		t.Fatal("zero sync target not established", target)
+	}
+}
+
+func TestSyncMode(t *testing.T) {
+	monitor := NewSyncMonitor(func() *SyncMonitorConfig { return &TestSyncMonitorConfig })
+	if mode := monitor.SyncMode(); mode != execution.SyncModeNone {
+		t.Fatal("unexpected sync mode before initialization", mode)
+	}
+
+	// Not started, so not synced
+	monitor.initialized = true
+	if mode := monitor.SyncMode(); mode != execution.SyncModeFull {
+		t.Fatal("unexpected sync mode without a snapshot", mode)
+	}
+	monitor.inboxReader = &InboxReader{tracker: &InboxTracker{snapSyncConfig: SnapSyncConfig{Enabled: true}}}
+	if mode := monitor.SyncMode(); mode != execution.SyncModeSnap {
+		t.Fatal("unexpected sync mode from a snapshot", mode)
+	}
+	// Falling behind after catching up from the snapshot is synced from the node's own state
+	monitor.snapSynced.Store(true)
+	if mode := monitor.SyncMode(); mode != execution.SyncModeFull {
+		t.Fatal("unexpected sync mode after snap sync completed", mode)
+	}
+}
+
+func TestSyncProgressMap(t *testing.T) {
+	_, streamer, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	monitor := NewSyncMonitor(func() *SyncMonitorConfig { return &TestSyncMonitorConfig })
+	monitor.Initialize(nil, streamer, nil, nil)
+
+	snapshot, err := monitor.SyncProgressSnapshot(context.Background())
+	Require(t, err)
+	progress := monitor.FullSyncProgressMap()
+	if progress["err"] != nil {
+		Fail(t, "unexpected error in sync progress", progress["err"])
+	}
+	if progress["version"] != json.Number(fmt.Sprint(execution.SyncProgressSnapshotVersion)) {
+		Fail(t, "unexpected version", progress["version"])
+	}
+	if progress["syncMode"] != string(snapshot.SyncMode) {
+		Fail(t, "unexpected sync mode", progress["syncMode"], snapshot.SyncMode)
+	}
+	if progress["processedMsgCount"] != json.Number(fmt.Sprint(snapshot.ProcessedMsgCount)) {
+		Fail(t, "unexpected processed message count", progress["processedMsgCount"], snapshot.ProcessedMsgCount)
+	}
+
+	// The map holds all of the snapshot, and nothing else
+	data, err := json.Marshal(progress)
+	Require(t, err)
+	var decoded execution.SyncProgressSnapshot
+	Require(t, json.Unmarshal(data, &decoded))
+	if !reflect.DeepEqual(decoded, snapshot) {
+		Fail(t, "sync progress map differs from the snapshot", progress, snapshot)
+	}
+	expected, err := snapshot.Map()
+	Require(t, err)
+	if len(progress) != len(expected) {
+		Fail(t, "unexpected sync progress map keys", progress)
+	}
+
+	// Not started, so not synced
+	if !reflect.DeepEqual(monitor.SyncProgressMap(), progress) {
+		Fail(t, "unexpected sync progress map while syncing", monitor.SyncProgressMap())
+	}
+}
+
+func TestLagThresholdCallbacksDontBlockSyncTarget(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, streamer, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	monitor := NewSyncMonitor(func() *SyncMonitorConfig { return &TestSyncMonitorConfig })
+	monitor.Initialize(nil, streamer, nil, nil)
+	// Runs the callbacks without updating the sync target, which the test does itself
+	monitor.StopWaiter.Start(ctx, monitor)
+	monitor.LaunchThread(monitor.runLagCallbacks)
+	defer monitor.StopAndWait()
+
+	processed, err := streamer.GetProcessedMessageCount()
+	Require(t, err)
+	exceeded := make(chan arbutil.MessageIndex, 1)
+	recovered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	releaseCallback := func() { releaseOnce.Do(func() { close(release) }) }
+	// Lets the runner stop if the test fails while the callback blocks
+	defer releaseCallback()
+	Require(t, monitor.SetLagThreshold(execution.LagSeverityWarn, 5, func(lag arbutil.MessageIndex) {
+		exceeded <- lag
+		<-release
+	}, func() {
+		recovered <- struct{}{}
+	}))
+
+	checkLagThresholds := func(syncTarget arbutil.MessageIndex) {
+		t.Helper()
+		done := make(chan struct{})
+		go func() {
+			monitor.checkLagThresholds(syncTarget)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			Fail(t, "checking the lag thresholds blocked on a callback")
+		}
+	}
+
+	checkLagThresholds(processed + 10)
+	select {
+	case lag := <-exceeded:
+		if lag != 10 {
+			Fail(t, "unexpected lag", lag)
+		}
+	case <-time.After(time.Second * 5):
+		Fail(t, "onExceed not called")
+	}
+
+	// The recovery waits for the blocked onExceed, without blocking the check
+	checkLagThresholds(processed)
+	select {
+	case <-recovered:
+		Fail(t, "onRecovery called before onExceed returned")
+	case <-time.After(time.Millisecond * 50):
+	}
+	releaseCallback()
+	select {
+	case <-recovered:
+	case <-time.After(time.Second * 5):
+		Fail(t, "onRecovery not called")
+	}
+}
+
+func TestSyncMonitorHealthy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, streamer, _, _ := NewTransactionStreamerForTest(t, common.Address{})
+	config := TestSyncMonitorConfig
+	config.MaxDeliveryStall = time.Minute
+	monitor := NewSyncMonitor(func() *SyncMonitorConfig { return &config })
+	monitor.Initialize(nil, streamer, nil, nil)
+	if status := monitor.Healthy(); status.Healthy || status.MessageDelivery.Healthy {
+		Fail(t, "healthy before starting", status)
+	}
+	// Health is checked without updating the sync target, which the test does itself
+	monitor.StopWaiter.Start(ctx, monitor)
+	defer monitor.StopAndWait()
+
+	processed, err := streamer.GetProcessedMessageCount()
+	Require(t, err)
+	setSyncTarget := func(count arbutil.MessageIndex) {
+		target := execution.SyncTarget{Established: true, Count: count}
+		monitor.advanceSyncTarget(target)
+		monitor.advanceSyncTarget(target)
+	}
+	setLastProgress := func(ago time.Duration) {
+		monitor.healthLock.Lock()
+		defer monitor.healthLock.Unlock()
+		monitor.lastProcessedCount = processed
+		monitor.lastProgressTime = time.Now().Add(-ago)
+	}
+
+	// Lagging is healthy while messages are still being processed
+	setSyncTarget(processed + 10)
+	setLastProgress(0)
+	if status := monitor.Healthy(); !status.Healthy || !status.MessageDelivery.Healthy {
+		Fail(t, "unhealthy while catching up", status)
+	}
+
+	// Lagging without processing for longer than allowed is a stall
+	setLastProgress(2 * time.Minute)
+	status := monitor.Healthy()
+	if status.Healthy || status.MessageDelivery.Healthy {
+		Fail(t, "healthy while stalled", status)
+	}
+	if !strings.Contains(status.MessageDelivery.Detail, "lag 10") {
+		Fail(t, "stall detail doesn't report the lag", status.MessageDelivery.Detail)
+	}
+	// Reading the sync target again without processing more messages keeps the stall
+	monitor.updateHealth(processed + 10)
+	if status := monitor.Healthy(); status.MessageDelivery.Healthy {
+		Fail(t, "stall cleared without progress", status)
+	}
+
+	// Without lag, there's nothing to process, so not processing isn't a stall
+	setSyncTarget(processed)
+	if status := monitor.Healthy(); !status.Healthy || !status.MessageDelivery.Healthy {
+		Fail(t, "unhealthy without lag", status)
+	}
+	// Catching up counts as progress once lagging again
+	monitor.updateHealth(processed)
+	setSyncTarget(processed + 10)
+	if status := monitor.Healthy(); !status.Healthy {
+		Fail(t, "unhealthy right after catching up", status)
+	}
+
+	// A configured feed without connections is unhealthy, whatever the lag
+	monitor.feed = &broadcastclients.BroadcastClients{}
+	status = monitor.Healthy()
+	if status.Healthy || status.Feed.Healthy || !status.MessageDelivery.Healthy {
+		Fail(t, "unexpected health without a connected feed", status)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,16 +27,20 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 
+	"github.com/offchainlabs/nitro/arbcompress"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcaster"
 	m "github.com/offchainlabs/nitro/broadcaster/message"
+	"github.com/offchainlabs/nitro/consensus"
 	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/staker"
 	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/util/sharedmetrics"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 )
@@ -69,12 +74,43 @@ type TransactionStreamer struct {
 	broadcastServer *broadcaster.Broadcaster
 	inboxReader     *InboxReader
 	delayedBridge   *DelayedBridge
+
+	writeObserversMutex   sync.Mutex
+	writeObservers        []*writeObserver
+	writeObserversStarted bool
+
+	sequencerWritesInFlight atomic.Int32
+	sequencerWriteLatency   *writeLatencyTracker
+	sequencerDrainer        *sequencerDrainer
+	sequencerWriteKeys      *sequencerWriteKeys
+	auditLog                *SequencerAuditLog
+}
+
+var writeObserverDroppedCounter = metrics.NewRegisteredCounter("arb/txstreamer/writeobserver/dropped", nil)
+
+// SequencerWriteObserver is called with every message written by WriteMessageFromSequencer,
+// after the write succeeded. Each observer runs on its own goroutine, in write order.
+type SequencerWriteObserver func(pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata)
+
+// writeObserver queues the writes for one observer, so a slow observer only delays itself
+type writeObserver struct {
+	observe SequencerWriteObserver
+	queue   chan sequencerWrite
+}
+
+type sequencerWrite struct {
+	pos arbutil.MessageIndex
+	msg arbostypes.MessageWithMetadata
 }
 
 type TransactionStreamerConfig struct {
 	MaxBroadcasterQueueSize int           `koanf:"max-broadcaster-queue-size"`
 	MaxReorgResequenceDepth int64         `koanf:"max-reorg-resequence-depth" reload:"hot"`
 	ExecuteMessageLoopDelay time.Duration `koanf:"execute-message-loop-delay" reload:"hot"`
+	WriteObserverQueueSize  int           `koanf:"write-observer-queue-size"`
+	WriteObserverTimeout    time.Duration `koanf:"write-observer-timeout" reload:"hot"`
+	WriteKeyCacheSize       int           `koanf:"write-key-cache-size"`
+	MessageCeiling          uint64        `koanf:"message-ceiling"`
 }
 
 type TransactionStreamerConfigFetcher func() *TransactionStreamerConfig
@@ -83,18 +119,30 @@ var DefaultTransactionStreamerConfig = TransactionStreamerConfig{
 	MaxBroadcasterQueueSize: 50_000,
 	MaxReorgResequenceDepth: 1024,
 	ExecuteMessageLoopDelay: time.Millisecond * 100,
+	WriteObserverQueueSize:  1024,
+	WriteObserverTimeout:    time.Second,
+	WriteKeyCacheSize:       1024,
+	MessageCeiling:          0,
 }
 
 var TestTransactionStreamerConfig = TransactionStreamerConfig{
 	MaxBroadcasterQueueSize: 10_000,
 	MaxReorgResequenceDepth: 128 * 1024,
 	ExecuteMessageLoopDelay: time.Millisecond,
+	WriteObserverQueueSize:  1024,
+	WriteObserverTimeout:    time.Second,
+	WriteKeyCacheSize:       1024,
+	MessageCeiling:          0,
 }
 
 func TransactionStreamerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-broadcaster-queue-size", DefaultTransactionStreamerConfig.MaxBroadcasterQueueSize, "maximum cache of pending broadcaster messages")
 	f.Int64(prefix+".max-reorg-resequence-depth", DefaultTransactionStreamerConfig.MaxReorgResequenceDepth, "maximum number of messages to attempt to resequence on reorg (0 = never resequence, -1 = always resequence)")
 	f.Duration(prefix+".execute-message-loop-delay", DefaultTransactionStreamerConfig.ExecuteMessageLoopDelay, "delay when polling calls to execute messages")
+	f.Int(prefix+".write-observer-queue-size", DefaultTransactionStreamerConfig.WriteObserverQueueSize, "maximum number of sequencer writes queued for each write observer before further writes are dropped for it")
+	f.Duration(prefix+".write-observer-timeout", DefaultTransactionStreamerConfig.WriteObserverTimeout, "time a sequencer write observer may take for a write, before writes are dropped for it until it returns (0 = unlimited)")
+	f.Int(prefix+".write-key-cache-size", DefaultTransactionStreamerConfig.WriteKeyCacheSize, "number of recent idempotent sequencer writes whose keys are remembered to recognize retries")
+	f.Uint64(prefix+".message-ceiling", DefaultTransactionStreamerConfig.MessageCeiling, "highest message position sequencer writes are accepted for, as a safety stop (0 = unlimited)")
 }
 
 func NewTransactionStreamer(
@@ -106,6 +154,10 @@ func NewTransactionStreamer(
 	config TransactionStreamerConfigFetcher,
 	snapSyncConfig *SnapSyncConfig,
 ) (*TransactionStreamer, error) {
+	writeLatency, err := newWriteLatencyTracker()
+	if err != nil {
+		return nil, err
+	}
 	streamer := &TransactionStreamer{
 		exec:               exec,
 		chainConfig:        chainConfig,
@@ -115,8 +167,12 @@ func NewTransactionStreamer(
 		fatalErrChan:       fatalErrChan,
 		config:             config,
 		snapSyncConfig:     snapSyncConfig,
+
+		sequencerWriteLatency: writeLatency,
+		sequencerDrainer:      newSequencerDrainer(),
+		sequencerWriteKeys:    newSequencerWriteKeys(config().WriteKeyCacheSize),
 	}
-	err := streamer.cleanupInconsistentState()
+	err = streamer.cleanupInconsistentState()
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +218,16 @@ func (s *TransactionStreamer) SetSeqCoordinator(coordinator *SeqCoordinator) {
 	s.coordinator = coordinator
 }
 
+func (s *TransactionStreamer) SetSequencerAuditLog(auditLog *SequencerAuditLog) {
+	if s.Started() {
+		panic("trying to set sequencer audit log after start")
+	}
+	if s.auditLog != nil {
+		panic("trying to set sequencer audit log when already set")
+	}
+	s.auditLog = auditLog
+}
+
 func (s *TransactionStreamer) SetInboxReaders(inboxReader *InboxReader, delayedBridge *DelayedBridge) {
 	if s.Started() {
 		panic("trying to set inbox reader after start")
@@ -278,6 +344,7 @@ func (s *TransactionStreamer) reorg(batch ethdb.Batch, count arbutil.MessageInde
 	if err != nil {
 		return err
 	}
+	prevMsgCount := targetMsgCount
 	config := s.config()
 	maxResequenceMsgCount := count + arbutil.MessageIndex(config.MaxReorgResequenceDepth)
 	if config.MaxReorgResequenceDepth >= 0 && maxResequenceMsgCount < targetMsgCount {
@@ -353,6 +420,8 @@ func (s *TransactionStreamer) reorg(batch ethdb.Batch, count arbutil.MessageInde
 	s.reorgMutex.Lock()
 	defer s.reorgMutex.Unlock()
 
+	s.sequencerWriteKeys.clear()
+
 	messagesResults, err := s.exec.Reorg(count, newMessages, oldMessages)
 	if err != nil {
 		return err
@@ -383,7 +452,13 @@ func (s *TransactionStreamer) reorg(batch ethdb.Batch, count arbutil.MessageInde
 		return err
 	}
 
-	return setMessageCount(batch, count)
+	if err := setMessageCount(batch, count); err != nil {
+		return err
+	}
+	if s.auditLog != nil {
+		s.auditLog.recordReorg(count, prevMsgCount)
+	}
+	return nil
 }
 
 func setMessageCount(batch ethdb.KeyValueWriter, count arbutil.MessageIndex) error {
@@ -471,6 +546,45 @@ func (s *TransactionStreamer) GetMessageCount() (arbutil.MessageIndex, error) {
 	return arbutil.MessageIndex(pos), nil
 }
 
+// findMessageReadingDelayed returns the position of the message that read delayed message
+// delayedSeqNum, which is at or after from. found is false if no message read it yet.
+func (s *TransactionStreamer) findMessageReadingDelayed(delayedSeqNum uint64, from arbutil.MessageIndex) (arbutil.MessageIndex, bool, error) {
+	count, err := s.GetMessageCount()
+	if err != nil {
+		return 0, false, err
+	}
+	if from >= count {
+		return 0, false, nil
+	}
+	var searchErr error
+	offset := sort.Search(int(count-from), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		msg, err := s.GetMessage(from + arbutil.MessageIndex(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return msg.DelayedMessagesRead > delayedSeqNum
+	})
+	if searchErr != nil {
+		return 0, false, searchErr
+	}
+	pos := from + arbutil.MessageIndex(offset)
+	if pos >= count {
+		return 0, false, nil
+	}
+	msg, err := s.GetMessage(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	if msg.DelayedMessagesRead != delayedSeqNum+1 {
+		return 0, false, fmt.Errorf("message %d read %d delayed messages, expected it to read delayed message %d", pos, msg.DelayedMessagesRead, delayedSeqNum)
+	}
+	return pos, true, nil
+}
+
 func (s *TransactionStreamer) GetProcessedMessageCount() (arbutil.MessageIndex, error) {
 	msgCount, err := s.GetMessageCount()
 	if err != nil {
@@ -918,37 +1032,186 @@ func (s *TransactionStreamer) addMessagesAndEndBatchImpl(messageStartPos arbutil
 func (s *TransactionStreamer) ExpectChosenSequencer() error {
 	if s.coordinator != nil {
 		if !s.coordinator.CurrentlyChosen() {
-			return fmt.Errorf("%w: not main sequencer", execution.ErrRetrySequencer)
+			return consensus.NewSequencerNotActiveError("not main sequencer")
 		}
 	}
 	return nil
 }
 
+// MessageCeiling returns the highest position sequencer writes are accepted for, and false if
+// there's no ceiling.
+func (s *TransactionStreamer) MessageCeiling() (arbutil.MessageIndex, bool) {
+	ceiling := s.config().MessageCeiling
+	return arbutil.MessageIndex(ceiling), ceiling != 0
+}
+
+// ComputeMessageL1Fee estimates the message's fee from the latest parent chain header, and fails
+// if the node doesn't read the parent chain.
+func (s *TransactionStreamer) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	return containers.NewReadyPromise(s.computeMessageL1Fee(&msgWithMeta))
+}
+
+func (s *TransactionStreamer) computeMessageL1Fee(msgWithMeta *arbostypes.MessageWithMetadata) (execution.MessageFeeEstimate, error) {
+	if s.inboxReader == nil || s.inboxReader.l1Reader == nil {
+		return execution.MessageFeeEstimate{}, errors.New("no parent chain reader to estimate message fees with")
+	}
+	header, err := s.inboxReader.l1Reader.LastHeaderWithError()
+	if err != nil {
+		return execution.MessageFeeEstimate{}, err
+	}
+	if header == nil || header.BaseFee == nil {
+		return execution.MessageFeeEstimate{}, errors.New("parent chain base fee not known yet")
+	}
+	var l2msg []byte
+	if msgWithMeta.Message != nil {
+		l2msg = msgWithMeta.Message.L2msg
+	}
+	compressed, err := arbcompress.CompressLevel(l2msg, consensus.MessageFeeCompressionLevel)
+	if err != nil {
+		return execution.MessageFeeEstimate{}, err
+	}
+	batchMessages, err := s.latestBatchMessages()
+	if err != nil {
+		return execution.MessageFeeEstimate{}, err
+	}
+	return consensus.NewMessageFeeEstimate(len(l2msg), len(compressed), header.BaseFee, batchMessages), nil
+}
+
+// latestBatchMessages returns how many messages the latest batch has, or zero if there are no batches
+func (s *TransactionStreamer) latestBatchMessages() (uint64, error) {
+	tracker := s.inboxReader.tracker
+	batchCount, err := tracker.GetBatchCount()
+	if err != nil || batchCount == 0 {
+		return 0, err
+	}
+	msgCount, err := tracker.GetBatchMessageCount(batchCount - 1)
+	if err != nil {
+		return 0, err
+	}
+	var prevMsgCount arbutil.MessageIndex
+	if batchCount > 1 {
+		prevMsgCount, err = tracker.GetBatchMessageCount(batchCount - 2)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if msgCount < prevMsgCount {
+		return 0, nil
+	}
+	return uint64(msgCount - prevMsgCount), nil
+}
+
 func (s *TransactionStreamer) WriteMessageFromSequencer(
 	pos arbutil.MessageIndex,
 	msgWithMeta arbostypes.MessageWithMetadata,
 	msgResult execution.MessageResult,
 ) error {
+	_, err := s.writeMessageFromSequencer(pos, msgWithMeta, msgResult, time.Time{}, "")
+	return err
+}
+
+func (s *TransactionStreamer) WriteMessageFromSequencerIdempotent(
+	pos arbutil.MessageIndex,
+	msgWithMeta arbostypes.MessageWithMetadata,
+	msgResult execution.MessageResult,
+	key string,
+) error {
+	if key == "" {
+		return errors.New("idempotent sequencer write requires a key")
+	}
+	_, err := s.writeMessageFromSequencer(pos, msgWithMeta, msgResult, time.Time{}, key)
+	return err
+}
+
+// SequencerWriteBacklog reports at most one pending write, as concurrent writes are rejected
+// with ErrSequencerInsertLockTaken rather than queued.
+func (s *TransactionStreamer) SequencerWriteBacklog() execution.BacklogStatus {
+	return execution.BacklogStatus{
+		PendingWrites: int(s.sequencerWritesInFlight.Load()),
+		PendingBytes:  s.sequencerDrainer.pendingBytes(),
+		WriteLatency:  s.sequencerWriteLatency.estimate(),
+	}
+}
+
+// DrainSequencerQueue waits for at most the one write in flight, as concurrent writes aren't queued.
+func (s *TransactionStreamer) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	return s.sequencerDrainer.drain(ctx)
+}
+
+func (s *TransactionStreamer) WriteMessageFromSequencerWithDeadline(
+	pos arbutil.MessageIndex,
+	msgWithMeta arbostypes.MessageWithMetadata,
+	msgResult execution.MessageResult,
+	deadline time.Time,
+) (time.Time, error) {
+	return s.writeMessageFromSequencer(pos, msgWithMeta, msgResult, deadline, "")
+}
+
+// writeMessageFromSequencer returns the commit time of the message. A zero deadline means no deadline,
+// and an empty key a write that isn't idempotent.
+func (s *TransactionStreamer) writeMessageFromSequencer(
+	pos arbutil.MessageIndex,
+	msgWithMeta arbostypes.MessageWithMetadata,
+	msgResult execution.MessageResult,
+	deadline time.Time,
+	key string,
+) (time.Time, error) {
+	start := time.Now()
 	if err := s.ExpectChosenSequencer(); err != nil {
-		return err
+		return time.Time{}, err
 	}
+	// Checked before anything else is done for the write, so it fails without side effects
+	if ceiling, ok := s.MessageCeiling(); ok && pos > ceiling {
+		return time.Time{}, &consensus.ErrMessageCeilingExceeded{Pos: pos, Ceiling: ceiling}
+	}
+	size := sequencerMessageSize(&msgWithMeta)
+	if err := s.sequencerDrainer.begin(size); err != nil {
+		return time.Time{}, err
+	}
+	committedWrite := false
+	defer func() { s.sequencerDrainer.end(size, committedWrite) }()
 	if !s.insertionMutex.TryLock() {
-		return execution.ErrSequencerInsertLockTaken
+		return time.Time{}, execution.ErrSequencerInsertLockTaken
 	}
 	defer s.insertionMutex.Unlock()
+	s.sequencerWritesInFlight.Add(1)
+	defer s.sequencerWritesInFlight.Add(-1)
+
+	var msgHash common.Hash
+	if key != "" || s.auditLog != nil {
+		var err error
+		msgHash, err = msgWithMeta.Hash(pos, s.chainConfig.ChainID.Uint64())
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if key != "" {
+		written, err := s.sequencerWriteKeys.check(key, pos, msgHash)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if written {
+			return time.Now(), nil
+		}
+	}
 
 	msgCount, err := s.GetMessageCount()
 	if err != nil {
-		return err
+		return time.Time{}, err
 	}
 
 	if msgCount != pos {
-		return fmt.Errorf("wrong pos got %d expected %d", pos, msgCount)
+		return time.Time{}, consensus.NewWrongPosError(pos, msgCount)
+	}
+
+	// Checked last, as the coordinator is the first to record the message
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return time.Time{}, &execution.ErrCommitDeadlineExceeded{Pos: pos, Deadline: deadline}
 	}
 
 	if s.coordinator != nil {
 		if err := s.coordinator.SequencingMessage(pos, &msgWithMeta); err != nil {
-			return err
+			return time.Time{}, err
 		}
 	}
 
@@ -958,11 +1221,118 @@ func (s *TransactionStreamer) WriteMessageFromSequencer(
 	}
 
 	if err := s.writeMessages(pos, []arbostypes.MessageWithMetadataAndBlockHash{msgWithBlockHash}, nil); err != nil {
-		return err
+		return time.Time{}, err
 	}
+	if key != "" {
+		s.sequencerWriteKeys.add(key, pos, msgHash)
+	}
+	if s.auditLog != nil {
+		s.auditLog.recordWrite(pos, msgHash, msgWithMeta.DelayedMessagesRead)
+	}
+	committed := time.Now()
+	committedWrite = true
+	s.sequencerWriteLatency.update(committed.Sub(start))
 	s.broadcastMessages([]arbostypes.MessageWithMetadataAndBlockHash{msgWithBlockHash}, pos)
+	s.queueSequencerWrite(pos, msgWithMeta)
 
-	return nil
+	return committed, nil
+}
+
+// RegisterWriteObserver adds an observer called after every successful WriteMessageFromSequencer.
+// Observers can't block or fail writes, nor delay each other: writes are dropped for an observer
+// that falls behind by more than the configured queue size, and for as long as it overruns the
+// configured timeout. Dropped writes are counted in the arb/txstreamer/writeobserver/dropped metric.
+func (s *TransactionStreamer) RegisterWriteObserver(observe SequencerWriteObserver) {
+	observer := &writeObserver{
+		observe: observe,
+		queue:   make(chan sequencerWrite, s.config().WriteObserverQueueSize),
+	}
+	s.writeObserversMutex.Lock()
+	defer s.writeObserversMutex.Unlock()
+	s.writeObservers = append(s.writeObservers, observer)
+	if s.writeObserversStarted {
+		s.LaunchThread(func(ctx context.Context) { s.runWriteObserver(ctx, observer) })
+	}
+}
+
+func (s *TransactionStreamer) startWriteObservers() {
+	s.writeObserversMutex.Lock()
+	defer s.writeObserversMutex.Unlock()
+	s.writeObserversStarted = true
+	for _, observer := range s.writeObservers {
+		observer := observer
+		s.LaunchThread(func(ctx context.Context) { s.runWriteObserver(ctx, observer) })
+	}
+}
+
+func (s *TransactionStreamer) queueSequencerWrite(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata) {
+	s.writeObserversMutex.Lock()
+	observers := s.writeObservers
+	s.writeObserversMutex.Unlock()
+	write := sequencerWrite{pos: pos, msg: msgWithMeta}
+	for _, observer := range observers {
+		select {
+		case observer.queue <- write:
+		default:
+			writeObserverDroppedCounter.Inc(1)
+			log.Warn("sequencer write observer queue full, dropping write", "pos", pos)
+		}
+	}
+}
+
+// runWriteObserver calls the observer with its queued writes until ctx is done. If a call overruns
+// the timeout, the writes queued until it returns are dropped, so the observer resumes with new writes.
+func (s *TransactionStreamer) runWriteObserver(ctx context.Context, observer *writeObserver) {
+	for {
+		var write sequencerWrite
+		select {
+		case <-ctx.Done():
+			return
+		case write = <-observer.queue:
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			callWriteObserver(observer.observe, write)
+		}()
+		var timer *time.Timer
+		var timeoutChan <-chan time.Time
+		if timeout := s.config().WriteObserverTimeout; timeout > 0 {
+			timer = time.NewTimer(timeout)
+			timeoutChan = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		case <-timeoutChan:
+		}
+		log.Warn("sequencer write observer overran its timeout, dropping writes until it returns", "pos", write.pos, "timeout", s.config().WriteObserverTimeout)
+		for stuck := true; stuck; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				stuck = false
+			case dropped := <-observer.queue:
+				writeObserverDroppedCounter.Inc(1)
+				log.Debug("dropping write for sequencer write observer past its timeout", "pos", dropped.pos)
+			}
+		}
+	}
+}
+
+func callWriteObserver(observe SequencerWriteObserver, write sequencerWrite) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("sequencer write observer panicked", "pos", write.pos, "err", r)
+		}
+	}()
+	observe(write.pos, write.msg)
 }
 
 // PauseReorgs until a matching call to ResumeReorgs (may be called concurrently)
@@ -1138,5 +1508,6 @@ func (s *TransactionStreamer) executeMessages(ctx context.Context, ignored struc
 
 func (s *TransactionStreamer) Start(ctxIn context.Context) error {
 	s.StopWaiter.Start(ctxIn, s)
+	s.startWriteObservers()
 	return stopwaiter.CallIterativelyWith[struct{}](&s.StopWaiterSafe, s.executeMessages, s.newMessageNotifier)
 }
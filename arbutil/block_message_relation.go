@@ -3,6 +3,11 @@
 
 package arbutil
 
+import (
+	"fmt"
+	"math"
+)
+
 type MessageIndex uint64
 
 func BlockNumberToMessageCount(blockNumber uint64, genesisBlockNumber uint64) MessageIndex {
@@ -17,3 +22,29 @@ func SignedBlockNumberToMessageCount(blockNumber int64, genesisBlockNumber uint6
 func MessageCountToBlockNumber(messageCount MessageIndex, genesisBlockNumber uint64) int64 {
 	return int64(uint64(messageCount)+genesisBlockNumber) - 1
 }
+
+// ErrBlockBeforeGenesis is returned when mapping a block number below the genesis block to a message index
+type ErrBlockBeforeGenesis struct {
+	Block   uint64
+	Genesis uint64
+}
+
+func (e *ErrBlockBeforeGenesis) Error() string {
+	return fmt.Sprintf("block %d is before genesis block %d", e.Block, e.Genesis)
+}
+
+// MessageIndexToBlockNumber returns the number of the block produced by the message at pos
+func MessageIndexToBlockNumber(pos MessageIndex, genesisBlockNumber uint64) (uint64, error) {
+	if uint64(pos) > math.MaxUint64-genesisBlockNumber {
+		return 0, fmt.Errorf("%w: message %d with genesis block %d", ErrMsgIndexOverflow, pos, genesisBlockNumber)
+	}
+	return uint64(pos) + genesisBlockNumber, nil
+}
+
+// BlockNumberToMessageIndex returns the index of the message that produced the block
+func BlockNumberToMessageIndex(blockNumber uint64, genesisBlockNumber uint64) (MessageIndex, error) {
+	if blockNumber < genesisBlockNumber {
+		return 0, &ErrBlockBeforeGenesis{Block: blockNumber, Genesis: genesisBlockNumber}
+	}
+	return MessageIndex(blockNumber - genesisBlockNumber), nil
+}
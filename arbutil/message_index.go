@@ -0,0 +1,60 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrMsgIndexOverflow = errors.New("message index overflow")
+var ErrMsgIndexUnderflow = errors.New("message index underflow")
+
+// MaxRangeSize is the largest number of indices MsgIndexRange returns.
+var MaxRangeSize uint64 = 1 << 20
+
+func MsgIndexAdd(a, b MessageIndex) (MessageIndex, error) {
+	if a > math.MaxUint64-b {
+		return 0, fmt.Errorf("%w: %d + %d", ErrMsgIndexOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+func MsgIndexSub(a, b MessageIndex) (MessageIndex, error) {
+	if a < b {
+		return 0, fmt.Errorf("%w: %d - %d", ErrMsgIndexUnderflow, a, b)
+	}
+	return a - b, nil
+}
+
+// MsgIndexDiff returns a - b, saturated to the range of an int64.
+func MsgIndexDiff(a, b MessageIndex) int64 {
+	if a >= b {
+		if a-b > math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return int64(a - b)
+	}
+	if b-a > math.MaxInt64 {
+		return math.MinInt64
+	}
+	return -int64(b - a)
+}
+
+// MsgIndexRange returns the indices from start up to but not including end.
+// It fails if there are more than MaxRangeSize of them.
+func MsgIndexRange(start, end MessageIndex) ([]MessageIndex, error) {
+	if end < start {
+		return nil, fmt.Errorf("invalid message index range %d to %d", start, end)
+	}
+	if uint64(end-start) > MaxRangeSize {
+		return nil, fmt.Errorf("message index range %d to %d exceeds max range size %d", start, end, MaxRangeSize)
+	}
+	indices := make([]MessageIndex, 0, end-start)
+	for i := start; i < end; i++ {
+		indices = append(indices, i)
+	}
+	return indices, nil
+}
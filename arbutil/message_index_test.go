@@ -0,0 +1,95 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbutil
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMsgIndexArithmetic(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		a, b    MessageIndex
+		sum     MessageIndex
+		sumErr  error
+		diff    MessageIndex
+		diffErr error
+		signed  int64
+	}{
+		{desc: "simple case", a: 5, b: 3, sum: 8, diff: 2, signed: 2},
+		{desc: "underflow", a: 3, b: 5, sum: 8, diffErr: ErrMsgIndexUnderflow, signed: -2},
+		{desc: "overflow", a: math.MaxUint64, b: 1, sumErr: ErrMsgIndexOverflow, diff: math.MaxUint64 - 1, signed: math.MaxInt64},
+		{desc: "saturated negative", a: 0, b: math.MaxUint64, sum: math.MaxUint64, diffErr: ErrMsgIndexUnderflow, signed: math.MinInt64},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			sum, err := MsgIndexAdd(tc.a, tc.b)
+			if !errors.Is(err, tc.sumErr) || (err == nil && sum != tc.sum) {
+				t.Errorf("MsgIndexAdd(%d, %d) = %d, %v want %d, %v", tc.a, tc.b, sum, err, tc.sum, tc.sumErr)
+			}
+			diff, err := MsgIndexSub(tc.a, tc.b)
+			if !errors.Is(err, tc.diffErr) || (err == nil && diff != tc.diff) {
+				t.Errorf("MsgIndexSub(%d, %d) = %d, %v want %d, %v", tc.a, tc.b, diff, err, tc.diff, tc.diffErr)
+			}
+			if signed := MsgIndexDiff(tc.a, tc.b); signed != tc.signed {
+				t.Errorf("MsgIndexDiff(%d, %d) = %d want %d", tc.a, tc.b, signed, tc.signed)
+			}
+		})
+	}
+}
+
+func TestMsgIndexRange(t *testing.T) {
+	indices, err := MsgIndexRange(3, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indices) != 3 || indices[0] != 3 || indices[2] != 5 {
+		t.Errorf("MsgIndexRange(3, 6) = %v", indices)
+	}
+	if _, err := MsgIndexRange(6, 3); err == nil {
+		t.Error("MsgIndexRange accepted a reversed range")
+	}
+	if _, err := MsgIndexRange(0, MessageIndex(MaxRangeSize)+1); err == nil {
+		t.Error("MsgIndexRange accepted a range above MaxRangeSize")
+	}
+}
+
+func TestBlockNumberMapping(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		pos      MessageIndex
+		genesis  uint64
+		block    uint64
+		overflow bool
+	}{
+		{desc: "zero genesis", pos: 7, genesis: 0, block: 7},
+		{desc: "nonzero genesis", pos: 7, genesis: 22207817, block: 22207824},
+		{desc: "genesis message", pos: 0, genesis: 100, block: 100},
+		{desc: "overflow", pos: math.MaxUint64, genesis: 1, overflow: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			block, err := MessageIndexToBlockNumber(tc.pos, tc.genesis)
+			if tc.overflow {
+				if !errors.Is(err, ErrMsgIndexOverflow) {
+					t.Errorf("MessageIndexToBlockNumber(%d, %d) = %d, %v want overflow", tc.pos, tc.genesis, block, err)
+				}
+				return
+			}
+			if err != nil || block != tc.block {
+				t.Errorf("MessageIndexToBlockNumber(%d, %d) = %d, %v want %d", tc.pos, tc.genesis, block, err, tc.block)
+			}
+			pos, err := BlockNumberToMessageIndex(tc.block, tc.genesis)
+			if err != nil || pos != tc.pos {
+				t.Errorf("BlockNumberToMessageIndex(%d, %d) = %d, %v want %d", tc.block, tc.genesis, pos, err, tc.pos)
+			}
+		})
+	}
+
+	_, err := BlockNumberToMessageIndex(99, 100)
+	var beforeGenesis *ErrBlockBeforeGenesis
+	if !errors.As(err, &beforeGenesis) || beforeGenesis.Block != 99 || beforeGenesis.Genesis != 100 {
+		t.Errorf("BlockNumberToMessageIndex(99, 100) = %v want ErrBlockBeforeGenesis", err)
+	}
+}
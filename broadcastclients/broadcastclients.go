@@ -119,6 +119,11 @@ func (bcs *BroadcastClients) adjustCount(delta int32) {
 	}
 }
 
+// Connected returns the number of currently connected feed clients
+func (bcs *BroadcastClients) Connected() int32 {
+	return bcs.connected.Load()
+}
+
 // Clears out a ticker's channel and resets it to the interval
 func clearAndResetTicker(timer *time.Ticker, interval time.Duration) {
 	timer.Stop()
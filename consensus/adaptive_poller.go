@@ -0,0 +1,160 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type AdaptivePollerConfig struct {
+	MinInterval time.Duration `koanf:"min-interval"`
+	MaxInterval time.Duration `koanf:"max-interval"`
+}
+
+var DefaultAdaptivePollerConfig = AdaptivePollerConfig{
+	MinInterval: 100 * time.Millisecond,
+	MaxInterval: 30 * time.Second,
+}
+
+var TestAdaptivePollerConfig = AdaptivePollerConfig{
+	MinInterval: time.Millisecond,
+	MaxInterval: 20 * time.Millisecond,
+}
+
+func AdaptivePollerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".min-interval", DefaultAdaptivePollerConfig.MinInterval, "shortest interval to poll for new batches at, used while batches arrive frequently")
+	f.Duration(prefix+".max-interval", DefaultAdaptivePollerConfig.MaxInterval, "longest interval to poll for new batches at, used while no batches arrive")
+}
+
+func (c *AdaptivePollerConfig) Validate() error {
+	if c.MinInterval <= 0 {
+		return errors.New("adaptive poller min-interval must be positive")
+	}
+	if c.MaxInterval < c.MinInterval {
+		return errors.New("adaptive poller max-interval must be at least min-interval")
+	}
+	return nil
+}
+
+// AdaptivePoller polls a BatchFetcher's batch count, and sends the number of every new batch on
+// Batches, starting with the batches posted after the first poll. The poll interval follows half
+// the average time between recent batches, and doubles on every poll that finds none, within
+// MinInterval and MaxInterval. When the batch count goes backwards in a reorg, the batches posted
+// again are sent again.
+type AdaptivePoller struct {
+	stopwaiter.StopWaiter
+	config  *AdaptivePollerConfig
+	fetcher execution.BatchFetcher
+	batches chan uint64
+
+	mutex       sync.Mutex
+	started     bool
+	count       uint64
+	lastArrival time.Time
+	// average time between batches, zero until two polls found batches
+	avgGap   time.Duration
+	interval time.Duration
+}
+
+func NewAdaptivePoller(bf execution.BatchFetcher, config *AdaptivePollerConfig) (*AdaptivePoller, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &AdaptivePoller{
+		config:   config,
+		fetcher:  bf,
+		batches:  make(chan uint64),
+		interval: config.MinInterval,
+	}, nil
+}
+
+func (p *AdaptivePoller) Start(ctxIn context.Context) {
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(func(ctx context.Context) time.Duration {
+		count, err := p.fetcher.GetBatchCount().Await(ctx)
+		if err != nil {
+			log.Warn("adaptive poller failed to get batch count", "err", err)
+			return p.observeFailure()
+		}
+		first, interval := p.observe(count, time.Now())
+		for batchNum := first; batchNum < count; batchNum++ {
+			select {
+			case p.batches <- batchNum:
+			case <-ctx.Done():
+				return 0
+			}
+		}
+		return interval
+	})
+}
+
+// Batches returns the channel new batch numbers are sent on. Sends block until they're received,
+// delaying further polls.
+func (p *AdaptivePoller) Batches() <-chan uint64 {
+	return p.batches
+}
+
+// Interval returns the interval until the next poll.
+func (p *AdaptivePoller) Interval() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.interval
+}
+
+func (p *AdaptivePoller) clamp(interval time.Duration) time.Duration {
+	if interval < p.config.MinInterval {
+		return p.config.MinInterval
+	}
+	if interval > p.config.MaxInterval {
+		return p.config.MaxInterval
+	}
+	return interval
+}
+
+// observe records that the batch count was count at now, and returns the first new batch to send
+// (count if there are none) and the interval until the next poll.
+func (p *AdaptivePoller) observe(count uint64, now time.Time) (uint64, time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.started {
+		p.started = true
+		p.count = count
+		p.lastArrival = now
+		return count, p.interval
+	}
+	first := p.count
+	if count < p.count {
+		first = count
+	}
+	p.count = count
+	if count <= first {
+		p.interval = p.clamp(p.interval * 2)
+		return count, p.interval
+	}
+	gap := now.Sub(p.lastArrival) / time.Duration(count-first)
+	p.lastArrival = now
+	if p.avgGap == 0 {
+		p.avgGap = gap
+	} else {
+		p.avgGap = (3*p.avgGap + gap) / 4
+	}
+	p.interval = p.clamp(p.avgGap / 2)
+	return first, p.interval
+}
+
+func (p *AdaptivePoller) observeFailure() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.interval = p.clamp(p.interval * 2)
+	return p.interval
+}
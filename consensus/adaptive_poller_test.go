@@ -0,0 +1,101 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type countingBatchFetcher struct {
+	// calls to methods other than GetBatchCount panic
+	execution.BatchFetcher
+	count atomic.Uint64
+}
+
+func (f *countingBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	return containers.NewReadyPromise(f.count.Load(), nil)
+}
+
+func TestAdaptivePollerInterval(t *testing.T) {
+	config := AdaptivePollerConfig{MinInterval: time.Second, MaxInterval: time.Minute}
+	poller, err := NewAdaptivePoller(&countingBatchFetcher{}, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if first, interval := poller.observe(10, now); first != 10 || interval != time.Second {
+		t.Fatal("unexpected first poll", first, interval)
+	}
+
+	// Idle polls relax the interval up to the max
+	for i := 0; i < 10; i++ {
+		now = now.Add(poller.Interval())
+		poller.observe(10, now)
+	}
+	if poller.Interval() != time.Minute {
+		t.Fatal("interval didn't relax to the max while idle", poller.Interval())
+	}
+
+	// Frequent batches tighten it to half the time between batches
+	for i := uint64(1); i <= 20; i++ {
+		now = now.Add(10 * time.Second)
+		first, _ := poller.observe(10+i, now)
+		if first != 10+i-1 {
+			t.Fatal("unexpected first new batch", first)
+		}
+	}
+	if interval := poller.Interval(); interval < 5*time.Second || interval > 7*time.Second {
+		t.Fatal("interval didn't tighten to half the time between batches", interval)
+	}
+	for i := uint64(1); i <= 40; i++ {
+		now = now.Add(100 * time.Millisecond)
+		poller.observe(30+i, now)
+	}
+	if poller.Interval() != time.Second {
+		t.Fatal("interval not capped at the min", poller.Interval())
+	}
+
+	// Batches posted again after a reorg are sent again
+	now = now.Add(time.Second)
+	if first, _ := poller.observe(50, now); first != 50 {
+		t.Fatal("unexpected first new batch after the batch count went backwards", first)
+	}
+	now = now.Add(time.Second)
+	if first, _ := poller.observe(52, now); first != 50 {
+		t.Fatal("batches posted after the reorg not sent again", first)
+	}
+}
+
+func TestAdaptivePollerBatches(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	fetcher := &countingBatchFetcher{}
+	fetcher.count.Store(3)
+	poller, err := NewAdaptivePoller(fetcher, &TestAdaptivePollerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poller.Start(ctx)
+	defer poller.StopAndWait()
+
+	// Give the first poll a chance to find the existing batches, which aren't sent
+	time.Sleep(10 * TestAdaptivePollerConfig.MaxInterval)
+	fetcher.count.Store(6)
+	for expected := uint64(3); expected < 6; expected++ {
+		select {
+		case batchNum := <-poller.Batches():
+			if batchNum != expected {
+				t.Fatal("got batch", batchNum, "expected", expected)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for batch", expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// FindBatchesContainingKind implements FindBatchesContainingKind with the message range of each
+// batch and the stored messages, rather than by fetching and decoding the batches. Messages are
+// read one at a time, and each batch's scan stops at its first message of kind, so memory use
+// doesn't depend on batch sizes.
+func FindBatchesContainingKind(
+	ctx context.Context,
+	first, last uint64,
+	kind uint8,
+	getRange func(seqNum uint64) (execution.MessageRange, error),
+	getMessage func(pos arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error),
+) ([]uint64, error) {
+	if first > last || last-first >= execution.MaxFindBatchesContainingKindRange {
+		return nil, fmt.Errorf("invalid batch range %d to %d, at most %d batches are allowed", first, last, execution.MaxFindBatchesContainingKindRange)
+	}
+	batches := []uint64{}
+	for seqNum := first; ; seqNum++ {
+		msgRange, err := getRange(seqNum)
+		if err != nil {
+			return nil, fmt.Errorf("batch %d: %w", seqNum, err)
+		}
+		for pos := msgRange.Start; pos < msgRange.End; pos++ {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			msg, err := getMessage(pos)
+			if err != nil {
+				return nil, fmt.Errorf("message %d of batch %d: %w", pos, seqNum, err)
+			}
+			if msg.Message != nil && msg.Message.Header != nil && msg.Message.Header.Kind == kind {
+				batches = append(batches, seqNum)
+				break
+			}
+		}
+		if seqNum == last {
+			// Avoids overflowing when last is the highest batch number
+			break
+		}
+	}
+	return batches, nil
+}
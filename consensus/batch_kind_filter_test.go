@@ -0,0 +1,107 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// kindBatches serves batches of messages with the given kinds, counting the messages read
+type kindBatches struct {
+	kinds [][]uint8
+	reads int
+}
+
+func (b *kindBatches) getRange(seqNum uint64) (execution.MessageRange, error) {
+	if seqNum >= uint64(len(b.kinds)) {
+		return execution.MessageRange{}, &execution.ErrBatchNotYetPosted{BatchNum: seqNum}
+	}
+	var start arbutil.MessageIndex
+	for _, kinds := range b.kinds[:seqNum] {
+		start += arbutil.MessageIndex(len(kinds))
+	}
+	return execution.MessageRange{Start: start, End: start + arbutil.MessageIndex(len(b.kinds[seqNum]))}, nil
+}
+
+func (b *kindBatches) getMessage(pos arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error) {
+	b.reads++
+	for _, kinds := range b.kinds {
+		if pos < arbutil.MessageIndex(len(kinds)) {
+			return &arbostypes.MessageWithMetadata{
+				Message: &arbostypes.L1IncomingMessage{Header: &arbostypes.L1IncomingMessageHeader{Kind: kinds[pos]}},
+			}, nil
+		}
+		pos -= arbutil.MessageIndex(len(kinds))
+	}
+	return nil, errors.New("message not found")
+}
+
+func TestFindBatchesContainingKind(t *testing.T) {
+	ctx := context.Background()
+	deposit := uint8(arbostypes.L1MessageType_EthDeposit)
+	l2 := uint8(arbostypes.L1MessageType_L2Message)
+	batches := &kindBatches{kinds: [][]uint8{
+		{l2, l2},
+		{deposit, deposit, l2},
+		{},
+		{l2, deposit},
+	}}
+
+	found, err := consensus.FindBatchesContainingKind(ctx, 0, 3, deposit, batches.getRange, batches.getMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found, []uint64{1, 3}) {
+		t.Fatal("unexpected batches containing deposits", found)
+	}
+	// Each batch's scan stops at its first matching message
+	if batches.reads != 5 {
+		t.Fatal("unexpected number of messages read", batches.reads)
+	}
+
+	found, err = consensus.FindBatchesContainingKind(ctx, 2, 2, deposit, batches.getRange, batches.getMessage)
+	if err != nil || found == nil || len(found) != 0 {
+		t.Fatal("expected no batches from an empty batch", found, err)
+	}
+
+	_, err = consensus.FindBatchesContainingKind(ctx, 3, 4, deposit, batches.getRange, batches.getMessage)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) || notYetPostedErr.BatchNum != 4 {
+		t.Fatal("expected batch not yet posted beyond the last batch, got", err)
+	}
+}
+
+func TestFindBatchesContainingKindErrors(t *testing.T) {
+	batches := &kindBatches{kinds: [][]uint8{{arbostypes.L1MessageType_L2Message}}}
+	for _, bounds := range [][2]uint64{{1, 0}, {0, execution.MaxFindBatchesContainingKindRange}} {
+		if _, err := consensus.FindBatchesContainingKind(context.Background(), bounds[0], bounds[1], 0, batches.getRange, batches.getMessage); err == nil {
+			t.Fatal("expected an error for the batch range", bounds)
+		}
+	}
+
+	// The highest batch number doesn't overflow the scan
+	_, err := consensus.FindBatchesContainingKind(context.Background(), math.MaxUint64, math.MaxUint64, 0, batches.getRange, batches.getMessage)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) || notYetPostedErr.BatchNum != math.MaxUint64 {
+		t.Fatal("expected batch not yet posted for the highest batch number, got", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := consensus.FindBatchesContainingKind(ctx, 0, 0, 0, batches.getRange, batches.getMessage); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the scan to stop once cancelled, got", err)
+	}
+	if batches.reads != 0 {
+		t.Fatal("messages read after cancellation", batches.reads)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// checkBatchRange fails unless first through last is a range of at most
+// MaxBatchParentChainBlocksRange batches
+func checkBatchRange(first, last uint64) error {
+	if first > last || last-first >= execution.MaxBatchParentChainBlocksRange {
+		return fmt.Errorf("invalid batch range %d to %d, at most %d batches are allowed", first, last, execution.MaxBatchParentChainBlocksRange)
+	}
+	return nil
+}
+
+// CollectBatchParentChainBlocks implements GetBatchParentChainBlocks with a single batch lookup,
+// such as GetBatchParentChainBlock. Batches it fails for with an error matched by
+// execution.IsBatchUnavailable are reported as not found, and any other error fails the range.
+func CollectBatchParentChainBlocks(first, last uint64, getBlock func(seqNum uint64) (uint64, error)) (execution.BatchParentChainBlocks, error) {
+	if err := checkBatchRange(first, last); err != nil {
+		return execution.BatchParentChainBlocks{}, err
+	}
+	blocks := execution.BatchParentChainBlocks{
+		First:  first,
+		Blocks: make([]uint64, 0, last-first+1),
+		Found:  make([]bool, 0, last-first+1),
+	}
+	for seqNum := first; ; seqNum++ {
+		block, err := getBlock(seqNum)
+		if err != nil && !execution.IsBatchUnavailable(err) {
+			return execution.BatchParentChainBlocks{}, fmt.Errorf("batch %d: %w", seqNum, err)
+		}
+		if err != nil {
+			block = 0
+		}
+		blocks.Blocks = append(blocks.Blocks, block)
+		blocks.Found = append(blocks.Found, err == nil)
+		if seqNum == last {
+			// Avoids overflowing when last is the highest batch number
+			break
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// BatchFetchErrors is the error of a FetchBatchesBounded call collecting errors.
+// Fetched holds the batches that were fetched, and Errors the error of each one that wasn't.
+type BatchFetchErrors struct {
+	Fetched map[uint64][]byte
+	Errors  map[uint64]error
+}
+
+func (e *BatchFetchErrors) failedBatches() []uint64 {
+	batches := make([]uint64, 0, len(e.Errors))
+	for batchNum := range e.Errors {
+		batches = append(batches, batchNum)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i] < batches[j] })
+	return batches
+}
+
+func (e *BatchFetchErrors) Error() string {
+	failed := e.failedBatches()
+	if len(failed) == 0 {
+		return "no batch fetches failed"
+	}
+	return fmt.Sprintf("fetching %d batches failed, first batch %d: %v", len(failed), failed[0], e.Errors[failed[0]])
+}
+
+func (e *BatchFetchErrors) Unwrap() []error {
+	failed := e.failedBatches()
+	errs := make([]error, 0, len(failed))
+	for _, batchNum := range failed {
+		errs = append(errs, e.Errors[batchNum])
+	}
+	return errs
+}
+
+// FetchBatchesBounded fetches the batches nums with fetcher, never having more than maxConcurrent
+// fetches outstanding, and resolves with the data of each batch.
+// The first failed fetch cancels the remaining ones and becomes the promise's error, unless
+// collectErrors is set, in which case every batch is attempted and the promise fails with
+// a *BatchFetchErrors if any of them failed.
+// Cancelling the promise, or ctx, cancels the outstanding fetches.
+func FetchBatchesBounded(ctx context.Context, fetcher execution.BatchFetcher, nums []uint64, maxConcurrent int, collectErrors bool) containers.PromiseInterface[map[uint64][]byte] {
+	if maxConcurrent <= 0 {
+		return containers.NewReadyPromise[map[uint64][]byte](nil, fmt.Errorf("max concurrent batch fetches must be positive, got %d", maxConcurrent))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	promise := containers.NewPromise[map[uint64][]byte](cancel)
+	go func() {
+		defer cancel()
+		var mutex sync.Mutex
+		fetched := make(map[uint64][]byte, len(nums))
+		failed := make(map[uint64]error)
+		var firstErr error
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, maxConcurrent)
+		seen := make(map[uint64]bool, len(nums))
+	launch:
+		for _, batchNum := range nums {
+			if seen[batchNum] {
+				continue
+			}
+			seen[batchNum] = true
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				break launch
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func(batchNum uint64) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				batch, err := fetcher.FetchBatch(ctx, batchNum).Await(ctx)
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err != nil {
+					failed[batchNum] = err
+					if firstErr == nil {
+						firstErr = fmt.Errorf("fetching batch %d: %w", batchNum, err)
+					}
+					if !collectErrors {
+						cancel()
+					}
+					return
+				}
+				fetched[batchNum] = batch.Data
+			}(batchNum)
+		}
+		wg.Wait()
+		if !collectErrors && firstErr != nil {
+			promise.ProduceError(firstErr)
+		} else if ctx.Err() != nil {
+			promise.ProduceError(ctx.Err())
+		} else if len(failed) > 0 {
+			promise.ProduceError(&BatchFetchErrors{Fetched: fetched, Errors: failed})
+		} else {
+			promise.Produce(fetched)
+		}
+	}()
+	return &promise
+}
@@ -0,0 +1,105 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// inFlightCountingFetcher records the most FetchBatch calls that were outstanding at once
+type inFlightCountingFetcher struct {
+	execution.BatchFetcher
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (f *inFlightCountingFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	current := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		highest := f.maxInFlight.Load()
+		if current <= highest || f.maxInFlight.CompareAndSwap(highest, current) {
+			break
+		}
+	}
+	batch, err := f.BatchFetcher.FetchBatch(ctx, batchNum).Await(ctx)
+	return containers.NewReadyPromise(batch, err)
+}
+
+func TestFetchBatchesBounded(t *testing.T) {
+	ctx := context.Background()
+	fake := consensustest.NewFakeConsensusClient()
+	var nums []uint64
+	for i := 0; i < 20; i++ {
+		err := fake.AddBatches(consensustest.FakeBatch{Data: []byte(fmt.Sprint("batch", i)), MessageCount: arbutil.MessageIndex(i + 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nums = append(nums, uint64(i))
+	}
+	fake.SetLatency(10 * time.Millisecond)
+	fetcher := &inFlightCountingFetcher{BatchFetcher: fake}
+
+	// Every batch is fetched, duplicates once, with at most maxConcurrent fetches at a time
+	batches, err := consensus.FetchBatchesBounded(ctx, fetcher, append(nums, 3, 3), 3, false).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != len(nums) {
+		t.Fatal("unexpected number of batches", len(batches))
+	}
+	for _, batchNum := range nums {
+		if string(batches[batchNum]) != fmt.Sprint("batch", batchNum) {
+			t.Fatal("unexpected batch data", batchNum, string(batches[batchNum]))
+		}
+	}
+	if highest := fetcher.maxInFlight.Load(); highest > 3 || highest < 2 {
+		t.Fatal("unexpected max fetches in flight", highest)
+	}
+
+	// By default the first failure fails the whole fetch
+	_, err = consensus.FetchBatchesBounded(ctx, fetcher, append(nums, 50), 4, false).Await(ctx)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) {
+		t.Fatal("expected batch not yet posted, got", err)
+	}
+
+	// Collecting errors attempts every batch
+	_, err = consensus.FetchBatchesBounded(ctx, fetcher, append([]uint64{50, 51}, nums...), 4, true).Await(ctx)
+	var fetchErrs *consensus.BatchFetchErrors
+	if !errors.As(err, &fetchErrs) {
+		t.Fatal("expected batch fetch errors, got", err)
+	}
+	if len(fetchErrs.Errors) != 2 || len(fetchErrs.Fetched) != len(nums) || !errors.As(err, &notYetPostedErr) {
+		t.Fatal("unexpected batch fetch errors", fetchErrs)
+	}
+
+	// Cancelling the promise stops the fetch
+	fake.SetLatency(time.Hour)
+	promise := consensus.FetchBatchesBounded(ctx, fetcher, nums, 2, false)
+	promise.Cancel()
+	select {
+	case <-promise.ReadyChan():
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled fetch didn't resolve")
+	}
+	if _, err := promise.Current(); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected cancelled fetch, got", err)
+	}
+
+	if _, err := consensus.FetchBatchesBounded(ctx, fetcher, nums, 0, false).Await(ctx); err == nil {
+		t.Fatal("fetch with no concurrency succeeded")
+	}
+}
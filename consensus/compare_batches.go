@@ -0,0 +1,118 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/offchainlabs/nitro/execution"
+)
+
+const compareBatchesConcurrency = 8
+
+// BatchDiff is a batch two fetchers disagree on. A side that hasn't posted the batch yet
+// has its Missing flag set, and no data or parent chain block.
+type BatchDiff struct {
+	BatchNum          uint64
+	DataA             []byte
+	DataB             []byte
+	ParentChainBlockA uint64
+	ParentChainBlockB uint64
+	MissingA          bool
+	MissingB          bool
+}
+
+func (d *BatchDiff) DataDiffers() bool {
+	return d.MissingA != d.MissingB || !bytes.Equal(d.DataA, d.DataB)
+}
+
+func (d *BatchDiff) ParentChainBlockDiffers() bool {
+	return d.MissingA != d.MissingB || d.ParentChainBlockA != d.ParentChainBlockB
+}
+
+type comparedBatch struct {
+	data             []byte
+	parentChainBlock uint64
+	missing          bool
+}
+
+func fetchComparedBatch(ctx context.Context, fetcher execution.BatchFetcher, batchNum uint64) (comparedBatch, error) {
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	batch, err := fetcher.FetchBatch(ctx, batchNum).Await(ctx)
+	if errors.As(err, &notYetPostedErr) {
+		return comparedBatch{missing: true}, nil
+	}
+	if err != nil {
+		return comparedBatch{}, err
+	}
+	parentChainBlock, err := fetcher.GetBatchParentChainBlock(batchNum).Await(ctx)
+	if err != nil {
+		return comparedBatch{}, err
+	}
+	return comparedBatch{data: batch.Data, parentChainBlock: parentChainBlock}, nil
+}
+
+// CompareBatches fetches the batches first through last from a and b, and returns the batches
+// whose data or parent chain block differ, ordered by batch number. A batch posted to only one
+// side is a difference; any other fetch failure fails the comparison.
+// At most compareBatchesConcurrency batches are fetched at a time.
+func CompareBatches(ctx context.Context, a, b execution.BatchFetcher, first, last uint64) ([]BatchDiff, error) {
+	if first > last {
+		return nil, fmt.Errorf("invalid batch range %d to %d", first, last)
+	}
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(compareBatchesConcurrency)
+	var mutex sync.Mutex
+	var diffs []BatchDiff
+	for batchNum := first; ; batchNum++ {
+		if groupCtx.Err() != nil {
+			break
+		}
+		batchNum := batchNum
+		group.Go(func() error {
+			batchA, err := fetchComparedBatch(groupCtx, a, batchNum)
+			if err != nil {
+				return fmt.Errorf("fetching batch %d from the first fetcher: %w", batchNum, err)
+			}
+			batchB, err := fetchComparedBatch(groupCtx, b, batchNum)
+			if err != nil {
+				return fmt.Errorf("fetching batch %d from the second fetcher: %w", batchNum, err)
+			}
+			diff := BatchDiff{
+				BatchNum:          batchNum,
+				DataA:             batchA.data,
+				DataB:             batchB.data,
+				ParentChainBlockA: batchA.parentChainBlock,
+				ParentChainBlockB: batchB.parentChainBlock,
+				MissingA:          batchA.missing,
+				MissingB:          batchB.missing,
+			}
+			if diff.DataDiffers() || diff.ParentChainBlockDiffers() {
+				mutex.Lock()
+				diffs = append(diffs, diff)
+				mutex.Unlock()
+			}
+			return nil
+		})
+		if batchNum == last {
+			// Avoids overflowing when last is the highest batch number
+			break
+		}
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].BatchNum < diffs[j].BatchNum })
+	return diffs, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+)
+
+func TestCompareBatches(t *testing.T) {
+	ctx := context.Background()
+	a := consensustest.NewFakeConsensusClient()
+	b := consensustest.NewFakeConsensusClient()
+	for i := 0; i < 20; i++ {
+		batch := consensustest.FakeBatch{Data: []byte(fmt.Sprint("batch", i)), ParentChainBlock: uint64(10 * i), MessageCount: arbutil.MessageIndex(i + 1)}
+		if err := a.AddBatches(batch); err != nil {
+			t.Fatal(err)
+		}
+		if i == 19 {
+			// Not yet posted to b
+			continue
+		}
+		if i == 4 {
+			batch.Data = []byte("corrupted")
+		}
+		if i == 7 {
+			batch.ParentChainBlock = 71
+		}
+		if err := b.AddBatches(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diffs, err := consensus.CompareBatches(ctx, a, b, 0, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 3 || diffs[0].BatchNum != 4 || diffs[1].BatchNum != 7 || diffs[2].BatchNum != 19 {
+		t.Fatal("unexpected diffs", diffs)
+	}
+	if !diffs[0].DataDiffers() || diffs[0].ParentChainBlockDiffers() || string(diffs[0].DataB) != "corrupted" {
+		t.Fatal("unexpected data diff", diffs[0])
+	}
+	if diffs[1].DataDiffers() || !diffs[1].ParentChainBlockDiffers() || diffs[1].ParentChainBlockB != 71 {
+		t.Fatal("unexpected parent chain block diff", diffs[1])
+	}
+	if diffs[2].MissingA || !diffs[2].MissingB || string(diffs[2].DataA) != "batch19" {
+		t.Fatal("unexpected missing batch diff", diffs[2])
+	}
+
+	if diffs, err := consensus.CompareBatches(ctx, a, b, 8, 18); err != nil || len(diffs) != 0 {
+		t.Fatal("unexpected diffs of matching batches", diffs, err)
+	}
+	b.FailNextCalls(1, errors.New("node down"))
+	if _, err := consensus.CompareBatches(ctx, a, b, 0, 3); err == nil {
+		t.Fatal("comparison succeeded with a failing fetcher")
+	}
+	if _, err := consensus.CompareBatches(ctx, a, b, 3, 2); err == nil {
+		t.Fatal("comparison of an empty range succeeded")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensustest
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+// FakeBatchPosterConfig configures how FakeBatchPoster batches messages.
+// The first batch is posted in parent chain block FirstParentChainBlock, and every
+// later one BlocksPerBatch blocks after the previous batch, with BlockTime seconds per block.
+type FakeBatchPosterConfig struct {
+	// Interval is how often a started poster posts a batch
+	Interval time.Duration
+	// MaxBatchMessages is the most messages in a batch, or zero for no limit
+	MaxBatchMessages      int
+	FirstParentChainBlock uint64
+	BlocksPerBatch        uint64
+	BlockTime             uint64
+}
+
+var DefaultFakeBatchPosterConfig = FakeBatchPosterConfig{
+	Interval:              10 * time.Millisecond,
+	MaxBatchMessages:      0,
+	FirstParentChainBlock: 1,
+	BlocksPerBatch:        5,
+	BlockTime:             12,
+}
+
+// FakeBatchPoster posts the messages of a FakeConsensusClient that aren't batched yet, as a batch
+// poster would, so consumers can be tested against batches arriving over time without a parent
+// chain. Batches depend only on the messages and config, not on when they're posted.
+type FakeBatchPoster struct {
+	stopwaiter.StopWaiter
+	client *FakeConsensusClient
+	config FakeBatchPosterConfig
+}
+
+func NewFakeBatchPoster(client *FakeConsensusClient, config FakeBatchPosterConfig) *FakeBatchPoster {
+	return &FakeBatchPoster{
+		client: client,
+		config: config,
+	}
+}
+
+// Start posts a batch every Interval until stopped.
+func (p *FakeBatchPoster) Start(ctxIn context.Context) {
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(func(ctx context.Context) time.Duration {
+		p.PostBatch()
+		return p.config.Interval
+	})
+}
+
+// PostBatch posts a batch of the messages not batched yet, and returns false if there were none.
+func (p *FakeBatchPoster) PostBatch() bool {
+	c := p.client
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var batched arbutil.MessageIndex
+	block := p.config.FirstParentChainBlock
+	if len(c.batches) > 0 {
+		last := c.batches[len(c.batches)-1]
+		batched = last.MessageCount
+		block = last.ParentChainBlock + p.config.BlocksPerBatch
+	}
+	count := arbutil.MessageIndex(len(c.messages))
+	if count <= batched {
+		return false
+	}
+	if p.config.MaxBatchMessages > 0 && count-batched > arbutil.MessageIndex(p.config.MaxBatchMessages) {
+		count = batched + arbutil.MessageIndex(p.config.MaxBatchMessages)
+	}
+	data := binary.BigEndian.AppendUint64(nil, uint64(len(c.batches)))
+	data = binary.BigEndian.AppendUint64(data, uint64(batched))
+	data = binary.BigEndian.AppendUint64(data, uint64(count))
+	c.batches = append(c.batches, FakeBatch{
+		Data:                 data,
+		BlockHash:            common.BytesToHash(binary.BigEndian.AppendUint64(nil, block)),
+		ParentChainBlock:     block,
+		ParentChainBlockTime: block * p.config.BlockTime,
+		MessageCount:         count,
+	})
+	return true
+}
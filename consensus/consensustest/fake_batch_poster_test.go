@@ -0,0 +1,63 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensustest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+func TestFakeBatchPoster(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeConsensusClient()
+	config := DefaultFakeBatchPosterConfig
+	config.MaxBatchMessages = 3
+	poster := NewFakeBatchPoster(client, config)
+	if poster.PostBatch() {
+		t.Fatal("posted a batch without messages")
+	}
+
+	client.AddMessages(make([]arbostypes.MessageWithMetadata, 5)...)
+	if !poster.PostBatch() || !poster.PostBatch() || poster.PostBatch() {
+		t.Fatal("expected exactly two batches for five messages of at most three per batch")
+	}
+	for batchNum, expected := range []struct {
+		block uint64
+		start uint64
+		end   uint64
+	}{{1, 0, 3}, {6, 3, 5}} {
+		block, err := client.GetBatchParentChainBlock(uint64(batchNum)).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messageRange, err := client.GetBatchMessageRange(uint64(batchNum)).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block != expected.block || uint64(messageRange.Start) != expected.start || uint64(messageRange.End) != expected.end {
+			t.Fatal("batch", batchNum, "in block", block, "with messages", messageRange, "expected", expected)
+		}
+	}
+
+	// Started, it batches messages as they arrive
+	config.Interval = time.Millisecond
+	poster = NewFakeBatchPoster(client, config)
+	poster.Start(ctx)
+	defer poster.StopAndWait()
+	client.AddMessages(make([]arbostypes.MessageWithMetadata, 2)...)
+	for i := 0; ; i++ {
+		if lookup, err := client.FindInboxBatchContainingMessage(6).Await(ctx); err != nil {
+			t.Fatal(err)
+		} else if lookup.Found {
+			break
+		}
+		if i == 1000 {
+			t.Fatal("messages not batched by the started poster")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
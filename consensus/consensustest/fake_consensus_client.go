@@ -0,0 +1,1173 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package consensustest provides an in-memory FullConsensusClient for execution-side tests.
+package consensustest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// FakeBatch is a batch served by FakeConsensusClient.
+// BlockHash and ParentChainBlockTime are of the parent chain block that included it.
+// MessageCount is the total message count after the batch, as in the inbox tracker's batch metadata.
+type FakeBatch struct {
+	Data                 []byte
+	BlockHash            common.Hash
+	ParentChainBlock     uint64
+	ParentChainBlockTime uint64
+	MessageCount         arbutil.MessageIndex
+}
+
+// WrittenMessage is a message successfully written with WriteMessageFromSequencer.
+type WrittenMessage struct {
+	Pos     arbutil.MessageIndex
+	Message arbostypes.MessageWithMetadata
+	Result  execution.MessageResult
+}
+
+type fakeLagThreshold struct {
+	messages   arbutil.MessageIndex
+	onExceed   func(lag arbutil.MessageIndex)
+	onRecovery func()
+	breached   bool
+}
+
+// FakeConsensusClient is a FullConsensusClient backed by in-memory state, following the
+// semantics and typed errors of arbnode.Node:
+//   - a batch beyond the batch count fails with *execution.ErrBatchNotYetPosted, and fetching the data of a pruned one with *execution.ErrBatchPruned
+//   - FindInboxBatchContainingMessage reports Found false, without an error, for a message not yet batched
+//   - WriteMessageFromSequencer fails without writing unless pos is the current message count
+//   - WriteMessageFromSequencerIdempotent remembers the keys of all writes until the next Reorg
+//   - messages have zero results unless written with one or set with SetMessageResult
+//   - batch posting reports are pending unless set with SetBatchPostingReport
+//
+// The processed message count is the number of messages, which are appended by AddMessages
+// and WriteMessageFromSequencer. All methods are safe for concurrent use.
+type FakeConsensusClient struct {
+	mutex           sync.Mutex
+	batches         []FakeBatch
+	oldestBatch     uint64
+	postingReports  map[uint64]execution.PostingReportInfo
+	messages        []arbostypes.MessageWithMetadata
+	results         map[arbutil.MessageIndex]execution.MessageResult
+	oldestMessage   arbutil.MessageIndex
+	written         []WrittenMessage
+	writeKeys       map[string]WrittenMessage
+	syncTarget      arbutil.MessageIndex
+	syncTargetSet   bool
+	syncConfidence  execution.SyncConfidence
+	syncPeers       int
+	chainSpec       execution.ChainSpec
+	l1BaseFee       *big.Int
+	catchUpRate     float64
+	compression     *execution.BatchCompressionStats
+	safe            execution.SafeMsgInfo
+	finalized       execution.FinalizedMsgInfo
+	validated       *arbutil.MessageIndex
+	synced          bool
+	syncMode        execution.SyncMode
+	health          execution.HealthStatus
+	chosenSequencer bool
+	backlog         execution.BacklogStatus
+	latency         time.Duration
+	failCalls       int
+	failErr         error
+	lagThresholds   map[execution.LagSeverity]*fakeLagThreshold
+	closed          chan struct{}
+}
+
+var _ execution.FullConsensusClient = (*FakeConsensusClient)(nil)
+var _ execution.PrunableBatchStore = (*FakeConsensusClient)(nil)
+
+// NewFakeConsensusClient returns a synced, healthy client that is the chosen sequencer, with no batches or messages.
+func NewFakeConsensusClient() *FakeConsensusClient {
+	healthy := execution.ComponentHealth{Healthy: true}
+	return &FakeConsensusClient{
+		synced:   true,
+		syncMode: execution.SyncModeFull,
+		health: execution.HealthStatus{
+			Healthy:         true,
+			ParentChain:     healthy,
+			Feed:            healthy,
+			MessageDelivery: healthy,
+			Batches:         healthy,
+		},
+		chosenSequencer: true,
+		lagThresholds:   make(map[execution.LagSeverity]*fakeLagThreshold),
+		closed:          make(chan struct{}),
+	}
+}
+
+// AddBatches appends batches. Their parent chain blocks and message counts must not decrease.
+func (c *FakeConsensusClient) AddBatches(batches ...FakeBatch) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, batch := range batches {
+		if len(c.batches) > 0 {
+			last := c.batches[len(c.batches)-1]
+			if batch.ParentChainBlock < last.ParentChainBlock || batch.MessageCount < last.MessageCount {
+				return fmt.Errorf("batch %d out of order with previous batch", len(c.batches))
+			}
+		}
+		c.batches = append(c.batches, batch)
+	}
+	return nil
+}
+
+// AddMessages appends messages, as if they were read from the parent chain or the feed.
+func (c *FakeConsensusClient) AddMessages(msgs ...arbostypes.MessageWithMetadata) {
+	c.mutex.Lock()
+	c.messages = append(c.messages, msgs...)
+	c.mutex.Unlock()
+	c.checkLagThresholds()
+}
+
+// Message returns the message at pos, and false if there's none.
+func (c *FakeConsensusClient) Message(pos arbutil.MessageIndex) (arbostypes.MessageWithMetadata, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if uint64(pos) >= uint64(len(c.messages)) {
+		return arbostypes.MessageWithMetadata{}, false
+	}
+	return c.messages[pos], true
+}
+
+// MessageCount returns the number of messages, i.e. the processed message count.
+func (c *FakeConsensusClient) MessageCount() arbutil.MessageIndex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return arbutil.MessageIndex(len(c.messages))
+}
+
+// Written returns every successful WriteMessageFromSequencer call in the order they were applied.
+// Messages rolled back by Reorg are still included.
+func (c *FakeConsensusClient) Written() []WrittenMessage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]WrittenMessage(nil), c.written...)
+}
+
+// Reorg rolls the message count back to count, dropping the batches that contain any of the removed messages.
+func (c *FakeConsensusClient) Reorg(count arbutil.MessageIndex) error {
+	c.mutex.Lock()
+	if uint64(count) > uint64(len(c.messages)) {
+		c.mutex.Unlock()
+		return fmt.Errorf("reorg to message count %d beyond current count %d", count, len(c.messages))
+	}
+	c.messages = c.messages[:count]
+	c.writeKeys = nil
+	for pos := range c.results {
+		if pos >= count {
+			delete(c.results, pos)
+		}
+	}
+	keep := len(c.batches)
+	for keep > 0 && c.batches[keep-1].MessageCount > count {
+		keep--
+	}
+	c.batches = c.batches[:keep]
+	if c.oldestBatch > uint64(keep) {
+		c.oldestBatch = uint64(keep)
+	}
+	c.mutex.Unlock()
+	c.checkLagThresholds()
+	return nil
+}
+
+// SetMessageResult sets the result of the message at pos, checked by VerifyExecutionCheckpoint.
+func (c *FakeConsensusClient) SetMessageResult(pos arbutil.MessageIndex, result execution.MessageResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.setResult(pos, result)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) setResult(pos arbutil.MessageIndex, result execution.MessageResult) {
+	if c.results == nil {
+		c.results = make(map[arbutil.MessageIndex]execution.MessageResult)
+	}
+	c.results[pos] = result
+}
+
+// SetBatchPostingReport sets the posting report returned for report.BatchNum, which must be a batch
+// other than 0 for it to be returned.
+func (c *FakeConsensusClient) SetBatchPostingReport(report execution.PostingReportInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.postingReports == nil {
+		c.postingReports = make(map[uint64]execution.PostingReportInfo)
+	}
+	c.postingReports[report.BatchNum] = report
+}
+
+// SetOldestAvailableMessage makes the messages before pos count as pruned for VerifyExecutionCheckpoint.
+func (c *FakeConsensusClient) SetOldestAvailableMessage(pos arbutil.MessageIndex) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.oldestMessage = pos
+}
+
+// SetLatency delays every call by latency. Calls taking a context return early if it's done.
+func (c *FakeConsensusClient) SetLatency(latency time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.latency = latency
+}
+
+// FailNextCalls makes the next calls calls, to any method, fail with err.
+func (c *FakeConsensusClient) FailNextCalls(calls int, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.failCalls = calls
+	c.failErr = err
+}
+
+func (c *FakeConsensusClient) SetSynced(synced bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.synced = synced
+}
+
+// SetSyncMode sets the mode GetSyncMode reports while the client isn't synced.
+func (c *FakeConsensusClient) SetSyncMode(mode execution.SyncMode) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.syncMode = mode
+}
+
+func (c *FakeConsensusClient) SetHealth(health execution.HealthStatus) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.health = health
+}
+
+// SetSyncTarget establishes the sync target, which isn't established until it's first set.
+func (c *FakeConsensusClient) SetSyncTarget(target arbutil.MessageIndex) {
+	c.mutex.Lock()
+	c.syncTarget = target
+	c.syncTargetSet = true
+	c.mutex.Unlock()
+	c.checkLagThresholds()
+}
+
+// SetSyncTargetConfidence sets the confidence and source peers SyncTargetMessageCount reports.
+func (c *FakeConsensusClient) SetSyncTargetConfidence(confidence execution.SyncConfidence, sourcePeers int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.syncConfidence = confidence
+	c.syncPeers = sourcePeers
+}
+
+// SetCatchUpRate sets the rate, in messages per second, CatchUpEstimate and SyncProgressSnapshot report.
+func (c *FakeConsensusClient) SetCatchUpRate(rate float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.catchUpRate = rate
+}
+
+// SetBatchCompressionStats sets the stats GetBatchCompressionStats reports. Until it's called,
+// GetBatchCompressionStats fails as if the client wasn't posting batches.
+func (c *FakeConsensusClient) SetBatchCompressionStats(stats execution.BatchCompressionStats) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.compression = &stats
+}
+
+func (c *FakeConsensusClient) SetChainSpec(spec execution.ChainSpec) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.chainSpec = spec
+}
+
+// SetL1BaseFee sets the parent chain base fee ComputeMessageL1Fee prices messages at. Until it's
+// set, ComputeMessageL1Fee fails.
+func (c *FakeConsensusClient) SetL1BaseFee(baseFee *big.Int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.l1BaseFee = baseFee
+}
+
+// SetSafeAndFinalizedMsgCount sets the safe and finalized message counts. A count that changes is
+// reported as updated now, at the parent chain block of the latest batch.
+func (c *FakeConsensusClient) SetSafeAndFinalizedMsgCount(safe, finalized arbutil.MessageIndex) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	var block uint64
+	if len(c.batches) > 0 {
+		block = c.batches[len(c.batches)-1].ParentChainBlock
+	}
+	if safe != c.safe.Count {
+		c.safe = execution.SafeMsgInfo{Count: safe, UpdatedAtL1Block: block, UpdatedAt: now}
+	}
+	if finalized != c.finalized.Count {
+		c.finalized = execution.FinalizedMsgInfo{Count: finalized, UpdatedAtL1Block: block, UpdatedAt: now}
+	}
+}
+
+// SetValidatedMessageCount sets the validated message count. Until it's called,
+// ValidatedMessageCount fails as it does without a block validator.
+func (c *FakeConsensusClient) SetValidatedMessageCount(count arbutil.MessageIndex) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.validated = &count
+}
+
+func (c *FakeConsensusClient) SetSequencerWriteBacklog(backlog execution.BacklogStatus) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.backlog = backlog
+}
+
+func (c *FakeConsensusClient) SetChosenSequencer(chosen bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.chosenSequencer = chosen
+}
+
+// checkClosed fails with execution.ErrClientClosed once Close was called.
+func (c *FakeConsensusClient) checkClosed() error {
+	select {
+	case <-c.closed:
+		return execution.ErrClientClosed
+	default:
+		return nil
+	}
+}
+
+// call applies the configured latency and injected errors. It must be called without holding the mutex.
+func (c *FakeConsensusClient) call(ctx context.Context) error {
+	if err := c.checkClosed(); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	latency := c.latency
+	var err error
+	if c.failCalls > 0 {
+		c.failCalls--
+		err = c.failErr
+	}
+	c.mutex.Unlock()
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.closed:
+			return execution.ErrClientClosed
+		}
+	}
+	return err
+}
+
+// getBatch must be called with the mutex held
+func (c *FakeConsensusClient) getBatch(batchNum uint64) (FakeBatch, error) {
+	if batchNum >= uint64(len(c.batches)) {
+		var latest uint64
+		if len(c.batches) > 0 {
+			latest = uint64(len(c.batches)) - 1
+		}
+		return FakeBatch{}, &execution.ErrBatchNotYetPosted{BatchNum: batchNum, LatestPostedBatch: latest}
+	}
+	return c.batches[batchNum], nil
+}
+
+// getBatchData is getBatch for reads of the batch's data, which fail for pruned batches.
+// It must be called with the mutex held.
+func (c *FakeConsensusClient) getBatchData(batchNum uint64) (FakeBatch, error) {
+	if batchNum < c.oldestBatch {
+		return FakeBatch{}, &execution.ErrBatchPruned{OldestAvailable: c.oldestBatch}
+	}
+	return c.getBatch(batchNum)
+}
+
+func (c *FakeConsensusClient) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batch, err := c.getBatchData(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+	}
+	return containers.NewReadyPromise(execution.FetchedBatch{Data: append([]byte(nil), batch.Data...), BlockHash: batch.BlockHash}, nil)
+}
+
+func (c *FakeConsensusClient) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise[[]byte](nil, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batch, err := c.getBatchData(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise[[]byte](nil, err)
+	}
+	size := uint64(len(batch.Data))
+	if offset >= size {
+		return containers.NewReadyPromise[[]byte](nil, fmt.Errorf("%w: offset %d, batch %d has %d bytes", execution.ErrBatchOffsetOutOfRange, offset, batchNum, size))
+	}
+	end := size
+	if length < size-offset {
+		end = offset + length
+	}
+	return containers.NewReadyPromise(append([]byte(nil), batch.Data[offset:end]...), nil)
+}
+
+func (c *FakeConsensusClient) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batch, err := c.getBatchData(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return containers.NewReadyPromise(uint64(len(batch.Data)), nil)
+}
+
+func (c *FakeConsensusClient) GetBatchCount() containers.PromiseInterface[uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(uint64(len(c.batches)), nil)
+}
+
+func (c *FakeConsensusClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.BatchLookup{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.findBatch(message), nil)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) findBatch(message arbutil.MessageIndex) execution.BatchLookup {
+	for seqNum := uint64(0); seqNum < uint64(len(c.batches)); seqNum++ {
+		if c.batches[seqNum].MessageCount > message {
+			return execution.BatchLookup{Batch: seqNum, Found: true}
+		}
+	}
+	return execution.BatchLookup{}
+}
+
+func (c *FakeConsensusClient) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batch, err := c.getBatch(seqNum)
+	return containers.NewReadyPromise(batch.ParentChainBlock, err)
+}
+
+func (c *FakeConsensusClient) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.BatchParentChainBlocks{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(consensus.CollectBatchParentChainBlocks(first, last, func(seqNum uint64) (uint64, error) {
+		batch, err := c.getBatch(seqNum)
+		return batch.ParentChainBlock, err
+	}))
+}
+
+func (c *FakeConsensusClient) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.MessageRange{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.batchMessageRange(batchNum))
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) batchMessageRange(batchNum uint64) (execution.MessageRange, error) {
+	batch, err := c.getBatch(batchNum)
+	if err != nil {
+		return execution.MessageRange{}, err
+	}
+	var start arbutil.MessageIndex
+	if batchNum > 0 {
+		start = c.batches[batchNum-1].MessageCount
+	}
+	return execution.MessageRange{Start: start, End: batch.MessageCount}, nil
+}
+
+func (c *FakeConsensusClient) FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[[]uint64](nil, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	getMessage := func(pos arbutil.MessageIndex) (*arbostypes.MessageWithMetadata, error) {
+		if pos >= arbutil.MessageIndex(len(c.messages)) {
+			return nil, &consensus.ErrMessageBeyondHead{Pos: pos, Head: arbutil.MessageIndex(len(c.messages))}
+		}
+		return &c.messages[pos], nil
+	}
+	return containers.NewReadyPromise(consensus.FindBatchesContainingKind(context.Background(), first, last, kind, c.batchMessageRange, getMessage))
+}
+
+func (c *FakeConsensusClient) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.L1Info{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	lookup := c.findBatch(pos)
+	if !lookup.Found {
+		return containers.NewReadyPromise(execution.L1Info{Pending: true}, nil)
+	}
+	batch := c.batches[lookup.Batch]
+	return containers.NewReadyPromise(execution.L1Info{
+		BatchNum:    lookup.Batch,
+		L1Block:     batch.ParentChainBlock,
+		L1BlockTime: batch.ParentChainBlockTime,
+		L1BlockHash: batch.BlockHash,
+	}, nil)
+}
+
+func (c *FakeConsensusClient) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[[]uint64](nil, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	batches := []uint64{}
+	if lastBlock < firstBlock {
+		return containers.NewReadyPromise(batches, nil)
+	}
+	for seqNum := uint64(0); seqNum < uint64(len(c.batches)); seqNum++ {
+		block := c.batches[seqNum].ParentChainBlock
+		if block > lastBlock {
+			break
+		}
+		if block >= firstBlock {
+			batches = append(batches, seqNum)
+		}
+	}
+	return containers.NewReadyPromise(batches, nil)
+}
+
+func (c *FakeConsensusClient) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, c.call(context.Background()))
+}
+
+func (c *FakeConsensusClient) PruneBatchesBefore(batchNum uint64) containers.PromiseInterface[struct{}] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if batchNum >= uint64(len(c.batches)) {
+		return containers.NewReadyPromise(struct{}{}, fmt.Errorf("can't prune before batch %d with batch count %d, the latest batch must be kept", batchNum, len(c.batches)))
+	}
+	if batchNum > c.oldestBatch {
+		c.oldestBatch = batchNum
+	}
+	return containers.NewReadyPromise(struct{}{}, nil)
+}
+
+func (c *FakeConsensusClient) OldestAvailableBatch() containers.PromiseInterface[uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.oldestBatch, nil)
+}
+
+// Synced, Healthy, FullSyncProgressMap, SyncTargetMessageCount, Capabilities and
+// SequencerWriteBacklog ignore the latency and injected errors, like the cheap local reads of
+// arbnode.Node, but fail once the client is closed.
+func (c *FakeConsensusClient) Synced() containers.PromiseInterface[bool] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(false, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.synced, nil)
+}
+
+func (c *FakeConsensusClient) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(execution.HealthStatus{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.health, nil)
+}
+
+func (c *FakeConsensusClient) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.PingResult{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(execution.PingResult{MessageCount: arbutil.MessageIndex(len(c.messages)), Time: time.Now()}, nil)
+}
+
+func (c *FakeConsensusClient) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.SyncProgressSnapshot{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.syncProgressSnapshot(), nil)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) syncProgressSnapshot() execution.SyncProgressSnapshot {
+	var l1Block uint64
+	if len(c.batches) > 0 {
+		l1Block = c.batches[len(c.batches)-1].ParentChainBlock
+	}
+	snapshot := execution.SyncProgressSnapshot{
+		Version:           execution.SyncProgressSnapshotVersion,
+		SyncMode:          c.currentSyncMode(),
+		ProcessedMsgCount: arbutil.MessageIndex(len(c.messages)),
+		TargetMsgCount:    c.syncTarget,
+		RemainingMsgCount: c.remainingMessages(),
+		SafeMsgCount:      c.safe.Count,
+		FinalizedMsgCount: c.finalized.Count,
+		L1Block:           l1Block,
+		MsgThroughput:     c.catchUpRate,
+	}
+	lag := c.postingLag()
+	snapshot.PostingLag = &lag
+	if timeToSync, ok := c.timeToSync(); ok {
+		snapshot.EstimatedTimeToSync = &timeToSync
+	}
+	return snapshot
+}
+
+func (c *FakeConsensusClient) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise[map[string]interface{}](nil, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.syncProgressSnapshot().Map())
+}
+
+func (c *FakeConsensusClient) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(execution.SyncTarget{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.syncTargetSet {
+		return containers.NewReadyPromise(execution.SyncTarget{}, nil)
+	}
+	return containers.NewReadyPromise(execution.SyncTarget{Established: true, Count: c.syncTarget, Confidence: c.syncConfidence, SourcePeers: c.syncPeers}, nil)
+}
+
+func (c *FakeConsensusClient) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.CatchUpEstimate{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	estimate := execution.CatchUpEstimate{Rate: c.catchUpRate, MessagesRemaining: c.remainingMessages()}
+	estimate.TimeToSync, _ = c.timeToSync()
+	return containers.NewReadyPromise(estimate, nil)
+}
+
+func (c *FakeConsensusClient) remainingMessages() arbutil.MessageIndex {
+	if processed := arbutil.MessageIndex(len(c.messages)); c.syncTarget > processed {
+		return c.syncTarget - processed
+	}
+	return 0
+}
+
+// timeToSync assumes a fixed sync target, so it only fails if the catch-up rate is zero.
+// Like remainingMessages, it must be called with the mutex held.
+func (c *FakeConsensusClient) timeToSync() (time.Duration, bool) {
+	remaining := c.remainingMessages()
+	if remaining == 0 {
+		return 0, true
+	}
+	if c.catchUpRate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(remaining) / c.catchUpRate * float64(time.Second)), true
+}
+
+// Capabilities reports compression stats only once SetBatchCompressionStats was called.
+// Writes ahead of the message count are rejected, so the sequencer pipeline isn't reported.
+func (c *FakeConsensusClient) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(execution.CapabilitySet{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	capabilities := []execution.ConsensusCapability{
+		execution.CapabilityBatchChunks,
+		execution.CapabilityBatchRanges,
+		execution.CapabilityBatchPruning,
+		execution.CapabilitySequencerDeadlines,
+	}
+	if c.compression != nil {
+		capabilities = append(capabilities, execution.CapabilityCompressionStats)
+	}
+	return containers.NewReadyPromise(execution.CapabilitySet{
+		ProtocolVersion: execution.ConsensusProtocolVersion,
+		Capabilities:    capabilities,
+	}, nil)
+}
+
+func (c *FakeConsensusClient) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.BatchCompressionStats{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.compression == nil {
+		return containers.NewReadyPromise(execution.BatchCompressionStats{}, execution.ErrBatchPosterNotEnabled)
+	}
+	return containers.NewReadyPromise(*c.compression, nil)
+}
+
+func (c *FakeConsensusClient) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[execution.SyncMode]("", err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.currentSyncMode(), nil)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) currentSyncMode() execution.SyncMode {
+	if c.synced {
+		return execution.SyncModeNone
+	}
+	return c.syncMode
+}
+
+// GetBatchPostingLag reports the poster as enabled if compression stats were set with
+// SetBatchCompressionStats, and the messages after the latest batch as unposted.
+func (c *FakeConsensusClient) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.PostingLag{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.postingLag(), nil)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) postingLag() execution.PostingLag {
+	var posted arbutil.MessageIndex
+	if len(c.batches) > 0 {
+		posted = c.batches[len(c.batches)-1].MessageCount
+	}
+	msgCount := arbutil.MessageIndex(len(c.messages))
+	var timestamp uint64
+	if posted < msgCount {
+		if msg := c.messages[posted].Message; msg != nil && msg.Header != nil {
+			timestamp = msg.Header.Timestamp
+		}
+	}
+	return consensus.NewPostingLag(c.compression != nil, msgCount, posted, timestamp, time.Now())
+}
+
+// MessageIndexToBlockNumber uses the genesis block number of the chain spec set with SetChainSpec
+func (c *FakeConsensusClient) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if count := arbutil.MessageIndex(len(c.messages)); pos >= count {
+		return containers.NewReadyPromise[uint64](0, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count})
+	}
+	return containers.NewReadyPromise(arbutil.MessageIndexToBlockNumber(pos, c.chainSpec.GenesisBlockNum))
+}
+
+// GetMessageAccHash hashes the message with the chain ID of the chain spec set with SetChainSpec
+func (c *FakeConsensusClient) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(common.Hash{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if count := arbutil.MessageIndex(len(c.messages)); pos >= count {
+		return containers.NewReadyPromise(common.Hash{}, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count})
+	}
+	return containers.NewReadyPromise(c.messages[pos].Hash(pos, c.chainSpec.ChainID))
+}
+
+// GetCheckpointInfo returns the last message before the finalized message count set with SetSafeAndFinalizedMsgCount
+func (c *FakeConsensusClient) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.CheckpointInfo{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.finalized.Count == 0 {
+		return containers.NewReadyPromise(execution.CheckpointInfo{}, errors.New("no finalized message to checkpoint yet"))
+	}
+	pos := c.finalized.Count - 1
+	result := c.results[pos]
+	return containers.NewReadyPromise(execution.CheckpointInfo{Pos: pos, BlockHash: result.BlockHash, SendRoot: result.SendRoot}, nil)
+}
+
+func (c *FakeConsensusClient) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(struct{}{}, c.verifyCheckpoint(pos, blockHash))
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) verifyCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) error {
+	count := arbutil.MessageIndex(len(c.messages))
+	if pos >= count {
+		return &consensus.ErrMessageBeyondHead{Pos: pos, Head: count}
+	}
+	if pos+1 < c.oldestMessage {
+		return &consensus.ErrSnapshotTooOld{Pos: pos}
+	}
+	if expected := c.results[pos].BlockHash; expected != blockHash {
+		return &consensus.ErrCheckpointMismatch{Pos: pos, ExpectedBlockHash: expected, BlockHash: blockHash}
+	}
+	return nil
+}
+
+// getPostingReport must be called with the mutex held
+func (c *FakeConsensusClient) getPostingReport(seqNum uint64) (execution.PostingReportInfo, error) {
+	if seqNum == 0 {
+		return execution.PostingReportInfo{}, errors.New("batch 0 has no posting report")
+	}
+	if _, err := c.getBatch(seqNum); err != nil {
+		return execution.PostingReportInfo{}, err
+	}
+	report, ok := c.postingReports[seqNum]
+	if !ok {
+		return execution.PostingReportInfo{Pending: true, BatchNum: seqNum}, nil
+	}
+	return report, nil
+}
+
+func (c *FakeConsensusClient) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.PostingReportInfo{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.getPostingReport(seqNum))
+}
+
+func (c *FakeConsensusClient) GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[[]execution.PostingReportInfo](nil, err)
+	}
+	if first > last || last-first >= execution.MaxBatchPostingReportRange {
+		return containers.NewReadyPromise[[]execution.PostingReportInfo](nil, fmt.Errorf("invalid batch posting report range %d to %d, at most %d batches are allowed", first, last, execution.MaxBatchPostingReportRange))
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reports := make([]execution.PostingReportInfo, 0, last-first+1)
+	for seqNum := first; seqNum <= last; seqNum++ {
+		report, err := c.getPostingReport(seqNum)
+		if err != nil {
+			return containers.NewReadyPromise[[]execution.PostingReportInfo](nil, fmt.Errorf("batch %d: %w", seqNum, err))
+		}
+		reports = append(reports, report)
+	}
+	return containers.NewReadyPromise(reports, nil)
+}
+
+func (c *FakeConsensusClient) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pos, err := arbutil.BlockNumberToMessageIndex(block, c.chainSpec.GenesisBlockNum)
+	if err != nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, err)
+	}
+	if count := arbutil.MessageIndex(len(c.messages)); pos >= count {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, &consensus.ErrMessageBeyondHead{Pos: pos, Head: count})
+	}
+	return containers.NewReadyPromise(pos, nil)
+}
+
+func (c *FakeConsensusClient) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.ChainSpec{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.chainSpec, nil)
+}
+
+func (c *FakeConsensusClient) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.SafeMsgInfo{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.safe, nil)
+}
+
+func (c *FakeConsensusClient) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.FinalizedMsgInfo{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.finalized, nil)
+}
+
+// GetSafeMsgCountWithHash and GetFinalizedMsgCountWithHash return the block hash of the message's
+// result, if it was written with one or set with SetMessageResult.
+func (c *FakeConsensusClient) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.msgCountWithHash(c.safe.Count), nil)
+}
+
+func (c *FakeConsensusClient) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.msgCountWithHash(c.finalized.Count), nil)
+}
+
+// The mutex must be held
+func (c *FakeConsensusClient) msgCountWithHash(count arbutil.MessageIndex) execution.MsgCountWithHash {
+	withHash := execution.MsgCountWithHash{Count: count}
+	if count > 0 {
+		if result, ok := c.results[count-1]; ok {
+			blockHash := result.BlockHash
+			withHash.BlockHash = &blockHash
+		}
+	}
+	return withHash
+}
+
+func (c *FakeConsensusClient) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.validated == nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, errors.New("validator not set up"))
+	}
+	return containers.NewReadyPromise(*c.validated, nil)
+}
+
+func (c *FakeConsensusClient) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	if severity != execution.LagSeverityWarn && severity != execution.LagSeverityCritical {
+		return containers.NewReadyPromise(struct{}{}, fmt.Errorf("unknown lag severity %v", severity))
+	}
+	if onExceed == nil {
+		return containers.NewReadyPromise(struct{}{}, errors.New("lag threshold requires an onExceed callback"))
+	}
+	c.mutex.Lock()
+	c.lagThresholds[severity] = &fakeLagThreshold{
+		messages:   messages,
+		onExceed:   onExceed,
+		onRecovery: onRecovery,
+	}
+	c.mutex.Unlock()
+	c.checkLagThresholds()
+	return containers.NewReadyPromise(struct{}{}, nil)
+}
+
+func (c *FakeConsensusClient) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lagThresholds = make(map[execution.LagSeverity]*fakeLagThreshold)
+	return containers.NewReadyPromise(struct{}{}, nil)
+}
+
+// checkLagThresholds fires the callbacks of thresholds whose breached state changed,
+// without holding the mutex.
+func (c *FakeConsensusClient) checkLagThresholds() {
+	c.mutex.Lock()
+	var lag arbutil.MessageIndex
+	if processed := arbutil.MessageIndex(len(c.messages)); c.syncTarget > processed {
+		lag = c.syncTarget - processed
+	}
+	var callbacks []func()
+	for _, threshold := range c.lagThresholds {
+		if !threshold.breached && lag > threshold.messages {
+			threshold.breached = true
+			onExceed := threshold.onExceed
+			callbacks = append(callbacks, func() { onExceed(lag) })
+		} else if threshold.breached && lag <= threshold.messages {
+			threshold.breached = false
+			if threshold.onRecovery != nil {
+				callbacks = append(callbacks, threshold.onRecovery)
+			}
+		}
+	}
+	c.mutex.Unlock()
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+func (c *FakeConsensusClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	_, err := c.writeMessage(pos, msgWithMeta, msgResult, time.Time{}, "")
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (c *FakeConsensusClient) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	return containers.NewReadyPromise(c.writeMessage(pos, msgWithMeta, msgResult, deadline, ""))
+}
+
+func (c *FakeConsensusClient) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	if key == "" {
+		return containers.NewReadyPromise(struct{}{}, errors.New("idempotent sequencer write requires a key"))
+	}
+	_, err := c.writeMessage(pos, msgWithMeta, msgResult, time.Time{}, key)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (c *FakeConsensusClient) writeMessage(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time, key string) (time.Time, error) {
+	if err := c.expectChosenSequencer(); err != nil {
+		return time.Time{}, err
+	}
+	c.mutex.Lock()
+	if written, ok := c.writeKeys[key]; ok && key != "" {
+		c.mutex.Unlock()
+		if written.Pos != pos || !reflect.DeepEqual(written.Message, msgWithMeta) {
+			return time.Time{}, fmt.Errorf("%w: key %q was written at pos %d", execution.ErrIdempotencyConflict, key, written.Pos)
+		}
+		return time.Now(), nil
+	}
+	msgCount := arbutil.MessageIndex(len(c.messages))
+	if pos != msgCount {
+		c.mutex.Unlock()
+		return time.Time{}, consensus.NewWrongPosError(pos, msgCount)
+	}
+	// The configured latency was spent in ExpectChosenSequencer, so it counts against the deadline
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		c.mutex.Unlock()
+		return time.Time{}, &execution.ErrCommitDeadlineExceeded{Pos: pos, Deadline: deadline}
+	}
+	c.messages = append(c.messages, msgWithMeta)
+	c.written = append(c.written, WrittenMessage{Pos: pos, Message: msgWithMeta, Result: msgResult})
+	c.setResult(pos, msgResult)
+	if key != "" {
+		if c.writeKeys == nil {
+			c.writeKeys = make(map[string]WrittenMessage)
+		}
+		c.writeKeys[key] = c.written[len(c.written)-1]
+	}
+	committed := time.Now()
+	c.mutex.Unlock()
+	c.checkLagThresholds()
+	return committed, nil
+}
+
+// SequencerWriteBacklog returns the backlog set with SetSequencerWriteBacklog, as writes to the fake never queue.
+func (c *FakeConsensusClient) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	if err := c.checkClosed(); err != nil {
+		return containers.NewReadyPromise(execution.BacklogStatus{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return containers.NewReadyPromise(c.backlog, nil)
+}
+
+// DrainSequencerQueue returns right away, as writes to the fake never queue.
+func (c *FakeConsensusClient) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	if err := c.call(ctx); err != nil {
+		return containers.NewReadyPromise(execution.DrainResult{}, err)
+	}
+	return containers.NewReadyPromise(execution.DrainResult{}, nil)
+}
+
+// ComputeMessageL1Fee doesn't compress messages, so their compressed size is their L2 message size.
+func (c *FakeConsensusClient) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	if err := c.call(context.Background()); err != nil {
+		return containers.NewReadyPromise(execution.MessageFeeEstimate{}, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.l1BaseFee == nil {
+		return containers.NewReadyPromise(execution.MessageFeeEstimate{}, errors.New("parent chain base fee not set"))
+	}
+	var size int
+	if msgWithMeta.Message != nil {
+		size = len(msgWithMeta.Message.L2msg)
+	}
+	var batchMessages uint64
+	if len(c.batches) > 0 {
+		batchMessages = uint64(c.batches[len(c.batches)-1].MessageCount)
+		if len(c.batches) > 1 {
+			batchMessages -= uint64(c.batches[len(c.batches)-2].MessageCount)
+		}
+	}
+	return containers.NewReadyPromise(consensus.NewMessageFeeEstimate(size, size, c.l1BaseFee, batchMessages), nil)
+}
+
+func (c *FakeConsensusClient) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, c.expectChosenSequencer())
+}
+
+func (c *FakeConsensusClient) expectChosenSequencer() error {
+	if err := c.call(context.Background()); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.chosenSequencer {
+		return consensus.NewSequencerNotActiveError("not main sequencer")
+	}
+	return nil
+}
+
+// Close fails the calls waiting out the latency set with SetLatency, and every call after it, with execution.ErrClientClosed.
+func (c *FakeConsensusClient) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.checkClosed() == nil {
+		close(c.closed)
+	}
+	return nil
+}
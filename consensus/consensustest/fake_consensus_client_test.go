@@ -0,0 +1,493 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensustest
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func newSeededClient(t *testing.T) *FakeConsensusClient {
+	client := NewFakeConsensusClient()
+	client.AddMessages(make([]arbostypes.MessageWithMetadata, 6)...)
+	err := client.AddBatches(
+		FakeBatch{Data: []byte("batch0"), ParentChainBlock: 10, MessageCount: 2},
+		FakeBatch{Data: []byte("batch1"), ParentChainBlock: 20, ParentChainBlockTime: 1000, MessageCount: 4},
+		FakeBatch{Data: []byte("batch2"), ParentChainBlock: 30, MessageCount: 5},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestFakeConsensusClientBatches(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+
+	for _, tc := range []struct {
+		message arbutil.MessageIndex
+		batch   uint64
+		found   bool
+	}{{0, 0, true}, {1, 0, true}, {2, 1, true}, {4, 2, true}, {5, 0, false}} {
+		lookup, err := client.FindInboxBatchContainingMessage(tc.message).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lookup.Batch != tc.batch || lookup.Found != tc.found {
+			t.Fatal("unexpected batch for message", tc.message, lookup)
+		}
+	}
+
+	info, err := client.GetMessageL1Info(ctx, 3).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Pending || info.BatchNum != 1 || info.L1Block != 20 || info.L1BlockTime != 1000 {
+		t.Fatal("unexpected L1 info", info)
+	}
+	if info, err := client.GetMessageL1Info(ctx, 5).Await(ctx); err != nil || !info.Pending {
+		t.Fatal("expected pending L1 info", info, err)
+	}
+
+	chunk, err := client.FetchBatchChunk(ctx, 1, 4, 100).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chunk) != "h1" {
+		t.Fatal("unexpected chunk", string(chunk))
+	}
+	if _, err := client.FetchBatchChunk(ctx, 1, 6, 1).Await(ctx); !errors.Is(err, execution.ErrBatchOffsetOutOfRange) {
+		t.Fatal("expected out of range error, got", err)
+	}
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if _, err := client.FetchBatch(ctx, 3).Await(ctx); !errors.As(err, &notYetPostedErr) || notYetPostedErr.LatestPostedBatch != 2 {
+		t.Fatal("expected batch not yet posted error, got", err)
+	}
+
+	if _, err := client.PruneBatchesBefore(1).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := client.GetBatchParentChainBlocks(0, 3).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks.First != 0 || len(blocks.Blocks) != 4 || blocks.Blocks[0] != 10 || blocks.Blocks[1] != 20 || blocks.Blocks[2] != 30 ||
+		!blocks.Found[0] || !blocks.Found[1] || !blocks.Found[2] || blocks.Found[3] {
+		t.Fatal("unexpected parent chain blocks", blocks)
+	}
+	if _, err := client.GetBatchParentChainBlocks(0, execution.MaxBatchParentChainBlocksRange).Await(ctx); err == nil {
+		t.Fatal("expected a range over the limit to fail")
+	}
+	// Only the data of pruned batches is gone
+	var prunedErr *execution.ErrBatchPruned
+	if _, err := client.FetchBatch(ctx, 0).Await(ctx); !errors.As(err, &prunedErr) || prunedErr.OldestAvailable != 1 {
+		t.Fatal("expected pruned error, got", err)
+	}
+	if _, err := client.GetBatchSize(ctx, 0).Await(ctx); !errors.As(err, &prunedErr) {
+		t.Fatal("expected pruned error, got", err)
+	}
+	if block, err := client.GetBatchParentChainBlock(0).Await(ctx); err != nil || block != 10 {
+		t.Fatal("unexpected parent chain block of a pruned batch", block, err)
+	}
+	if lookup, err := client.FindInboxBatchContainingMessage(1).Await(ctx); err != nil || !lookup.Found || lookup.Batch != 0 {
+		t.Fatal("unexpected batch of a message of a pruned batch", lookup, err)
+	}
+	batches, err := client.FindBatchesInParentChainRange(5, 30).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 || batches[0] != 0 || batches[2] != 2 {
+		t.Fatal("unexpected batches in range", batches)
+	}
+}
+
+func TestFakeConsensusClientWritesAndReorg(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+	if _, err := client.WriteMessageFromSequencer(5, arbostypes.MessageWithMetadata{}, execution.MessageResult{}).Await(ctx); err == nil {
+		t.Fatal("write at wrong pos succeeded")
+	}
+	if _, err := client.WriteMessageFromSequencer(6, arbostypes.MessageWithMetadata{DelayedMessagesRead: 1}, execution.MessageResult{}).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+	written := client.Written()
+	if len(written) != 1 || written[0].Pos != 6 || written[0].Message.DelayedMessagesRead != 1 {
+		t.Fatal("unexpected written messages", written)
+	}
+
+	msg := arbostypes.MessageWithMetadata{DelayedMessagesRead: 2}
+	for i := 0; i < 2; i++ {
+		if _, err := client.WriteMessageFromSequencerIdempotent(7, msg, execution.MessageResult{}, "key").Await(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(client.Written()) != 2 {
+		t.Fatal("retried idempotent write applied again", client.Written())
+	}
+	if _, err := client.WriteMessageFromSequencerIdempotent(7, arbostypes.MessageWithMetadata{}, execution.MessageResult{}, "key").Await(ctx); !errors.Is(err, execution.ErrIdempotencyConflict) {
+		t.Fatal("expected idempotency conflict, got", err)
+	}
+
+	client.SetChosenSequencer(false)
+	if _, err := client.WriteMessageFromSequencer(8, arbostypes.MessageWithMetadata{}, execution.MessageResult{}).Await(ctx); !errors.Is(err, execution.ErrRetrySequencer) {
+		t.Fatal("expected retry sequencer error, got", err)
+	}
+	client.SetChosenSequencer(true)
+
+	written6 := arbostypes.MessageWithMetadata{DelayedMessagesRead: 1}
+	localHash, err := written6.Hash(6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accHash, err := client.GetMessageAccHash(6).Await(ctx)
+	if err != nil || accHash != localHash {
+		t.Fatal("message hash doesn't match the written message", accHash, err)
+	}
+
+	if err := client.Reorg(3); err != nil {
+		t.Fatal(err)
+	}
+	var beyondHeadErr *consensus.ErrMessageBeyondHead
+	if _, err := client.GetMessageAccHash(6).Await(ctx); !errors.As(err, &beyondHeadErr) || beyondHeadErr.Head != 3 {
+		t.Fatal("expected reorged message beyond head, got", err)
+	}
+	if client.MessageCount() != 3 {
+		t.Fatal("unexpected message count after reorg", client.MessageCount())
+	}
+	batchCount, err := client.GetBatchCount().Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batchCount != 1 {
+		t.Fatal("batches containing reorged messages not dropped", batchCount)
+	}
+	if _, err := client.WriteMessageFromSequencer(3, arbostypes.MessageWithMetadata{}, execution.MessageResult{}).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeConsensusClientCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+	if _, err := client.GetCheckpointInfo(ctx).Await(ctx); err == nil {
+		t.Fatal("checkpoint returned without finalized messages")
+	}
+	result := execution.MessageResult{BlockHash: common.HexToHash("0x1"), SendRoot: common.HexToHash("0x2")}
+	client.SetMessageResult(3, result)
+	client.SetSafeAndFinalizedMsgCount(5, 4)
+	checkpoint, err := client.GetCheckpointInfo(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Pos != 3 || checkpoint.BlockHash != result.BlockHash || checkpoint.SendRoot != result.SendRoot {
+		t.Fatal("unexpected checkpoint", checkpoint)
+	}
+
+	finalized, err := client.GetFinalizedMsgCountWithHash(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalized.Count != 4 || finalized.BlockHash == nil || *finalized.BlockHash != result.BlockHash {
+		t.Fatal("unexpected finalized message count with hash", finalized)
+	}
+	// No result is stored for the last safe message, which must be reported rather than a zero hash
+	safe, err := client.GetSafeMsgCountWithHash(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safe.Count != 5 || safe.BlockHash != nil {
+		t.Fatal("unexpected safe message count with hash", safe)
+	}
+
+	safeInfo, err := client.GetSafeMsgCount(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safeInfo.Count != 5 || safeInfo.UpdatedAtL1Block != 30 || safeInfo.UpdatedAt.IsZero() {
+		t.Fatal("unexpected safe message info", safeInfo)
+	}
+	// Setting an unchanged count doesn't move when it was updated
+	client.SetSafeAndFinalizedMsgCount(5, 5)
+	if info, err := client.GetSafeMsgCount(ctx).Await(ctx); err != nil || info != safeInfo {
+		t.Fatal("unchanged safe message count updated", info, err)
+	}
+	if info, err := client.GetFinalizedMsgCount(ctx).Await(ctx); err != nil || info.Count != 5 || info.UpdatedAt.Before(safeInfo.UpdatedAt) {
+		t.Fatal("unexpected finalized message info", info, err)
+	}
+	client.SetSafeAndFinalizedMsgCount(5, 4)
+
+	if _, err := client.VerifyExecutionCheckpoint(checkpoint.Pos, checkpoint.BlockHash).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var mismatchErr *consensus.ErrCheckpointMismatch
+	_, err = client.VerifyExecutionCheckpoint(checkpoint.Pos, common.HexToHash("0x3")).Await(ctx)
+	if !errors.As(err, &mismatchErr) || mismatchErr.ExpectedBlockHash != result.BlockHash {
+		t.Fatal("expected checkpoint mismatch, got", err)
+	}
+	var beyondHeadErr *consensus.ErrMessageBeyondHead
+	if _, err := client.VerifyExecutionCheckpoint(6, common.Hash{}).Await(ctx); !errors.As(err, &beyondHeadErr) {
+		t.Fatal("expected message beyond head, got", err)
+	}
+	client.SetOldestAvailableMessage(4)
+	if _, err := client.VerifyExecutionCheckpoint(checkpoint.Pos, checkpoint.BlockHash).Await(ctx); err != nil {
+		t.Fatal("checkpoint at the pruning horizon rejected", err)
+	}
+	var tooOldErr *consensus.ErrSnapshotTooOld
+	if _, err := client.VerifyExecutionCheckpoint(2, common.Hash{}).Await(ctx); !errors.As(err, &tooOldErr) || tooOldErr.Pos != 2 {
+		t.Fatal("expected snapshot too old, got", err)
+	}
+}
+
+func TestFakeConsensusClientPostingReports(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+	if _, err := client.GetBatchPostingReport(0).Await(ctx); err == nil {
+		t.Fatal("posting report returned for batch 0")
+	}
+	report, err := client.GetBatchPostingReport(1).Await(ctx)
+	if err != nil || !report.Pending || report.BatchNum != 1 {
+		t.Fatal("expected pending posting report", report, err)
+	}
+	client.SetBatchPostingReport(execution.PostingReportInfo{BatchNum: 2, Pos: 5, DataGas: 100, L1BaseFee: big.NewInt(3)})
+	reports, err := client.GetBatchPostingReports(1, 2).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || !reports[0].Pending || reports[1].Pending || reports[1].Pos != 5 || reports[1].ReportedCost().Uint64() != 300 {
+		t.Fatal("unexpected posting reports", reports)
+	}
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if _, err := client.GetBatchPostingReports(2, 3).Await(ctx); !errors.As(err, &notYetPostedErr) {
+		t.Fatal("expected batch not yet posted, got", err)
+	}
+	if _, err := client.GetBatchPostingReports(1, execution.MaxBatchPostingReportRange+1).Await(ctx); err == nil {
+		t.Fatal("posting reports returned for a range that's too large")
+	}
+}
+
+func TestFakeConsensusClientClose(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+	client.SetLatency(time.Hour)
+	inFlight := make(chan error, 1)
+	go func() {
+		_, err := client.FetchBatch(ctx, 0).Await(ctx)
+		inFlight <- err
+	}()
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-inFlight:
+		if !errors.Is(err, execution.ErrClientClosed) {
+			t.Fatal("expected in-flight call to fail with client closed, got", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight call not failed by close")
+	}
+	if _, err := client.GetMessageAccHash(0).Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected promise to fail with client closed, got", err)
+	}
+	if _, err := client.WriteMessageFromSequencer(6, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected write to fail with client closed, got", err)
+	}
+	if _, err := client.ClearLagThreshold().Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected clearing lag thresholds to fail with client closed, got", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal("closing again failed", err)
+	}
+}
+
+func TestFakeConsensusClientKnobs(t *testing.T) {
+	ctx := context.Background()
+	client := newSeededClient(t)
+	errTransient := errors.New("transient")
+	client.FailNextCalls(2, errTransient)
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetBatchCount().Await(ctx); !errors.Is(err, errTransient) {
+			t.Fatal("expected injected error, got", err)
+		}
+	}
+	if _, err := client.GetBatchCount().Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetLatency(time.Hour)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetBatchSize(timeoutCtx, 0).Await(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected deadline exceeded, got", err)
+	}
+	client.SetLatency(0)
+
+	var exceeded arbutil.MessageIndex
+	recovered := false
+	_, err := client.SetLagThreshold(execution.LagSeverityWarn, 2, func(lag arbutil.MessageIndex) { exceeded = lag }, func() { recovered = true }).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target, err := client.SyncTargetMessageCount().Await(ctx); err != nil || target.Established {
+		t.Fatal("sync target established before being set", target, err)
+	}
+	client.SetSyncTarget(10)
+	if exceeded != 4 {
+		t.Fatal("lag threshold not exceeded", exceeded)
+	}
+	client.SetSyncTargetConfidence(execution.SyncConfidenceL1Confirmed, 2)
+	if target, err := client.SyncTargetMessageCount().Await(ctx); err != nil || !target.Established || target.Count != 10 || target.Confidence != execution.SyncConfidenceL1Confirmed || target.SourcePeers != 2 {
+		t.Fatal("unexpected sync target", target, err)
+	}
+	client.AddMessages(make([]arbostypes.MessageWithMetadata, 2)...)
+	if !recovered {
+		t.Fatal("lag threshold not recovered")
+	}
+	snapshot, err := client.SyncProgressSnapshot(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.RemainingMsgCount != 2 || snapshot.EstimatedTimeToSync != nil {
+		t.Fatal("unexpected sync progress without a catch-up rate", snapshot)
+	}
+	client.SetSynced(false)
+	client.SetSyncMode(execution.SyncModeSnap)
+	if mode, err := client.GetSyncMode().Await(ctx); err != nil || mode != execution.SyncModeSnap {
+		t.Fatal("unexpected sync mode while syncing", mode, err)
+	}
+	client.SetSynced(true)
+	if mode, err := client.GetSyncMode().Await(ctx); err != nil || mode != execution.SyncModeNone {
+		t.Fatal("unexpected sync mode while synced", mode, err)
+	}
+	client.SetCatchUpRate(2)
+	snapshot, err = client.SyncProgressSnapshot(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.SyncMode != execution.SyncModeNone {
+		t.Fatal("unexpected sync mode in snapshot", snapshot.SyncMode)
+	}
+	if snapshot.EstimatedTimeToSync == nil || *snapshot.EstimatedTimeToSync != time.Second {
+		t.Fatal("unexpected time to sync", snapshot.EstimatedTimeToSync)
+	}
+
+	msg := arbostypes.MessageWithMetadata{Message: &arbostypes.L1IncomingMessage{L2msg: make([]byte, 10)}}
+	if _, err := client.ComputeMessageL1Fee(msg).Await(ctx); err == nil {
+		t.Fatal("message fee estimated without a parent chain base fee")
+	}
+	client.SetL1BaseFee(big.NewInt(1))
+	estimate, err := client.ComputeMessageL1Fee(msg).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The latest batch has a message, which bears the whole fixed cost
+	if estimate.CompressedBytes != 10 || estimate.L1DataFee.Cmp(big.NewInt(160)) != 0 || estimate.BatchAmortizedFee.Cmp(big.NewInt(160+21000)) != 0 {
+		t.Fatal("unexpected message fee estimate", estimate)
+	}
+
+	if capabilities, err := client.Capabilities().Await(ctx); err != nil || capabilities.Has(execution.CapabilityCompressionStats) {
+		t.Fatal("compression stats reported before being set", err)
+	}
+	if _, err := client.GetBatchCompressionStats().Await(ctx); !errors.Is(err, execution.ErrBatchPosterNotEnabled) {
+		t.Fatal("expected batch poster not enabled, got", err)
+	}
+	client.SetBatchCompressionStats(execution.BatchCompressionStats{TotalBatchesPosted: 1})
+	if capabilities, err := client.Capabilities().Await(ctx); err != nil || !capabilities.Has(execution.CapabilityCompressionStats) {
+		t.Fatal("compression stats not reported once set", err)
+	}
+}
+
+func TestFakeConsensusClientPostingLag(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeConsensusClient()
+	timestamp := uint64(time.Now().Add(-time.Hour).Unix())
+	for i := uint64(0); i < 4; i++ {
+		client.AddMessages(arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{Header: &arbostypes.L1IncomingMessageHeader{Timestamp: timestamp + i}},
+		})
+	}
+	if err := client.AddBatches(FakeBatch{Data: []byte("batch0"), ParentChainBlock: 10, MessageCount: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	lag, err := client.GetBatchPostingLag().Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lag.PosterEnabled || lag.MessageCount != 4 || lag.PostedMsgCount != 1 || lag.UnpostedMessages != 3 {
+		t.Fatal("unexpected posting lag", lag)
+	}
+	// The oldest unposted message is a second younger than the first message
+	if lag.OldestUnpostedAge < time.Hour-2*time.Second || lag.OldestUnpostedAge > time.Hour+time.Minute {
+		t.Fatal("unexpected age of the oldest unposted message", lag.OldestUnpostedAge)
+	}
+
+	client.SetBatchCompressionStats(execution.BatchCompressionStats{})
+	if err := client.AddBatches(FakeBatch{Data: []byte("batch1"), ParentChainBlock: 20, MessageCount: 4}); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := client.SyncProgressSnapshot(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.PostingLag == nil || !snapshot.PostingLag.PosterEnabled || snapshot.PostingLag.UnpostedMessages != 0 || snapshot.PostingLag.OldestUnpostedAge != 0 {
+		t.Fatal("unexpected posting lag in sync progress", snapshot.PostingLag)
+	}
+}
+
+func TestFakeConsensusClientFindBatchesContainingKind(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeConsensusClient()
+	for _, kind := range []uint8{
+		arbostypes.L1MessageType_L2Message, arbostypes.L1MessageType_L2Message,
+		arbostypes.L1MessageType_EthDeposit, arbostypes.L1MessageType_L2Message,
+		arbostypes.L1MessageType_L2Message, arbostypes.L1MessageType_EthDeposit,
+	} {
+		client.AddMessages(arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{Header: &arbostypes.L1IncomingMessageHeader{Kind: kind}},
+		})
+	}
+	err := client.AddBatches(
+		FakeBatch{Data: []byte("batch0"), MessageCount: 2},
+		FakeBatch{Data: []byte("batch1"), MessageCount: 4},
+		FakeBatch{Data: []byte("batch2"), MessageCount: 5},
+		FakeBatch{Data: []byte("batch3"), MessageCount: 6},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batches, err := client.FindBatchesContainingKind(0, 3, arbostypes.L1MessageType_EthDeposit).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0] != 1 || batches[1] != 3 {
+		t.Fatal("unexpected batches containing deposits", batches)
+	}
+	batches, err = client.FindBatchesContainingKind(1, 2, arbostypes.L1MessageType_SubmitRetryable).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 0 {
+		t.Fatal("unexpected batches containing retryables", batches)
+	}
+
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if _, err := client.FindBatchesContainingKind(3, 4, arbostypes.L1MessageType_L2Message).Await(ctx); !errors.As(err, &notYetPostedErr) {
+		t.Fatal("expected batch not yet posted error, got", err)
+	}
+	if _, err := client.FindBatchesContainingKind(0, execution.MaxFindBatchesContainingKindRange, arbostypes.L1MessageType_L2Message).Await(ctx); err == nil {
+		t.Fatal("expected a range over the limit to fail")
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// RunContractTests checks that client follows the behavior of the consensus interfaces beyond
+// their Go signatures, such as which typed errors are returned, so new implementations can be
+// verified without knowing how arbnode.Node works. The sequencer checks write a message, so
+// client must be a test instance. They're skipped if client isn't the chosen sequencer.
+func RunContractTests(ctx context.Context, client execution.FullConsensusClient, t *testing.T) {
+	t.Helper()
+	t.Run("FetchBatch", func(t *testing.T) {
+		checkFetchBatchContract(ctx, client, t)
+	})
+	t.Run("SafeAndFinalized", func(t *testing.T) {
+		checkSafeAndFinalizedContract(ctx, client, t)
+	})
+	t.Run("SequencerWrite", func(t *testing.T) {
+		checkSequencerWriteContract(ctx, client, t)
+	})
+}
+
+func checkFetchBatchContract(ctx context.Context, client execution.FullConsensusClient, t *testing.T) {
+	batch, err := client.FetchBatch(ctx, 0).Await(ctx)
+	if err == nil && batch.Data == nil {
+		t.Fatal("FetchBatch(0) returned neither data nor an error")
+	}
+	if err != nil && !execution.IsBatchUnavailable(err) {
+		t.Fatal("FetchBatch(0) failed without a typed missing batch error:", err)
+	}
+	count, err := client.GetBatchCount().Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count > 0 {
+		if _, err := client.FetchBatch(ctx, count-1).Await(ctx); err != nil && !execution.IsBatchUnavailable(err) {
+			t.Fatal("fetching the latest batch failed without a typed missing batch error:", err)
+		}
+	}
+	if count > 1 {
+		prev, prevErr := client.GetBatchMessageRange(count - 2).Await(ctx)
+		latest, err := client.GetBatchMessageRange(count - 1).Await(ctx)
+		if prevErr == nil && err == nil && prev.End != latest.Start {
+			t.Fatal("message range of the latest batch", latest, "isn't contiguous with the previous batch's", prev)
+		}
+		if err != nil && !execution.IsBatchUnavailable(err) {
+			t.Fatal("getting the message range of the latest batch failed without a typed missing batch error:", err)
+		}
+		if latest.End < latest.Start {
+			t.Fatal("message range of the latest batch ends before it starts", latest)
+		}
+	}
+	blocks, err := client.GetBatchParentChainBlocks(count, count+1).Await(ctx)
+	if err != nil {
+		t.Fatal("getting parent chain blocks of batches not yet posted failed instead of reporting them not found:", err)
+	}
+	if blocks.First != count || len(blocks.Blocks) != 2 || len(blocks.Found) != 2 || blocks.Found[0] || blocks.Found[1] {
+		t.Fatal("unexpected parent chain blocks of batches not yet posted", blocks)
+	}
+	if _, err := client.GetBatchParentChainBlocks(count+1, count).Await(ctx); err == nil {
+		t.Fatal("getting parent chain blocks of a range ending before it starts didn't fail")
+	}
+	_, err = client.FetchBatch(ctx, count).Await(ctx)
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(err, &notYetPostedErr) || notYetPostedErr.BatchNum != count {
+		t.Fatal("fetching the batch at the batch count didn't fail with *ErrBatchNotYetPosted:", err)
+	}
+}
+
+func checkSafeAndFinalizedContract(ctx context.Context, client execution.FullConsensusClient, t *testing.T) {
+	// Finalized is read first, so both advancing in between can't break the ordering
+	finalized, err := client.GetFinalizedMsgCount(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	safe, err := client.GetSafeMsgCount(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if safe.Count < finalized.Count {
+		t.Fatal("safe message count", safe.Count, "is behind finalized message count", finalized.Count)
+	}
+	if safe.UpdatedAt.After(time.Now()) || finalized.UpdatedAt.After(time.Now()) {
+		t.Fatal("safe or finalized message count updated in the future", safe, finalized)
+	}
+}
+
+func checkSequencerWriteContract(ctx context.Context, client execution.FullConsensusClient, t *testing.T) {
+	if _, err := client.ExpectChosenSequencer().Await(ctx); err != nil {
+		t.Skip("not the chosen sequencer:", err)
+	}
+	snapshot, err := client.SyncProgressSnapshot(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := arbostypes.EmptyTestMessageWithMetadata
+	pos := snapshot.ProcessedMsgCount
+	_, err = client.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}).Await(ctx)
+	var conflictErr *ErrConflictingMessage
+	var gapErr *ErrMsgGap
+	if errors.As(err, &conflictErr) {
+		pos = conflictErr.Expected
+		_, err = client.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}).Await(ctx)
+	} else if errors.As(err, &gapErr) {
+		pos = gapErr.Expected
+		_, err = client.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}).Await(ctx)
+	}
+	if err != nil {
+		t.Fatal("sequencer write failed:", err)
+	}
+	_, err = client.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}).Await(ctx)
+	if !errors.As(err, &conflictErr) || conflictErr.Pos != pos {
+		t.Fatal("repeating the write didn't fail with *ErrConflictingMessage:", err)
+	}
+
+	if _, err := client.MessageIndexToBlockNumber(pos).Await(ctx); err != nil {
+		t.Fatal("written message has no block number:", err)
+	}
+	spec, err := client.GetChainSpec(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHash, err := msg.Hash(pos, spec.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := client.GetMessageAccHash(pos).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != expectedHash {
+		t.Fatal("hash of the written message", hash, "isn't the hash of the message", expectedHash)
+	}
+
+	// A message just written is usually not batched yet, but either way the batch must agree with its L1 info
+	lookup, err := client.FindInboxBatchContainingMessage(pos).Await(ctx)
+	if err != nil {
+		t.Fatal("finding the batch of the written message failed:", err)
+	}
+	info, err := client.GetMessageL1Info(ctx, pos).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Found == info.Pending || (lookup.Found && info.BatchNum != lookup.Batch) {
+		t.Fatal("batch of the written message", lookup, "inconsistent with its L1 info", info)
+	}
+	beyond := pos + arbutil.MessageIndex(1<<32)
+	if lookup, err := client.FindInboxBatchContainingMessage(beyond).Await(ctx); err != nil || lookup.Found {
+		t.Fatal("a message far beyond the head was found in a batch", lookup, err)
+	}
+}
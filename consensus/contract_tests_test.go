@@ -0,0 +1,34 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestFakeConsensusClientContract(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Empty", func(t *testing.T) {
+		consensus.RunContractTests(ctx, consensustest.NewFakeConsensusClient(), t)
+	})
+
+	fake := consensustest.NewFakeConsensusClient()
+	fake.AddMessages(make([]arbostypes.MessageWithMetadata, 3)...)
+	if err := fake.AddBatches(consensustest.FakeBatch{Data: []byte("batch0"), ParentChainBlock: 1, MessageCount: 2},
+		consensustest.FakeBatch{Data: []byte("batch1"), ParentChainBlock: 2, MessageCount: 3},
+	); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetSafeAndFinalizedMsgCount(2, 1)
+	fake.SetChainSpec(execution.ChainSpec{ChainID: 412346})
+	t.Run("Seeded", func(t *testing.T) {
+		consensus.RunContractTests(ctx, fake, t)
+	})
+}
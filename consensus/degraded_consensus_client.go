@@ -0,0 +1,483 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// The components of a DegradedModeConsensusClient, as named by ErrComponentUnavailable and ComponentHealth
+const (
+	ComponentBatchFetcher = "batchFetcher"
+	ComponentInfo         = "info"
+	ComponentSequencer    = "sequencer"
+)
+
+// DegradedModeConsensusClient is a FullConsensusClient composed of separate BatchFetcher,
+// ConsensusInfo and ConsensusSequencer implementations, so one of them being down only fails the
+// calls that belong to it. Calls to an unavailable component fail with *ErrComponentUnavailable.
+// Components are unavailable if nil, and otherwise until marked so with SetComponentAvailable.
+type DegradedModeConsensusClient struct {
+	batchFetcher execution.BatchFetcher
+	info         execution.ConsensusInfo
+	sequencer    execution.ConsensusSequencer
+
+	mutex     sync.RWMutex
+	available map[string]bool
+}
+
+var _ execution.FullConsensusClient = (*DegradedModeConsensusClient)(nil)
+
+func NewDegradedModeConsensusClient(batchFetcher execution.BatchFetcher, info execution.ConsensusInfo, sequencer execution.ConsensusSequencer) *DegradedModeConsensusClient {
+	return &DegradedModeConsensusClient{
+		batchFetcher: batchFetcher,
+		info:         info,
+		sequencer:    sequencer,
+		available: map[string]bool{
+			ComponentBatchFetcher: batchFetcher != nil,
+			ComponentInfo:         info != nil,
+			ComponentSequencer:    sequencer != nil,
+		},
+	}
+}
+
+// SetComponentAvailable marks the component as available or not, failing for unknown or nil components.
+func (d *DegradedModeConsensusClient) SetComponentAvailable(component string, available bool) error {
+	var missing bool
+	switch component {
+	case ComponentBatchFetcher:
+		missing = d.batchFetcher == nil
+	case ComponentInfo:
+		missing = d.info == nil
+	case ComponentSequencer:
+		missing = d.sequencer == nil
+	default:
+		return fmt.Errorf("unknown consensus component %q", component)
+	}
+	if available && missing {
+		return fmt.Errorf("consensus component %q not configured", component)
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.available[component] = available
+	return nil
+}
+
+// ComponentHealth returns whether each component is available, by component name.
+func (d *DegradedModeConsensusClient) ComponentHealth() map[string]bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	health := make(map[string]bool, len(d.available))
+	for component, available := range d.available {
+		health[component] = available
+	}
+	return health
+}
+
+func (d *DegradedModeConsensusClient) check(component string) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if !d.available[component] {
+		return &ErrComponentUnavailable{Component: component}
+	}
+	return nil
+}
+
+func (d *DegradedModeConsensusClient) fetcher() (execution.BatchFetcher, error) {
+	return d.batchFetcher, d.check(ComponentBatchFetcher)
+}
+
+func (d *DegradedModeConsensusClient) consensusInfo() (execution.ConsensusInfo, error) {
+	return d.info, d.check(ComponentInfo)
+}
+
+func (d *DegradedModeConsensusClient) consensusSequencer() (execution.ConsensusSequencer, error) {
+	return d.sequencer, d.check(ComponentSequencer)
+}
+
+// Close closes each distinct component that has a Close method, whether or not it's available.
+func (d *DegradedModeConsensusClient) Close() error {
+	var errs []error
+	closed := make(map[execution.ConsensusLifecycle]bool)
+	for _, component := range []interface{}{d.batchFetcher, d.info, d.sequencer} {
+		closer, ok := component.(execution.ConsensusLifecycle)
+		if !ok || closed[closer] {
+			continue
+		}
+		closed[closer] = true
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (d *DegradedModeConsensusClient) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+	}
+	return fetcher.FetchBatch(ctx, batchNum)
+}
+
+func (d *DegradedModeConsensusClient) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise[[]byte](nil, err)
+	}
+	return fetcher.FetchBatchChunk(ctx, batchNum, offset, length)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return fetcher.GetBatchSize(ctx, batchNum)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchCount() containers.PromiseInterface[uint64] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return fetcher.GetBatchCount()
+}
+
+func (d *DegradedModeConsensusClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(execution.BatchLookup{}, err)
+	}
+	return fetcher.FindInboxBatchContainingMessage(message)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return fetcher.GetBatchParentChainBlock(seqNum)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(execution.BatchParentChainBlocks{}, err)
+	}
+	return fetcher.GetBatchParentChainBlocks(first, last)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(execution.MessageRange{}, err)
+	}
+	return fetcher.GetBatchMessageRange(batchNum)
+}
+
+func (d *DegradedModeConsensusClient) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(execution.L1Info{}, err)
+	}
+	return fetcher.GetMessageL1Info(ctx, pos)
+}
+
+func (d *DegradedModeConsensusClient) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise[[]uint64](nil, err)
+	}
+	return fetcher.FindBatchesInParentChainRange(firstBlock, lastBlock)
+}
+
+func (d *DegradedModeConsensusClient) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	fetcher, err := d.fetcher()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return fetcher.PrefetchBatches(first, last)
+}
+
+func (d *DegradedModeConsensusClient) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.CapabilitySet{}, err)
+	}
+	return info.Capabilities()
+}
+
+func (d *DegradedModeConsensusClient) Synced() containers.PromiseInterface[bool] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(false, err)
+	}
+	return info.Synced()
+}
+
+func (d *DegradedModeConsensusClient) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.HealthStatus{}, err)
+	}
+	return info.Healthy()
+}
+
+func (d *DegradedModeConsensusClient) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.PingResult{}, err)
+	}
+	return info.Ping(ctx)
+}
+
+func (d *DegradedModeConsensusClient) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.SyncProgressSnapshot{}, err)
+	}
+	return info.SyncProgressSnapshot(ctx)
+}
+
+func (d *DegradedModeConsensusClient) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[map[string]interface{}](nil, err)
+	}
+	return info.FullSyncProgressMap()
+}
+
+func (d *DegradedModeConsensusClient) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.SyncTarget{}, err)
+	}
+	return info.SyncTargetMessageCount()
+}
+
+func (d *DegradedModeConsensusClient) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.CatchUpEstimate{}, err)
+	}
+	return info.CatchUpEstimate()
+}
+
+func (d *DegradedModeConsensusClient) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.BatchCompressionStats{}, err)
+	}
+	return info.GetBatchCompressionStats()
+}
+
+func (d *DegradedModeConsensusClient) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.PostingLag{}, err)
+	}
+	return info.GetBatchPostingLag()
+}
+
+func (d *DegradedModeConsensusClient) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[execution.SyncMode]("", err)
+	}
+	return info.GetSyncMode()
+}
+
+func (d *DegradedModeConsensusClient) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.ChainSpec{}, err)
+	}
+	return info.GetChainSpec(ctx)
+}
+
+func (d *DegradedModeConsensusClient) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return info.MessageIndexToBlockNumber(pos)
+}
+
+func (d *DegradedModeConsensusClient) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, err)
+	}
+	return info.BlockNumberToMessageIndex(block)
+}
+
+func (d *DegradedModeConsensusClient) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(common.Hash{}, err)
+	}
+	return info.GetMessageAccHash(pos)
+}
+
+func (d *DegradedModeConsensusClient) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.CheckpointInfo{}, err)
+	}
+	return info.GetCheckpointInfo(ctx)
+}
+
+func (d *DegradedModeConsensusClient) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return info.VerifyExecutionCheckpoint(pos, blockHash)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.PostingReportInfo{}, err)
+	}
+	return info.GetBatchPostingReport(seqNum)
+}
+
+func (d *DegradedModeConsensusClient) GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[[]execution.PostingReportInfo](nil, err)
+	}
+	return info.GetBatchPostingReports(first, last)
+}
+
+func (d *DegradedModeConsensusClient) FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[[]uint64](nil, err)
+	}
+	return info.FindBatchesContainingKind(first, last, kind)
+}
+
+func (d *DegradedModeConsensusClient) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.SafeMsgInfo{}, err)
+	}
+	return info.GetSafeMsgCount(ctx)
+}
+
+func (d *DegradedModeConsensusClient) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.FinalizedMsgInfo{}, err)
+	}
+	return info.GetFinalizedMsgCount(ctx)
+}
+
+func (d *DegradedModeConsensusClient) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+	}
+	return info.GetSafeMsgCountWithHash(ctx)
+}
+
+func (d *DegradedModeConsensusClient) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(execution.MsgCountWithHash{}, err)
+	}
+	return info.GetFinalizedMsgCountWithHash(ctx)
+}
+
+func (d *DegradedModeConsensusClient) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise[arbutil.MessageIndex](0, err)
+	}
+	return info.ValidatedMessageCount()
+}
+
+func (d *DegradedModeConsensusClient) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return info.SetLagThreshold(severity, messages, onExceed, onRecovery)
+}
+
+func (d *DegradedModeConsensusClient) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	info, err := d.consensusInfo()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return info.ClearLagThreshold()
+}
+
+func (d *DegradedModeConsensusClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return sequencer.WriteMessageFromSequencer(pos, msgWithMeta, msgResult)
+}
+
+func (d *DegradedModeConsensusClient) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(time.Time{}, err)
+	}
+	return sequencer.WriteMessageFromSequencerWithDeadline(pos, msgWithMeta, msgResult, deadline)
+}
+
+func (d *DegradedModeConsensusClient) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return sequencer.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key)
+}
+
+func (d *DegradedModeConsensusClient) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(execution.MessageFeeEstimate{}, err)
+	}
+	return sequencer.ComputeMessageL1Fee(msgWithMeta)
+}
+
+func (d *DegradedModeConsensusClient) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	return sequencer.ExpectChosenSequencer()
+}
+
+func (d *DegradedModeConsensusClient) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(execution.BacklogStatus{}, err)
+	}
+	return sequencer.SequencerWriteBacklog()
+}
+
+func (d *DegradedModeConsensusClient) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	sequencer, err := d.consensusSequencer()
+	if err != nil {
+		return containers.NewReadyPromise(execution.DrainResult{}, err)
+	}
+	return sequencer.DrainSequencerQueue(ctx)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestDegradedModeConsensusClient(t *testing.T) {
+	ctx := context.Background()
+	fake := consensustest.NewFakeConsensusClient()
+	fake.AddMessages(make([]arbostypes.MessageWithMetadata, 3)...)
+	if err := fake.AddBatches(consensustest.FakeBatch{Data: []byte("batch0"), ParentChainBlock: 1, MessageCount: 3}); err != nil {
+		t.Fatal(err)
+	}
+	client := consensus.NewDegradedModeConsensusClient(fake, fake, fake)
+	t.Run("Available", func(t *testing.T) {
+		consensus.RunContractTests(ctx, client, t)
+	})
+
+	if err := client.SetComponentAvailable(consensus.ComponentSequencer, false); err != nil {
+		t.Fatal(err)
+	}
+	health := client.ComponentHealth()
+	if !health[consensus.ComponentBatchFetcher] || !health[consensus.ComponentInfo] || health[consensus.ComponentSequencer] {
+		t.Fatal("unexpected component health", health)
+	}
+	var unavailableErr *consensus.ErrComponentUnavailable
+	_, err := client.WriteMessageFromSequencer(3, arbostypes.MessageWithMetadata{}, execution.MessageResult{}).Await(ctx)
+	if !errors.As(err, &unavailableErr) || unavailableErr.Component != consensus.ComponentSequencer {
+		t.Fatal("expected the sequencer to be unavailable, got", err)
+	}
+	if _, err := client.DrainSequencerQueue(ctx).Await(ctx); !errors.As(err, &unavailableErr) {
+		t.Fatal("expected draining to fail with the sequencer unavailable, got", err)
+	}
+	// Reads are still served
+	if batch, err := client.FetchBatch(ctx, 0).Await(ctx); err != nil || string(batch.Data) != "batch0" {
+		t.Fatal("unexpected batch with the sequencer unavailable", string(batch.Data), err)
+	}
+	if count, err := client.GetBatchCount().Await(ctx); err != nil || count != 1 {
+		t.Fatal("unexpected batch count with the sequencer unavailable", count, err)
+	}
+	if synced, err := client.Synced().Await(ctx); err != nil || !synced {
+		t.Fatal("not synced with the sequencer unavailable", err)
+	}
+	if _, err := client.GetMessageAccHash(2).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.SetComponentAvailable(consensus.ComponentInfo, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Synced().Await(ctx); !errors.As(err, &unavailableErr) || unavailableErr.Component != consensus.ComponentInfo {
+		t.Fatal("expected synced to fail with the info component unavailable, got", err)
+	}
+	if _, err := client.GetSyncMode().Await(ctx); !errors.As(err, &unavailableErr) || unavailableErr.Component != consensus.ComponentInfo {
+		t.Fatal("expected the info component to be unavailable, got", err)
+	}
+	if _, err := client.FetchBatch(ctx, 0).Await(ctx); err != nil {
+		t.Fatal("batch fetcher failed with the info component unavailable", err)
+	}
+
+	if err := client.SetComponentAvailable(consensus.ComponentSequencer, true); err != nil {
+		t.Fatal(err)
+	}
+	ping, err := fake.Ping(ctx).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WriteMessageFromSequencer(ping.MessageCount, arbostypes.MessageWithMetadata{}, execution.MessageResult{}).Await(ctx); err != nil {
+		t.Fatal("sequencer write failed after the sequencer became available", err)
+	}
+
+	// Missing components are always unavailable
+	readOnly := consensus.NewDegradedModeConsensusClient(fake, fake, nil)
+	if _, err := readOnly.ExpectChosenSequencer().Await(ctx); !errors.As(err, &unavailableErr) {
+		t.Fatal("expected the missing sequencer to be unavailable, got", err)
+	}
+	if err := readOnly.SetComponentAvailable(consensus.ComponentSequencer, true); err == nil {
+		t.Fatal("missing sequencer marked available")
+	}
+	if err := readOnly.SetComponentAvailable("unknown", false); err == nil {
+		t.Fatal("unknown component accepted")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.GetBatchCount().Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected the components to be closed, got", err)
+	}
+}
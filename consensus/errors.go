@@ -0,0 +1,153 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// Errors returned by implementations of the consensus interfaces defined in the execution package.
+// Sentinels are matched with errors.Is, and the struct types, always returned as pointers, with errors.As.
+// The errors already defined by the execution package are aliased, so either name matches.
+var (
+	// ErrQueueFull is returned when a sequencer write can't be queued behind the pending ones.
+	ErrQueueFull = execution.ErrBackpressure
+	// ErrSequencerNotActive is returned by sequencer writes on a node that isn't the chosen
+	// sequencer. It's always wrapped together with execution.ErrRetrySequencer.
+	ErrSequencerNotActive = errors.New("sequencer not active")
+	ErrCircuitOpen        = errors.New("circuit breaker open")
+	// ErrIdempotencyConflict is returned by an idempotent sequencer write whose key was already
+	// used for a different position or message.
+	ErrIdempotencyConflict = execution.ErrIdempotencyConflict
+	// ErrClientClosed is returned by a consensus client after it was closed.
+	ErrClientClosed = execution.ErrClientClosed
+	// ErrDraining is returned by sequencer writes while the sequencer queue is drained.
+	ErrDraining = execution.ErrDraining
+)
+
+type ErrBatchNotFound = execution.ErrBatchNotFound
+type ErrBatchNotYetPosted = execution.ErrBatchNotYetPosted
+type ErrBatchPruned = execution.ErrBatchPruned
+type ErrCommitDeadlineExceeded = execution.ErrCommitDeadlineExceeded
+type ErrBlockBeforeGenesis = arbutil.ErrBlockBeforeGenesis
+
+// ErrMessageBeyondHead is returned when mapping a message (or the block it would produce) that
+// isn't known yet. Head is the message count.
+type ErrMessageBeyondHead struct {
+	Pos  arbutil.MessageIndex
+	Head arbutil.MessageIndex
+}
+
+func (e *ErrMessageBeyondHead) Error() string {
+	return fmt.Sprintf("message %d is beyond head, message count is %d", e.Pos, e.Head)
+}
+
+// ErrCheckpointMismatch is returned when an execution node's state for message Pos has a block hash
+// other than the one consensus has for it, so the snapshot it was bootstrapped from can't be used.
+type ErrCheckpointMismatch struct {
+	Pos               arbutil.MessageIndex
+	ExpectedBlockHash common.Hash
+	BlockHash         common.Hash
+}
+
+func (e *ErrCheckpointMismatch) Error() string {
+	return fmt.Sprintf("execution state for message %d has block hash %v, expected %v", e.Pos, e.BlockHash, e.ExpectedBlockHash)
+}
+
+// ErrSnapshotTooOld is returned when an execution node's state is for message Pos, but the messages
+// after it were pruned, so they can't be delivered. The node needs a snapshot of a more recent checkpoint.
+type ErrSnapshotTooOld struct {
+	Pos arbutil.MessageIndex
+}
+
+func (e *ErrSnapshotTooOld) Error() string {
+	return fmt.Sprintf("snapshot too old: messages after %d have been pruned", e.Pos)
+}
+
+// ErrMessageCeilingExceeded is returned by a sequencer write for a position beyond Ceiling, the
+// highest position the node was configured to accept sequencer writes for.
+type ErrMessageCeilingExceeded struct {
+	Pos     arbutil.MessageIndex
+	Ceiling arbutil.MessageIndex
+}
+
+func (e *ErrMessageCeilingExceeded) Error() string {
+	return fmt.Sprintf("sequencer write for message %d exceeds the message ceiling %d", e.Pos, e.Ceiling)
+}
+
+// ErrEarlierWriteFailed is returned for a sequencer write pending in a pipeline behind the write
+// for FailedPos, which failed, timed out or missed its deadline. Pos can't be committed in order
+// until FailedPos is written again.
+type ErrEarlierWriteFailed struct {
+	Pos       arbutil.MessageIndex
+	FailedPos arbutil.MessageIndex
+}
+
+func (e *ErrEarlierWriteFailed) Error() string {
+	return fmt.Sprintf("sequencer write for message %d aborted, the write for earlier message %d failed", e.Pos, e.FailedPos)
+}
+
+// ErrComponentUnavailable is returned by a DegradedModeConsensusClient for calls to a component
+// that's unavailable, while the calls to its other components are served.
+type ErrComponentUnavailable struct {
+	Component string
+}
+
+func (e *ErrComponentUnavailable) Error() string {
+	return fmt.Sprintf("consensus component %s unavailable", e.Component)
+}
+
+// ErrConflictingMessage is returned by a sequencer write for a position that has already been
+// written, or has a write pending.
+type ErrConflictingMessage struct {
+	Pos arbutil.MessageIndex
+	// Expected is the lowest position a write is accepted for
+	Expected arbutil.MessageIndex
+}
+
+func (e *ErrConflictingMessage) Error() string {
+	return fmt.Sprintf("wrong pos got %d expected %d: conflicts with a written or pending message", e.Pos, e.Expected)
+}
+
+// ErrMsgGap is returned by a sequencer write that would leave earlier positions unwritten.
+type ErrMsgGap struct {
+	Pos      arbutil.MessageIndex
+	Expected arbutil.MessageIndex
+}
+
+func (e *ErrMsgGap) Error() string {
+	return fmt.Sprintf("wrong pos got %d expected %d: leaves a gap", e.Pos, e.Expected)
+}
+
+// ErrRateLimited is returned by a rate limited call. RetryAfter is zero if there's no hint.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter == 0 {
+		return "rate limited"
+	}
+	return fmt.Sprintf("rate limited, retry after %v", e.RetryAfter)
+}
+
+// NewWrongPosError returns the error for a sequencer write at pos, when expected is the next position to write.
+func NewWrongPosError(pos, expected arbutil.MessageIndex) error {
+	if pos < expected {
+		return &ErrConflictingMessage{Pos: pos, Expected: expected}
+	}
+	return &ErrMsgGap{Pos: pos, Expected: expected}
+}
+
+// NewSequencerNotActiveError returns an error matching both ErrSequencerNotActive and execution.ErrRetrySequencer.
+func NewSequencerNotActiveError(reason string) error {
+	return fmt.Errorf("%w: %w: %s", execution.ErrRetrySequencer, ErrSequencerNotActive, reason)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestErrorsRoundTrip(t *testing.T) {
+	for _, sentinel := range []error{ErrQueueFull, ErrSequencerNotActive, ErrCircuitOpen, ErrIdempotencyConflict} {
+		wrapped := fmt.Errorf("calling consensus: %w", sentinel)
+		if !errors.Is(wrapped, sentinel) {
+			t.Fatal("wrapped sentinel not matched", sentinel)
+		}
+	}
+
+	deadline := time.Now()
+	for _, tc := range []struct {
+		err   error
+		match func(error) bool
+	}{
+		{&ErrBatchNotFound{BatchNum: 7}, func(err error) bool {
+			var target *execution.ErrBatchNotFound
+			return errors.As(err, &target) && target.BatchNum == 7
+		}},
+		{&ErrBatchNotYetPosted{BatchNum: 8, LatestPostedBatch: 6}, func(err error) bool {
+			var target *execution.ErrBatchNotYetPosted
+			var notFound *ErrBatchNotFound
+			return errors.As(err, &target) && target.BatchNum == 8 && target.LatestPostedBatch == 6 && !errors.As(err, &notFound)
+		}},
+		{&ErrBatchPruned{OldestAvailable: 3}, func(err error) bool {
+			var target *ErrBatchPruned
+			return errors.As(err, &target) && target.OldestAvailable == 3
+		}},
+		{&ErrCommitDeadlineExceeded{Pos: 4, Deadline: deadline}, func(err error) bool {
+			var target *execution.ErrCommitDeadlineExceeded
+			return errors.As(err, &target) && target.Pos == 4 && target.Deadline.Equal(deadline)
+		}},
+		{NewWrongPosError(1, 2), func(err error) bool {
+			var target *ErrConflictingMessage
+			return errors.As(err, &target) && target.Pos == 1 && target.Expected == 2
+		}},
+		{NewWrongPosError(3, 2), func(err error) bool {
+			var target *ErrMsgGap
+			return errors.As(err, &target) && target.Pos == 3 && target.Expected == 2
+		}},
+		{&ErrRateLimited{RetryAfter: time.Second}, func(err error) bool {
+			var target *ErrRateLimited
+			return errors.As(err, &target) && target.RetryAfter == time.Second
+		}},
+		{&ErrMessageBeyondHead{Pos: 5, Head: 5}, func(err error) bool {
+			var target *ErrMessageBeyondHead
+			return errors.As(err, &target) && target.Pos == 5 && target.Head == 5
+		}},
+		{&ErrMessageCeilingExceeded{Pos: 9, Ceiling: 8}, func(err error) bool {
+			var target *ErrMessageCeilingExceeded
+			return errors.As(err, &target) && target.Pos == 9 && target.Ceiling == 8
+		}},
+		{&ErrEarlierWriteFailed{Pos: 7, FailedPos: 5}, func(err error) bool {
+			var target *ErrEarlierWriteFailed
+			return errors.As(err, &target) && target.Pos == 7 && target.FailedPos == 5
+		}},
+		{&ErrComponentUnavailable{Component: ComponentSequencer}, func(err error) bool {
+			var target *ErrComponentUnavailable
+			return errors.As(err, &target) && target.Component == ComponentSequencer
+		}},
+		{&ErrCheckpointMismatch{Pos: 6, ExpectedBlockHash: common.Hash{1}, BlockHash: common.Hash{2}}, func(err error) bool {
+			var target *ErrCheckpointMismatch
+			return errors.As(err, &target) && target.Pos == 6 && target.ExpectedBlockHash == common.Hash{1} && target.BlockHash == common.Hash{2}
+		}},
+		{&ErrSnapshotTooOld{Pos: 2}, func(err error) bool {
+			var target *ErrSnapshotTooOld
+			return errors.As(err, &target) && target.Pos == 2
+		}},
+		{&ErrBlockBeforeGenesis{Block: 1, Genesis: 2}, func(err error) bool {
+			var target *arbutil.ErrBlockBeforeGenesis
+			return errors.As(err, &target) && target.Block == 1 && target.Genesis == 2
+		}},
+		{NewSequencerNotActiveError("testing"), func(err error) bool {
+			return errors.Is(err, ErrSequencerNotActive) && errors.Is(err, execution.ErrRetrySequencer)
+		}},
+	} {
+		wrapped := fmt.Errorf("calling consensus: %w", tc.err)
+		if !tc.match(wrapped) {
+			t.Fatal("error didn't round-trip", tc.err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// A call that doesn't return within this long is assumed to be deadlocked
+const fuzzCallTimeout = 5 * time.Second
+
+// The batch poster's default max batch size
+const fuzzMaxBatchSize = 100_000
+
+func callWithTimeout(t *testing.T, name string, call func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		call()
+	}()
+	select {
+	case <-done:
+	case <-time.After(fuzzCallTimeout):
+		t.Fatalf("%s didn't return within %v", name, fuzzCallTimeout)
+	}
+}
+
+func checkBatchFetcherError(t *testing.T, name string, err error) {
+	t.Helper()
+	var notFoundErr *consensus.ErrBatchNotFound
+	var notYetPostedErr *consensus.ErrBatchNotYetPosted
+	var prunedErr *consensus.ErrBatchPruned
+	if err == nil || errors.As(err, &notFoundErr) || errors.As(err, &notYetPostedErr) || errors.Is(err, execution.ErrBatchOffsetOutOfRange) || errors.As(err, &prunedErr) {
+		return
+	}
+	t.Fatalf("%s returned an error outside the consensus error types: %v", name, err)
+}
+
+func storedBatchesHash(t *testing.T, fetcher execution.BatchFetcher, count uint64) [32]byte {
+	t.Helper()
+	hasher := sha256.New()
+	for batchNum := uint64(0); batchNum < count; batchNum++ {
+		batch, err := fetcher.FetchBatch(context.Background(), batchNum).Await(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasher.Write(batch.Data)
+		hasher.Write(batch.BlockHash[:])
+	}
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// fuzzBatchFetcher checks the fetcher, which serves count batches, with arbitrary arguments.
+// Each call must return one of the consensus errors without deadlocking, the results must agree
+// with each other, and modifying them must not change the stored batches.
+func fuzzBatchFetcher(t *testing.T, fetcher execution.BatchFetcher, count uint64, batchNum, offset, length uint64) {
+	ctx := context.Background()
+	before := storedBatchesHash(t, fetcher, count)
+
+	var batch execution.FetchedBatch
+	var chunk []byte
+	var size uint64
+	var dataErr, chunkErr, sizeErr error
+	callWithTimeout(t, "FetchBatch", func() { batch, dataErr = fetcher.FetchBatch(ctx, batchNum).Await(ctx) })
+	data := batch.Data
+	callWithTimeout(t, "FetchBatchChunk", func() { chunk, chunkErr = fetcher.FetchBatchChunk(ctx, batchNum, offset, length).Await(ctx) })
+	callWithTimeout(t, "GetBatchSize", func() { size, sizeErr = fetcher.GetBatchSize(ctx, batchNum).Await(ctx) })
+	checkBatchFetcherError(t, "FetchBatch", dataErr)
+	checkBatchFetcherError(t, "FetchBatchChunk", chunkErr)
+	checkBatchFetcherError(t, "GetBatchSize", sizeErr)
+
+	if dataErr == nil {
+		if sizeErr != nil || size != uint64(len(data)) {
+			t.Fatalf("GetBatchSize(%d) = %d, %v but FetchBatch returned %d bytes", batchNum, size, sizeErr, len(data))
+		}
+		if offset < uint64(len(data)) {
+			end := uint64(len(data))
+			if length < end-offset {
+				end = offset + length
+			}
+			if chunkErr != nil || !bytes.Equal(chunk, data[offset:end]) {
+				t.Fatalf("FetchBatchChunk(%d, %d, %d) doesn't match the batch data, err %v", batchNum, offset, length, chunkErr)
+			}
+		} else if !errors.Is(chunkErr, execution.ErrBatchOffsetOutOfRange) {
+			t.Fatalf("FetchBatchChunk(%d, %d, %d) past the end of the batch returned %v", batchNum, offset, length, chunkErr)
+		}
+	} else if batchNum < count {
+		t.Fatalf("FetchBatch(%d) of a stored batch failed: %v", batchNum, dataErr)
+	}
+
+	for i := range data {
+		data[i] ^= 0xff
+	}
+	for i := range chunk {
+		chunk[i] ^= 0xff
+	}
+	if storedBatchesHash(t, fetcher, count) != before {
+		t.Fatal("stored batches changed by fetching them")
+	}
+}
+
+func FuzzFetchBatch(f *testing.F) {
+	f.Add([]byte{}, uint64(0), uint64(0), uint64(0))
+	f.Add([]byte{}, uint64(0), uint64(math.MaxUint64), uint64(math.MaxUint64))
+	f.Add(bytes.Repeat([]byte{0xff}, fuzzMaxBatchSize), uint64(0), uint64(fuzzMaxBatchSize-1), uint64(math.MaxUint64))
+	f.Add([]byte("batch"), uint64(1), uint64(2), uint64(2))
+	f.Add([]byte("batch"), uint64(math.MaxUint64), uint64(0), uint64(1))
+	f.Fuzz(func(t *testing.T, data []byte, batchNum, offset, length uint64) {
+		fake := consensustest.NewFakeConsensusClient()
+		err := fake.AddBatches(
+			consensustest.FakeBatch{Data: data, ParentChainBlock: 1, MessageCount: 1},
+			consensustest.FakeBatch{Data: []byte("second batch"), ParentChainBlock: 2, MessageCount: 2},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fuzzBatchFetcher(t, fake, 2, batchNum, offset, length)
+
+		config := consensus.TestPoolConfig
+		pool, err := consensus.NewPooledBatchFetcher(&config, func(int) (execution.BatchFetcher, error) {
+			return fake, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fuzzBatchFetcher(t, pool, 2, batchNum, offset, length)
+	})
+}
+
+// readableSequencer is a ConsensusSequencer whose messages can be read back
+type readableSequencer interface {
+	execution.ConsensusSequencer
+	MessageCount() arbutil.MessageIndex
+	Message(pos arbutil.MessageIndex) (arbostypes.MessageWithMetadata, bool)
+}
+
+func storedMessagesHash(t *testing.T, sequencer readableSequencer, count arbutil.MessageIndex) [32]byte {
+	t.Helper()
+	hasher := sha256.New()
+	for pos := arbutil.MessageIndex(0); pos < count; pos++ {
+		msg, ok := sequencer.Message(pos)
+		if !ok {
+			t.Fatal("stored message missing", pos)
+		}
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hasher.Write(encoded)
+	}
+	var hash [32]byte
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+func checkSequencerError(t *testing.T, err error) {
+	t.Helper()
+	var conflictErr *consensus.ErrConflictingMessage
+	var gapErr *consensus.ErrMsgGap
+	var deadlineErr *consensus.ErrCommitDeadlineExceeded
+	if errors.As(err, &conflictErr) || errors.As(err, &gapErr) || errors.As(err, &deadlineErr) ||
+		errors.Is(err, consensus.ErrSequencerNotActive) || errors.Is(err, consensus.ErrQueueFull) ||
+		errors.Is(err, execution.ErrSequencerInsertLockTaken) {
+		return
+	}
+	t.Fatalf("WriteMessageFromSequencer returned an error outside the consensus error types: %v", err)
+}
+
+// fuzzConsensusSequencer writes msg at pos. The write must only succeed at the message count,
+// must fail with one of the consensus errors otherwise, and must never change earlier messages.
+func fuzzConsensusSequencer(t *testing.T, sequencer readableSequencer, pos arbutil.MessageIndex, msg arbostypes.MessageWithMetadata) {
+	count := sequencer.MessageCount()
+	before := storedMessagesHash(t, sequencer, count)
+	var err error
+	callWithTimeout(t, "WriteMessageFromSequencer", func() {
+		_, err = sequencer.WriteMessageFromSequencer(pos, msg, execution.MessageResult{}).Await(context.Background())
+	})
+	if storedMessagesHash(t, sequencer, count) != before {
+		t.Fatal("earlier messages changed by a write at", pos)
+	}
+	if err != nil {
+		checkSequencerError(t, err)
+		if newCount := sequencer.MessageCount(); newCount != count {
+			t.Fatalf("failed write at %d changed the message count from %d to %d", pos, count, newCount)
+		}
+		return
+	}
+	if pos != count {
+		t.Fatalf("write at %d succeeded with message count %d", pos, count)
+	}
+	written, ok := sequencer.Message(pos)
+	if !ok || written.DelayedMessagesRead != msg.DelayedMessagesRead || !bytes.Equal(written.Message.L2msg, msg.Message.L2msg) {
+		t.Fatal("written message doesn't match", pos)
+	}
+}
+
+func FuzzWriteMessageFromSequencer(f *testing.F) {
+	f.Add(uint64(0), []byte{}, uint64(0))
+	f.Add(uint64(2), []byte{}, uint64(0))
+	f.Add(uint64(2), bytes.Repeat([]byte{0xff}, fuzzMaxBatchSize), uint64(math.MaxUint64))
+	f.Add(uint64(3), []byte("message"), uint64(1))
+	f.Add(uint64(math.MaxUint64), []byte("message"), uint64(1))
+	f.Fuzz(func(t *testing.T, pos uint64, l2msg []byte, delayedRead uint64) {
+		fake := consensustest.NewFakeConsensusClient()
+		fake.AddMessages(make([]arbostypes.MessageWithMetadata, 2)...)
+		msg := arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{Kind: arbostypes.L1MessageType_L2Message},
+				L2msg:  l2msg,
+			},
+			DelayedMessagesRead: delayedRead,
+		}
+		fuzzConsensusSequencer(t, fake, arbutil.MessageIndex(pos), msg)
+
+		fake.SetChosenSequencer(false)
+		fuzzConsensusSequencer(t, fake, fake.MessageCount(), msg)
+	})
+}
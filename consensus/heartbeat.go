@@ -0,0 +1,157 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var heartbeatStaleGauge = metrics.NewRegisteredGauge("arb/consensus/heartbeat/stale", nil)
+
+type HeartbeatConfig struct {
+	Interval  time.Duration `koanf:"interval"`
+	Timeout   time.Duration `koanf:"timeout"`
+	MaxMissed int           `koanf:"max-missed"`
+}
+
+var DefaultHeartbeatConfig = HeartbeatConfig{
+	Interval:  5 * time.Second,
+	Timeout:   2 * time.Second,
+	MaxMissed: 3,
+}
+
+var TestHeartbeatConfig = HeartbeatConfig{
+	Interval:  10 * time.Millisecond,
+	Timeout:   time.Second,
+	MaxMissed: 2,
+}
+
+func HeartbeatConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".interval", DefaultHeartbeatConfig.Interval, "how often to ping the consensus node")
+	f.Duration(prefix+".timeout", DefaultHeartbeatConfig.Timeout, "timeout for a single ping")
+	f.Int(prefix+".max-missed", DefaultHeartbeatConfig.MaxMissed, "number of consecutive missed pings after which the consensus node is considered stale")
+}
+
+func (c *HeartbeatConfig) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("heartbeat interval must be positive")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("heartbeat timeout must be positive")
+	}
+	if c.MaxMissed <= 0 {
+		return errors.New("heartbeat max-missed must be positive")
+	}
+	return nil
+}
+
+// HeartbeatMonitor pings a consensus client, and considers it stale after MaxMissed consecutive
+// pings failed or timed out. OnStale is called when it becomes stale, with the last ping error,
+// and OnRecovered when a ping succeeds again. While stale, Reconnect (if not nil) is called after
+// every missed ping, and is expected to replace the connection the client uses.
+// Hooks are called from the monitor's goroutine, one at a time.
+type HeartbeatMonitor struct {
+	stopwaiter.StopWaiter
+	config *HeartbeatConfig
+	client execution.ConsensusInfo
+
+	OnStale     func(missed int, err error)
+	OnRecovered func(result execution.PingResult)
+	Reconnect   func(ctx context.Context) error
+
+	mutex    sync.Mutex
+	missed   int
+	stale    bool
+	lastPing execution.PingResult
+	lastRTT  time.Duration
+}
+
+func NewHeartbeatMonitor(config *HeartbeatConfig, client execution.ConsensusInfo) (*HeartbeatMonitor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &HeartbeatMonitor{
+		config: config,
+		client: client,
+	}, nil
+}
+
+func (m *HeartbeatMonitor) Start(ctxIn context.Context) {
+	m.StopWaiter.Start(ctxIn, m)
+	m.CallIteratively(func(ctx context.Context) time.Duration {
+		m.heartbeat(ctx)
+		return m.config.Interval
+	})
+}
+
+// Stale returns whether the last MaxMissed pings were missed.
+func (m *HeartbeatMonitor) Stale() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.stale
+}
+
+// LastPing returns the result and round trip time of the last successful ping, and false if none succeeded yet.
+func (m *HeartbeatMonitor) LastPing() (execution.PingResult, time.Duration, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.lastPing, m.lastRTT, !m.lastPing.Time.IsZero()
+}
+
+func (m *HeartbeatMonitor) heartbeat(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, m.config.Timeout)
+	start := time.Now()
+	result, err := m.client.Ping(pingCtx).Await(pingCtx)
+	rtt := time.Since(start)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	wasStale := m.stale
+	if err == nil {
+		m.missed = 0
+		m.stale = false
+		m.lastPing = result
+		m.lastRTT = rtt
+	} else {
+		m.missed++
+		m.stale = m.missed >= m.config.MaxMissed
+	}
+	stale, missed := m.stale, m.missed
+	m.mutex.Unlock()
+
+	if stale {
+		heartbeatStaleGauge.Update(1)
+	} else {
+		heartbeatStaleGauge.Update(0)
+	}
+	if !wasStale && stale {
+		log.Warn("consensus heartbeat stale", "missed", missed, "err", err)
+		if m.OnStale != nil {
+			m.OnStale(missed, err)
+		}
+	} else if wasStale && !stale {
+		log.Info("consensus heartbeat recovered", "msgCount", result.MessageCount, "rtt", rtt)
+		if m.OnRecovered != nil {
+			m.OnRecovered(result)
+		}
+	}
+	if stale && m.Reconnect != nil {
+		if err := m.Reconnect(ctx); err != nil {
+			log.Warn("reconnecting to consensus failed", "err", err)
+		}
+	}
+}
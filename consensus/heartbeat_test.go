@@ -0,0 +1,136 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type fakePinger struct {
+	// calls to methods other than Ping panic
+	execution.ConsensusInfo
+	mutex sync.Mutex
+	count int
+	err   error
+}
+
+func (p *fakePinger) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.err != nil {
+		return containers.NewReadyPromise(execution.PingResult{}, p.err)
+	}
+	p.count++
+	return containers.NewReadyPromise(execution.PingResult{MessageCount: 10, Time: time.Now()}, nil)
+}
+
+func (p *fakePinger) setErr(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.err = err
+}
+
+func TestHeartbeatMonitorStale(t *testing.T) {
+	ctx := context.Background()
+	pinger := &fakePinger{}
+	monitor, err := NewHeartbeatMonitor(&TestHeartbeatConfig, pinger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var staleCalls, recoveredCalls, reconnects int
+	var staleErr error
+	monitor.OnStale = func(missed int, err error) {
+		staleCalls++
+		staleErr = err
+		if missed != TestHeartbeatConfig.MaxMissed {
+			t.Error("stale after", missed, "missed pings, expected", TestHeartbeatConfig.MaxMissed)
+		}
+	}
+	monitor.OnRecovered = func(result execution.PingResult) {
+		recoveredCalls++
+	}
+	monitor.Reconnect = func(ctx context.Context) error {
+		reconnects++
+		return nil
+	}
+
+	if _, _, ok := monitor.LastPing(); ok {
+		t.Fatal("last ping reported before any ping")
+	}
+	monitor.heartbeat(ctx)
+	result, _, ok := monitor.LastPing()
+	if !ok || result.MessageCount != 10 || monitor.Stale() {
+		t.Fatal("unexpected state after a successful ping", result, ok, monitor.Stale())
+	}
+
+	errUnreachable := errors.New("unreachable")
+	pinger.setErr(errUnreachable)
+	for i := 1; i < TestHeartbeatConfig.MaxMissed; i++ {
+		monitor.heartbeat(ctx)
+	}
+	if monitor.Stale() || staleCalls != 0 || reconnects != 0 {
+		t.Fatal("stale before missing max-missed pings")
+	}
+	monitor.heartbeat(ctx)
+	monitor.heartbeat(ctx)
+	if !monitor.Stale() || staleCalls != 1 || !errors.Is(staleErr, errUnreachable) {
+		t.Fatal("stale hook not called exactly once with the ping error", staleCalls, staleErr)
+	}
+	if reconnects != 2 {
+		t.Fatal("expected a reconnect after every missed ping while stale, got", reconnects)
+	}
+
+	pinger.setErr(nil)
+	monitor.heartbeat(ctx)
+	monitor.heartbeat(ctx)
+	if monitor.Stale() || recoveredCalls != 1 || reconnects != 2 {
+		t.Fatal("recovered hook not called exactly once", recoveredCalls, reconnects)
+	}
+}
+
+func TestHeartbeatMonitorTimeout(t *testing.T) {
+	config := TestHeartbeatConfig
+	config.Timeout = 10 * time.Millisecond
+	config.MaxMissed = 1
+	monitor, err := NewHeartbeatMonitor(&config, &blockingPinger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var staleErr error
+	monitor.OnStale = func(missed int, err error) {
+		staleErr = err
+	}
+	monitor.heartbeat(context.Background())
+	if !monitor.Stale() || !errors.Is(staleErr, context.DeadlineExceeded) {
+		t.Fatal("a ping that timed out wasn't missed", staleErr)
+	}
+}
+
+type blockingPinger struct {
+	execution.ConsensusInfo
+}
+
+// Ping returns a promise that's never ready, as a consensus node that stopped responding would
+func (p *blockingPinger) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	promise := containers.NewPromise[execution.PingResult](nil)
+	return &promise
+}
+
+func TestHeartbeatConfigValidate(t *testing.T) {
+	config := DefaultHeartbeatConfig
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	config.MaxMissed = 0
+	if _, err := NewHeartbeatMonitor(&config, &fakePinger{}); err == nil {
+		t.Fatal("expected max-missed of 0 to be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// MessageFeeCompressionLevel is the brotli level messages are compressed at to estimate their fee.
+// It's below the level batches are compressed at, since the estimate is made for every sequencer
+// write, so the compressed size is slightly overstated.
+const MessageFeeCompressionLevel = 6
+
+// NewMessageFeeEstimate prices compressedBytes at the calldata gas of non-zero bytes, and splits the
+// fixed gas of a transaction evenly over batchMessages, the messages of a batch (at least one).
+func NewMessageFeeEstimate(uncompressedBytes, compressedBytes int, l1BaseFee *big.Int, batchMessages uint64) execution.MessageFeeEstimate {
+	if batchMessages == 0 {
+		batchMessages = 1
+	}
+	dataFee := arbmath.BigMulByUint(l1BaseFee, uint64(compressedBytes)*params.TxDataNonZeroGasEIP2028)
+	overhead := arbmath.BigDivByUint(arbmath.BigMulByUint(l1BaseFee, params.TxGas), batchMessages)
+	return execution.MessageFeeEstimate{
+		L1DataFee:         dataFee,
+		CompressedBytes:   compressedBytes,
+		UncompressedBytes: uncompressedBytes,
+		BatchAmortizedFee: arbmath.BigAdd(dataFee, overhead),
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNewMessageFeeEstimate(t *testing.T) {
+	estimate := NewMessageFeeEstimate(200, 100, big.NewInt(10), 5)
+	if estimate.UncompressedBytes != 200 || estimate.CompressedBytes != 100 {
+		t.Fatal("unexpected sizes", estimate)
+	}
+	// 100 bytes at 16 gas each, and a fifth of 21000 gas, at 10 wei per gas
+	if estimate.L1DataFee.Cmp(big.NewInt(16_000)) != 0 || estimate.BatchAmortizedFee.Cmp(big.NewInt(16_000+42_000)) != 0 {
+		t.Fatal("unexpected fees", estimate.L1DataFee, estimate.BatchAmortizedFee)
+	}
+
+	// Without a batch to split it over, a message bears the whole fixed cost
+	estimate = NewMessageFeeEstimate(0, 0, big.NewInt(10), 0)
+	if estimate.L1DataFee.Sign() != 0 || estimate.BatchAmortizedFee.Cmp(big.NewInt(210_000)) != 0 {
+		t.Fatal("unexpected fees without a batch", estimate.L1DataFee, estimate.BatchAmortizedFee)
+	}
+}
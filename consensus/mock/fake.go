@@ -0,0 +1,72 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package mock
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// FakeConsensusConfig configures NewFakeFullConsensusClient.
+type FakeConsensusConfig struct {
+	// InitialMessages is the number of messages the client starts with
+	InitialMessages int
+	// Latency delays every call, as a consensus node over RPC would
+	Latency     time.Duration
+	BatchPoster consensustest.FakeBatchPosterConfig
+}
+
+var DefaultFakeConsensusConfig = FakeConsensusConfig{
+	InitialMessages: 0,
+	Latency:         0,
+	BatchPoster:     consensustest.DefaultFakeBatchPosterConfig,
+}
+
+type fakeFullConsensusClient struct {
+	*consensustest.FakeConsensusClient
+	poster *consensustest.FakeBatchPoster
+}
+
+// NewFakeFullConsensusClient returns a consensustest.FakeConsensusClient that posts its messages as
+// batches every cfg.BatchPoster.Interval, as arriving from the parent chain blocks the config
+// schedules, until closed. The messages and batches only depend on cfg and the messages written,
+// so tests run the same without a parent chain.
+func NewFakeFullConsensusClient(cfg FakeConsensusConfig) execution.FullConsensusClient {
+	client := consensustest.NewFakeConsensusClient()
+	client.SetLatency(cfg.Latency)
+	for i := 0; i < cfg.InitialMessages; i++ {
+		client.AddMessages(fakeMessage(uint64(i)))
+	}
+	poster := consensustest.NewFakeBatchPoster(client, cfg.BatchPoster)
+	poster.Start(context.Background())
+	return &fakeFullConsensusClient{
+		FakeConsensusClient: client,
+		poster:              poster,
+	}
+}
+
+func fakeMessage(i uint64) arbostypes.MessageWithMetadata {
+	return arbostypes.MessageWithMetadata{
+		Message: &arbostypes.L1IncomingMessage{
+			Header: &arbostypes.L1IncomingMessageHeader{
+				Kind:        arbostypes.L1MessageType_L2Message,
+				BlockNumber: i,
+				Timestamp:   i,
+			},
+			L2msg: binary.BigEndian.AppendUint64(nil, i),
+		},
+		DelayedMessagesRead: 1,
+	}
+}
+
+// Close stops posting batches, then closes the client.
+func (c *fakeFullConsensusClient) Close() error {
+	c.poster.StopAndWait()
+	return c.FakeConsensusClient.Close()
+}
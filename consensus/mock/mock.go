@@ -0,0 +1,60 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package mock provides mocks of the consensus interfaces, for unit tests of the packages
+// consuming them, and NewFakeFullConsensusClient for tests needing a client that behaves like a
+// consensus node. The mocks are generated from execution/interface.go by running go generate.
+package mock
+
+//go:generate go run ./mockgen -source ../../execution/interface.go -source-package github.com/offchainlabs/nitro/execution -output mocks.go -interfaces BatchFetcher,ConsensusInfo,ConsensusSequencer,FullConsensusClient
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// ErrNotStubbed is returned by mock calls to methods without a stub.
+var ErrNotStubbed = errors.New("mock method not stubbed")
+
+// callCounter counts the calls to each method of a mock.
+type callCounter struct {
+	t      testing.TB
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (c *callCounter) called(method string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[method]++
+}
+
+func (c *callCounter) notStubbed(method string) error {
+	c.t.Errorf("unexpected call to %s, which has no stub", method)
+	return ErrNotStubbed
+}
+
+// Calls returns the number of calls to method.
+func (c *callCounter) Calls(method string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.counts[method]
+}
+
+// AssertCalls fails the test unless method was called exactly want times.
+func (c *callCounter) AssertCalls(method string, want int) {
+	c.t.Helper()
+	if got := c.Calls(method); got != want {
+		c.t.Errorf("expected %d calls to %s, got %d", want, method, got)
+	}
+}
+
+// AssertNotCalled fails the test if method was called.
+func (c *callCounter) AssertNotCalled(method string) {
+	c.t.Helper()
+	c.AssertCalls(method, 0)
+}
@@ -0,0 +1,116 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// recordingTB records the failures of a test instead of failing it
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestMockStubsAndCallCounts(t *testing.T) {
+	ctx := context.Background()
+	m := NewMockFullConsensusClient(t)
+	m.GetBatchCountFunc = func() containers.PromiseInterface[uint64] { return containers.NewReadyPromise[uint64](7, nil) }
+	m.GetBatchMessageRangeFunc = func(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+		return containers.NewReadyPromise(execution.MessageRange{Start: arbutil.MessageIndex(batchNum), End: arbutil.MessageIndex(batchNum + 1)}, nil)
+	}
+	var client execution.FullConsensusClient = m
+
+	for i := 0; i < 3; i++ {
+		count, err := client.GetBatchCount().Await(ctx)
+		if err != nil || count != 7 {
+			t.Fatal("unexpected stubbed batch count", count, err)
+		}
+	}
+	msgRange, err := client.GetBatchMessageRange(2).Await(ctx)
+	if err != nil || msgRange.Start != 2 || msgRange.End != 3 {
+		t.Fatal("unexpected stubbed message range", msgRange, err)
+	}
+	m.AssertCalls("GetBatchCount", 3)
+	m.AssertCalls("GetBatchMessageRange", 1)
+	m.AssertNotCalled("FetchBatch")
+}
+
+func TestMockUnstubbedCallFails(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	m := NewMockBatchFetcher(tb)
+	if _, err := m.FetchBatch(context.Background(), 0).Await(context.Background()); !errors.Is(err, ErrNotStubbed) {
+		t.Fatal("expected ErrNotStubbed, got", err)
+	}
+	if _, err := m.PrefetchBatches(0, 1).Await(context.Background()); !errors.Is(err, ErrNotStubbed) {
+		t.Fatal("expected ErrNotStubbed from the promise, got", err)
+	}
+	if len(tb.failures) != 2 {
+		t.Fatal("expected the unstubbed calls to fail the test, got", tb.failures)
+	}
+	m.AssertCalls("FetchBatch", 2)
+	if len(tb.failures) != 3 {
+		t.Fatal("expected the wrong call count to fail the test, got", tb.failures)
+	}
+}
+
+func TestFakeFullConsensusClientPostsBatches(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultFakeConsensusConfig
+	config.InitialMessages = 5
+	config.BatchPoster.MaxBatchMessages = 2
+	client := NewFakeFullConsensusClient(config)
+	defer client.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		count, err := client.GetBatchCount().Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("initial messages not batched, batch count", count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	lookup, err := client.FindInboxBatchContainingMessage(4).Await(ctx)
+	if err != nil || !lookup.Found || lookup.Batch != 2 {
+		t.Fatal("unexpected batch of the last message", lookup, err)
+	}
+	first, err := client.GetBatchParentChainBlock(0).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.GetBatchParentChainBlock(1).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second-first != config.BatchPoster.BlocksPerBatch {
+		t.Fatal("batches not posted", config.BatchPoster.BlocksPerBatch, "blocks apart, got", first, second)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Ping(ctx).Await(ctx); !errors.Is(err, execution.ErrClientClosed) {
+		t.Fatal("expected ErrClientClosed after closing, got", err)
+	}
+}
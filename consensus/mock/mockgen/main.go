@@ -0,0 +1,359 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// mockgen generates the mocks of the consensus/mock package from the interfaces declared in a
+// source file. It only parses the source, so it runs without building the source's package.
+// Interfaces embedded by the mocked ones must be declared in the same file.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type method struct {
+	name    string
+	params  []field
+	results []string
+	// promise is the type the result promises, if the only result is a containers.PromiseInterface
+	promise  string
+	variadic bool
+}
+
+type field struct {
+	name string
+	typ  string
+}
+
+type generator struct {
+	fset       *token.FileSet
+	pkgName    string
+	interfaces map[string]*ast.InterfaceType
+	// imports maps the names the source imports packages as to their paths
+	imports map[string]string
+	used    map[string]bool
+}
+
+func main() {
+	source := flag.String("source", "", "go file declaring the interfaces to mock")
+	sourcePkg := flag.String("source-package", "", "import path of the source's package")
+	output := flag.String("output", "", "file to write the mocks to")
+	pkg := flag.String("package", "mock", "package of the generated mocks")
+	names := flag.String("interfaces", "", "comma separated interfaces to mock")
+	flag.Parse()
+	if *source == "" || *sourcePkg == "" || *output == "" || *names == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	code, err := generate(*source, *sourcePkg, *pkg, strings.Split(*names, ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mockgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, code, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "mockgen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(source, sourcePkg, pkg string, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		fset:       fset,
+		pkgName:    file.Name.Name,
+		interfaces: make(map[string]*ast.InterfaceType),
+		imports:    map[string]string{file.Name.Name: sourcePkg},
+		used:       map[string]bool{file.Name.Name: true, "testing": true},
+	}
+	g.imports["testing"] = "testing"
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		g.imports[name] = importPath
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				g.interfaces[typeSpec.Name.Name] = iface
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	for _, name := range names {
+		methods, err := g.methods(name)
+		if err != nil {
+			return nil, err
+		}
+		g.writeMock(&body, name, methods)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by consensus/mock/mockgen from %s; DO NOT EDIT.\n\n", path.Base(source))
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	g.writeImports(&out)
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// methods returns the methods of the interface, including the ones of the interfaces it embeds
+func (g *generator) methods(name string) ([]method, error) {
+	iface, ok := g.interfaces[name]
+	if !ok {
+		return nil, fmt.Errorf("interface %s not declared in the source", name)
+	}
+	var methods []method
+	for _, elem := range iface.Methods.List {
+		switch typ := elem.Type.(type) {
+		case *ast.FuncType:
+			for _, methodName := range elem.Names {
+				methods = append(methods, g.method(methodName.Name, typ))
+			}
+		case *ast.Ident:
+			embedded, err := g.methods(typ.Name)
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, embedded...)
+		default:
+			return nil, fmt.Errorf("interface %s embeds unsupported %s", name, g.print(elem.Type))
+		}
+	}
+	return methods, nil
+}
+
+func (g *generator) method(name string, typ *ast.FuncType) method {
+	m := method{name: name}
+	for _, param := range typ.Params.List {
+		paramType := param.Type
+		if ellipsis, ok := paramType.(*ast.Ellipsis); ok {
+			m.variadic = true
+			paramType = ellipsis.Elt
+		}
+		typeString := g.typeString(paramType)
+		if m.variadic {
+			typeString = "..." + typeString
+		}
+		if len(param.Names) == 0 {
+			m.params = append(m.params, field{name: fmt.Sprintf("p%d", len(m.params)), typ: typeString})
+		}
+		for _, paramName := range param.Names {
+			m.params = append(m.params, field{name: paramName.Name, typ: typeString})
+		}
+	}
+	if typ.Results == nil {
+		return m
+	}
+	for _, result := range typ.Results.List {
+		typeString := g.typeString(result.Type)
+		for i := 0; i < len(result.Names) || (i == 0 && len(result.Names) == 0); i++ {
+			m.results = append(m.results, typeString)
+		}
+	}
+	if len(m.results) == 1 {
+		if index, ok := typ.Results.List[0].Type.(*ast.IndexExpr); ok {
+			if sel, ok := index.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "PromiseInterface" {
+				m.promise = g.typeString(index.Index)
+			}
+		}
+	}
+	return m
+}
+
+// typeString prints the type as the mock package refers to it, qualifying the source package's types
+func (g *generator) typeString(expr ast.Expr) string {
+	expr = g.qualify(expr)
+	return g.print(expr)
+}
+
+func (g *generator) qualify(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(e.Name) != nil {
+			return e
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(g.pkgName), Sel: ast.NewIdent(e.Name)}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			g.used[x.Name] = true
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.qualify(e.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: g.qualify(e.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: g.qualify(e.Key), Value: g.qualify(e.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: g.qualify(e.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.qualify(e.Elt)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.qualify(e.X), Index: g.qualify(e.Index)}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.qualifyFields(e.Params), Results: g.qualifyFields(e.Results)}
+	case *ast.InterfaceType:
+		if len(e.Methods.List) == 0 {
+			return e
+		}
+	case *ast.StructType:
+		if len(e.Fields.List) == 0 {
+			return e
+		}
+	}
+	panic(fmt.Sprintf("unsupported type %s", g.print(expr)))
+}
+
+func (g *generator) qualifyFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	qualified := &ast.FieldList{}
+	for _, f := range fields.List {
+		qualified.List = append(qualified.List, &ast.Field{Names: f.Names, Type: g.qualify(f.Type)})
+	}
+	return qualified
+}
+
+func (g *generator) print(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, g.fset, expr); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// writeImports groups the imports like the repo does: the standard library, then other modules,
+// then nitro
+func (g *generator) writeImports(out *bytes.Buffer) {
+	var std, other, nitro []string
+	for name := range g.used {
+		importPath := g.imports[name]
+		spec := strconv.Quote(importPath)
+		if path.Base(importPath) != name {
+			spec = name + " " + spec
+		}
+		switch {
+		case strings.HasPrefix(importPath, "github.com/offchainlabs/nitro/"):
+			nitro = append(nitro, spec)
+		case strings.Contains(importPath, "."):
+			other = append(other, spec)
+		default:
+			std = append(std, spec)
+		}
+	}
+	out.WriteString("import (\n")
+	for i, group := range [][]string{std, other, nitro} {
+		sort.Strings(group)
+		if i > 0 && len(group) > 0 {
+			out.WriteString("\n")
+		}
+		for _, spec := range group {
+			fmt.Fprintf(out, "\t%s\n", spec)
+		}
+	}
+	out.WriteString(")\n\n")
+}
+
+func (g *generator) writeMock(out *bytes.Buffer, name string, methods []method) {
+	mock := "Mock" + name
+	fmt.Fprintf(out, "// %s is a mock %s.%s. A call runs the stub set for its method, and a call\n", mock, g.pkgName, name)
+	fmt.Fprintf(out, "// without one fails the test and returns zero values and ErrNotStubbed.\n")
+	fmt.Fprintf(out, "type %s struct {\n\tcallCounter\n\n", mock)
+	for _, m := range methods {
+		fmt.Fprintf(out, "\t%sFunc func(%s) %s\n", m.name, m.paramList(), m.resultList())
+	}
+	out.WriteString("}\n\n")
+	fmt.Fprintf(out, "var _ %s.%s = (*%s)(nil)\n\n", g.pkgName, name, mock)
+	fmt.Fprintf(out, "// New%s returns a mock without stubs, failing t on unexpected calls.\n", mock)
+	fmt.Fprintf(out, "func New%s(t testing.TB) *%s {\n\treturn &%s{callCounter: callCounter{t: t}}\n}\n\n", mock, mock, mock)
+	for _, m := range methods {
+		fmt.Fprintf(out, "func (m *%s) %s(%s) %s {\n", mock, m.name, m.paramList(), m.resultList())
+		fmt.Fprintf(out, "\tm.called(%q)\n", m.name)
+		fmt.Fprintf(out, "\tif m.%sFunc != nil {\n\t\t", m.name)
+		if len(m.results) > 0 {
+			out.WriteString("return ")
+		}
+		fmt.Fprintf(out, "m.%sFunc(%s)\n", m.name, m.argList())
+		if len(m.results) == 0 {
+			out.WriteString("\t\treturn\n")
+		}
+		out.WriteString("\t}\n")
+		switch {
+		case m.promise != "":
+			fmt.Fprintf(out, "\tvar r0 %s\n\treturn containers.NewReadyPromise(r0, m.notStubbed(%q))\n", m.promise, m.name)
+		case len(m.results) > 0 && m.results[len(m.results)-1] == "error":
+			var returns []string
+			for i, result := range m.results[:len(m.results)-1] {
+				fmt.Fprintf(out, "\tvar r%d %s\n", i, result)
+				returns = append(returns, fmt.Sprintf("r%d", i))
+			}
+			returns = append(returns, fmt.Sprintf("m.notStubbed(%q)", m.name))
+			fmt.Fprintf(out, "\treturn %s\n", strings.Join(returns, ", "))
+		default:
+			fmt.Fprintf(out, "\t_ = m.notStubbed(%q)\n", m.name)
+			var returns []string
+			for i, result := range m.results {
+				fmt.Fprintf(out, "\tvar r%d %s\n", i, result)
+				returns = append(returns, fmt.Sprintf("r%d", i))
+			}
+			if len(returns) > 0 {
+				fmt.Fprintf(out, "\treturn %s\n", strings.Join(returns, ", "))
+			}
+		}
+		out.WriteString("}\n\n")
+	}
+}
+
+func (m *method) paramList() string {
+	var params []string
+	for _, p := range m.params {
+		params = append(params, p.name+" "+p.typ)
+	}
+	return strings.Join(params, ", ")
+}
+
+func (m *method) argList() string {
+	var args []string
+	for _, p := range m.params {
+		args = append(args, p.name)
+	}
+	if m.variadic {
+		args[len(args)-1] += "..."
+	}
+	return strings.Join(args, ", ")
+}
+
+func (m *method) resultList() string {
+	if len(m.results) <= 1 {
+		return strings.Join(m.results, "")
+	}
+	return "(" + strings.Join(m.results, ", ") + ")"
+}
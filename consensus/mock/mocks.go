@@ -0,0 +1,981 @@
+// Code generated by consensus/mock/mockgen from interface.go; DO NOT EDIT.
+
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// MockBatchFetcher is a mock execution.BatchFetcher. A call runs the stub set for its method, and a call
+// without one fails the test and returns zero values and ErrNotStubbed.
+type MockBatchFetcher struct {
+	callCounter
+
+	FetchBatchFunc                      func(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch]
+	FetchBatchChunkFunc                 func(ctx context.Context, batchNum uint64, offset uint64, length uint64) containers.PromiseInterface[[]byte]
+	GetBatchSizeFunc                    func(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64]
+	GetBatchCountFunc                   func() containers.PromiseInterface[uint64]
+	FindInboxBatchContainingMessageFunc func(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup]
+	GetBatchParentChainBlockFunc        func(seqNum uint64) containers.PromiseInterface[uint64]
+	GetBatchParentChainBlocksFunc       func(first uint64, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks]
+	GetBatchMessageRangeFunc            func(batchNum uint64) containers.PromiseInterface[execution.MessageRange]
+	GetMessageL1InfoFunc                func(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info]
+	FindBatchesInParentChainRangeFunc   func(firstBlock uint64, lastBlock uint64) containers.PromiseInterface[[]uint64]
+	PrefetchBatchesFunc                 func(first uint64, last uint64) containers.PromiseInterface[struct{}]
+}
+
+var _ execution.BatchFetcher = (*MockBatchFetcher)(nil)
+
+// NewMockBatchFetcher returns a mock without stubs, failing t on unexpected calls.
+func NewMockBatchFetcher(t testing.TB) *MockBatchFetcher {
+	return &MockBatchFetcher{callCounter: callCounter{t: t}}
+}
+
+func (m *MockBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	m.called("FetchBatch")
+	if m.FetchBatchFunc != nil {
+		return m.FetchBatchFunc(ctx, batchNum)
+	}
+	var r0 execution.FetchedBatch
+	return containers.NewReadyPromise(r0, m.notStubbed("FetchBatch"))
+}
+
+func (m *MockBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset uint64, length uint64) containers.PromiseInterface[[]byte] {
+	m.called("FetchBatchChunk")
+	if m.FetchBatchChunkFunc != nil {
+		return m.FetchBatchChunkFunc(ctx, batchNum, offset, length)
+	}
+	var r0 []byte
+	return containers.NewReadyPromise(r0, m.notStubbed("FetchBatchChunk"))
+}
+
+func (m *MockBatchFetcher) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	m.called("GetBatchSize")
+	if m.GetBatchSizeFunc != nil {
+		return m.GetBatchSizeFunc(ctx, batchNum)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchSize"))
+}
+
+func (m *MockBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	m.called("GetBatchCount")
+	if m.GetBatchCountFunc != nil {
+		return m.GetBatchCountFunc()
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchCount"))
+}
+
+func (m *MockBatchFetcher) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	m.called("FindInboxBatchContainingMessage")
+	if m.FindInboxBatchContainingMessageFunc != nil {
+		return m.FindInboxBatchContainingMessageFunc(message)
+	}
+	var r0 execution.BatchLookup
+	return containers.NewReadyPromise(r0, m.notStubbed("FindInboxBatchContainingMessage"))
+}
+
+func (m *MockBatchFetcher) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	m.called("GetBatchParentChainBlock")
+	if m.GetBatchParentChainBlockFunc != nil {
+		return m.GetBatchParentChainBlockFunc(seqNum)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchParentChainBlock"))
+}
+
+func (m *MockBatchFetcher) GetBatchParentChainBlocks(first uint64, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	m.called("GetBatchParentChainBlocks")
+	if m.GetBatchParentChainBlocksFunc != nil {
+		return m.GetBatchParentChainBlocksFunc(first, last)
+	}
+	var r0 execution.BatchParentChainBlocks
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchParentChainBlocks"))
+}
+
+func (m *MockBatchFetcher) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	m.called("GetBatchMessageRange")
+	if m.GetBatchMessageRangeFunc != nil {
+		return m.GetBatchMessageRangeFunc(batchNum)
+	}
+	var r0 execution.MessageRange
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchMessageRange"))
+}
+
+func (m *MockBatchFetcher) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	m.called("GetMessageL1Info")
+	if m.GetMessageL1InfoFunc != nil {
+		return m.GetMessageL1InfoFunc(ctx, pos)
+	}
+	var r0 execution.L1Info
+	return containers.NewReadyPromise(r0, m.notStubbed("GetMessageL1Info"))
+}
+
+func (m *MockBatchFetcher) FindBatchesInParentChainRange(firstBlock uint64, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	m.called("FindBatchesInParentChainRange")
+	if m.FindBatchesInParentChainRangeFunc != nil {
+		return m.FindBatchesInParentChainRangeFunc(firstBlock, lastBlock)
+	}
+	var r0 []uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("FindBatchesInParentChainRange"))
+}
+
+func (m *MockBatchFetcher) PrefetchBatches(first uint64, last uint64) containers.PromiseInterface[struct{}] {
+	m.called("PrefetchBatches")
+	if m.PrefetchBatchesFunc != nil {
+		return m.PrefetchBatchesFunc(first, last)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("PrefetchBatches"))
+}
+
+// MockConsensusInfo is a mock execution.ConsensusInfo. A call runs the stub set for its method, and a call
+// without one fails the test and returns zero values and ErrNotStubbed.
+type MockConsensusInfo struct {
+	callCounter
+
+	CapabilitiesFunc                 func() containers.PromiseInterface[execution.CapabilitySet]
+	SyncedFunc                       func() containers.PromiseInterface[bool]
+	HealthyFunc                      func() containers.PromiseInterface[execution.HealthStatus]
+	PingFunc                         func(ctx context.Context) containers.PromiseInterface[execution.PingResult]
+	SyncProgressSnapshotFunc         func(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot]
+	FullSyncProgressMapFunc          func() containers.PromiseInterface[map[string]interface{}]
+	SyncTargetMessageCountFunc       func() containers.PromiseInterface[execution.SyncTarget]
+	CatchUpEstimateFunc              func() containers.PromiseInterface[execution.CatchUpEstimate]
+	GetBatchCompressionStatsFunc     func() containers.PromiseInterface[execution.BatchCompressionStats]
+	GetBatchPostingLagFunc           func() containers.PromiseInterface[execution.PostingLag]
+	GetSyncModeFunc                  func() containers.PromiseInterface[execution.SyncMode]
+	GetChainSpecFunc                 func(ctx context.Context) containers.PromiseInterface[execution.ChainSpec]
+	MessageIndexToBlockNumberFunc    func(pos arbutil.MessageIndex) containers.PromiseInterface[uint64]
+	BlockNumberToMessageIndexFunc    func(block uint64) containers.PromiseInterface[arbutil.MessageIndex]
+	GetMessageAccHashFunc            func(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash]
+	GetCheckpointInfoFunc            func(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo]
+	VerifyExecutionCheckpointFunc    func(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}]
+	GetBatchPostingReportFunc        func(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo]
+	GetBatchPostingReportsFunc       func(first uint64, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo]
+	FindBatchesContainingKindFunc    func(first uint64, last uint64, kind uint8) containers.PromiseInterface[[]uint64]
+	GetSafeMsgCountFunc              func(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo]
+	GetFinalizedMsgCountFunc         func(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo]
+	GetSafeMsgCountWithHashFunc      func(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash]
+	GetFinalizedMsgCountWithHashFunc func(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash]
+	ValidatedMessageCountFunc        func() containers.PromiseInterface[arbutil.MessageIndex]
+	SetLagThresholdFunc              func(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}]
+	ClearLagThresholdFunc            func() containers.PromiseInterface[struct{}]
+}
+
+var _ execution.ConsensusInfo = (*MockConsensusInfo)(nil)
+
+// NewMockConsensusInfo returns a mock without stubs, failing t on unexpected calls.
+func NewMockConsensusInfo(t testing.TB) *MockConsensusInfo {
+	return &MockConsensusInfo{callCounter: callCounter{t: t}}
+}
+
+func (m *MockConsensusInfo) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	m.called("Capabilities")
+	if m.CapabilitiesFunc != nil {
+		return m.CapabilitiesFunc()
+	}
+	var r0 execution.CapabilitySet
+	return containers.NewReadyPromise(r0, m.notStubbed("Capabilities"))
+}
+
+func (m *MockConsensusInfo) Synced() containers.PromiseInterface[bool] {
+	m.called("Synced")
+	if m.SyncedFunc != nil {
+		return m.SyncedFunc()
+	}
+	var r0 bool
+	return containers.NewReadyPromise(r0, m.notStubbed("Synced"))
+}
+
+func (m *MockConsensusInfo) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	m.called("Healthy")
+	if m.HealthyFunc != nil {
+		return m.HealthyFunc()
+	}
+	var r0 execution.HealthStatus
+	return containers.NewReadyPromise(r0, m.notStubbed("Healthy"))
+}
+
+func (m *MockConsensusInfo) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	m.called("Ping")
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx)
+	}
+	var r0 execution.PingResult
+	return containers.NewReadyPromise(r0, m.notStubbed("Ping"))
+}
+
+func (m *MockConsensusInfo) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	m.called("SyncProgressSnapshot")
+	if m.SyncProgressSnapshotFunc != nil {
+		return m.SyncProgressSnapshotFunc(ctx)
+	}
+	var r0 execution.SyncProgressSnapshot
+	return containers.NewReadyPromise(r0, m.notStubbed("SyncProgressSnapshot"))
+}
+
+func (m *MockConsensusInfo) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	m.called("FullSyncProgressMap")
+	if m.FullSyncProgressMapFunc != nil {
+		return m.FullSyncProgressMapFunc()
+	}
+	var r0 map[string]interface{}
+	return containers.NewReadyPromise(r0, m.notStubbed("FullSyncProgressMap"))
+}
+
+func (m *MockConsensusInfo) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	m.called("SyncTargetMessageCount")
+	if m.SyncTargetMessageCountFunc != nil {
+		return m.SyncTargetMessageCountFunc()
+	}
+	var r0 execution.SyncTarget
+	return containers.NewReadyPromise(r0, m.notStubbed("SyncTargetMessageCount"))
+}
+
+func (m *MockConsensusInfo) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	m.called("CatchUpEstimate")
+	if m.CatchUpEstimateFunc != nil {
+		return m.CatchUpEstimateFunc()
+	}
+	var r0 execution.CatchUpEstimate
+	return containers.NewReadyPromise(r0, m.notStubbed("CatchUpEstimate"))
+}
+
+func (m *MockConsensusInfo) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	m.called("GetBatchCompressionStats")
+	if m.GetBatchCompressionStatsFunc != nil {
+		return m.GetBatchCompressionStatsFunc()
+	}
+	var r0 execution.BatchCompressionStats
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchCompressionStats"))
+}
+
+func (m *MockConsensusInfo) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	m.called("GetBatchPostingLag")
+	if m.GetBatchPostingLagFunc != nil {
+		return m.GetBatchPostingLagFunc()
+	}
+	var r0 execution.PostingLag
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingLag"))
+}
+
+func (m *MockConsensusInfo) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	m.called("GetSyncMode")
+	if m.GetSyncModeFunc != nil {
+		return m.GetSyncModeFunc()
+	}
+	var r0 execution.SyncMode
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSyncMode"))
+}
+
+func (m *MockConsensusInfo) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	m.called("GetChainSpec")
+	if m.GetChainSpecFunc != nil {
+		return m.GetChainSpecFunc(ctx)
+	}
+	var r0 execution.ChainSpec
+	return containers.NewReadyPromise(r0, m.notStubbed("GetChainSpec"))
+}
+
+func (m *MockConsensusInfo) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	m.called("MessageIndexToBlockNumber")
+	if m.MessageIndexToBlockNumberFunc != nil {
+		return m.MessageIndexToBlockNumberFunc(pos)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("MessageIndexToBlockNumber"))
+}
+
+func (m *MockConsensusInfo) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	m.called("BlockNumberToMessageIndex")
+	if m.BlockNumberToMessageIndexFunc != nil {
+		return m.BlockNumberToMessageIndexFunc(block)
+	}
+	var r0 arbutil.MessageIndex
+	return containers.NewReadyPromise(r0, m.notStubbed("BlockNumberToMessageIndex"))
+}
+
+func (m *MockConsensusInfo) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	m.called("GetMessageAccHash")
+	if m.GetMessageAccHashFunc != nil {
+		return m.GetMessageAccHashFunc(pos)
+	}
+	var r0 common.Hash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetMessageAccHash"))
+}
+
+func (m *MockConsensusInfo) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	m.called("GetCheckpointInfo")
+	if m.GetCheckpointInfoFunc != nil {
+		return m.GetCheckpointInfoFunc(ctx)
+	}
+	var r0 execution.CheckpointInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetCheckpointInfo"))
+}
+
+func (m *MockConsensusInfo) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	m.called("VerifyExecutionCheckpoint")
+	if m.VerifyExecutionCheckpointFunc != nil {
+		return m.VerifyExecutionCheckpointFunc(pos, blockHash)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("VerifyExecutionCheckpoint"))
+}
+
+func (m *MockConsensusInfo) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	m.called("GetBatchPostingReport")
+	if m.GetBatchPostingReportFunc != nil {
+		return m.GetBatchPostingReportFunc(seqNum)
+	}
+	var r0 execution.PostingReportInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingReport"))
+}
+
+func (m *MockConsensusInfo) GetBatchPostingReports(first uint64, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	m.called("GetBatchPostingReports")
+	if m.GetBatchPostingReportsFunc != nil {
+		return m.GetBatchPostingReportsFunc(first, last)
+	}
+	var r0 []execution.PostingReportInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingReports"))
+}
+
+func (m *MockConsensusInfo) FindBatchesContainingKind(first uint64, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	m.called("FindBatchesContainingKind")
+	if m.FindBatchesContainingKindFunc != nil {
+		return m.FindBatchesContainingKindFunc(first, last, kind)
+	}
+	var r0 []uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("FindBatchesContainingKind"))
+}
+
+func (m *MockConsensusInfo) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	m.called("GetSafeMsgCount")
+	if m.GetSafeMsgCountFunc != nil {
+		return m.GetSafeMsgCountFunc(ctx)
+	}
+	var r0 execution.SafeMsgInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSafeMsgCount"))
+}
+
+func (m *MockConsensusInfo) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	m.called("GetFinalizedMsgCount")
+	if m.GetFinalizedMsgCountFunc != nil {
+		return m.GetFinalizedMsgCountFunc(ctx)
+	}
+	var r0 execution.FinalizedMsgInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetFinalizedMsgCount"))
+}
+
+func (m *MockConsensusInfo) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	m.called("GetSafeMsgCountWithHash")
+	if m.GetSafeMsgCountWithHashFunc != nil {
+		return m.GetSafeMsgCountWithHashFunc(ctx)
+	}
+	var r0 execution.MsgCountWithHash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSafeMsgCountWithHash"))
+}
+
+func (m *MockConsensusInfo) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	m.called("GetFinalizedMsgCountWithHash")
+	if m.GetFinalizedMsgCountWithHashFunc != nil {
+		return m.GetFinalizedMsgCountWithHashFunc(ctx)
+	}
+	var r0 execution.MsgCountWithHash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetFinalizedMsgCountWithHash"))
+}
+
+func (m *MockConsensusInfo) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	m.called("ValidatedMessageCount")
+	if m.ValidatedMessageCountFunc != nil {
+		return m.ValidatedMessageCountFunc()
+	}
+	var r0 arbutil.MessageIndex
+	return containers.NewReadyPromise(r0, m.notStubbed("ValidatedMessageCount"))
+}
+
+func (m *MockConsensusInfo) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	m.called("SetLagThreshold")
+	if m.SetLagThresholdFunc != nil {
+		return m.SetLagThresholdFunc(severity, messages, onExceed, onRecovery)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("SetLagThreshold"))
+}
+
+func (m *MockConsensusInfo) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	m.called("ClearLagThreshold")
+	if m.ClearLagThresholdFunc != nil {
+		return m.ClearLagThresholdFunc()
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("ClearLagThreshold"))
+}
+
+// MockConsensusSequencer is a mock execution.ConsensusSequencer. A call runs the stub set for its method, and a call
+// without one fails the test and returns zero values and ErrNotStubbed.
+type MockConsensusSequencer struct {
+	callCounter
+
+	WriteMessageFromSequencerFunc             func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}]
+	WriteMessageFromSequencerWithDeadlineFunc func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time]
+	WriteMessageFromSequencerIdempotentFunc   func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}]
+	ExpectChosenSequencerFunc                 func() containers.PromiseInterface[struct{}]
+	SequencerWriteBacklogFunc                 func() containers.PromiseInterface[execution.BacklogStatus]
+	DrainSequencerQueueFunc                   func(ctx context.Context) containers.PromiseInterface[execution.DrainResult]
+	ComputeMessageL1FeeFunc                   func(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate]
+}
+
+var _ execution.ConsensusSequencer = (*MockConsensusSequencer)(nil)
+
+// NewMockConsensusSequencer returns a mock without stubs, failing t on unexpected calls.
+func NewMockConsensusSequencer(t testing.TB) *MockConsensusSequencer {
+	return &MockConsensusSequencer{callCounter: callCounter{t: t}}
+}
+
+func (m *MockConsensusSequencer) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	m.called("WriteMessageFromSequencer")
+	if m.WriteMessageFromSequencerFunc != nil {
+		return m.WriteMessageFromSequencerFunc(pos, msgWithMeta, msgResult)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencer"))
+}
+
+func (m *MockConsensusSequencer) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	m.called("WriteMessageFromSequencerWithDeadline")
+	if m.WriteMessageFromSequencerWithDeadlineFunc != nil {
+		return m.WriteMessageFromSequencerWithDeadlineFunc(pos, msgWithMeta, msgResult, deadline)
+	}
+	var r0 time.Time
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencerWithDeadline"))
+}
+
+func (m *MockConsensusSequencer) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	m.called("WriteMessageFromSequencerIdempotent")
+	if m.WriteMessageFromSequencerIdempotentFunc != nil {
+		return m.WriteMessageFromSequencerIdempotentFunc(pos, msgWithMeta, msgResult, key)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencerIdempotent"))
+}
+
+func (m *MockConsensusSequencer) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	m.called("ExpectChosenSequencer")
+	if m.ExpectChosenSequencerFunc != nil {
+		return m.ExpectChosenSequencerFunc()
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("ExpectChosenSequencer"))
+}
+
+func (m *MockConsensusSequencer) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	m.called("SequencerWriteBacklog")
+	if m.SequencerWriteBacklogFunc != nil {
+		return m.SequencerWriteBacklogFunc()
+	}
+	var r0 execution.BacklogStatus
+	return containers.NewReadyPromise(r0, m.notStubbed("SequencerWriteBacklog"))
+}
+
+func (m *MockConsensusSequencer) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	m.called("DrainSequencerQueue")
+	if m.DrainSequencerQueueFunc != nil {
+		return m.DrainSequencerQueueFunc(ctx)
+	}
+	var r0 execution.DrainResult
+	return containers.NewReadyPromise(r0, m.notStubbed("DrainSequencerQueue"))
+}
+
+func (m *MockConsensusSequencer) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	m.called("ComputeMessageL1Fee")
+	if m.ComputeMessageL1FeeFunc != nil {
+		return m.ComputeMessageL1FeeFunc(msgWithMeta)
+	}
+	var r0 execution.MessageFeeEstimate
+	return containers.NewReadyPromise(r0, m.notStubbed("ComputeMessageL1Fee"))
+}
+
+// MockFullConsensusClient is a mock execution.FullConsensusClient. A call runs the stub set for its method, and a call
+// without one fails the test and returns zero values and ErrNotStubbed.
+type MockFullConsensusClient struct {
+	callCounter
+
+	FetchBatchFunc                            func(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch]
+	FetchBatchChunkFunc                       func(ctx context.Context, batchNum uint64, offset uint64, length uint64) containers.PromiseInterface[[]byte]
+	GetBatchSizeFunc                          func(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64]
+	GetBatchCountFunc                         func() containers.PromiseInterface[uint64]
+	FindInboxBatchContainingMessageFunc       func(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup]
+	GetBatchParentChainBlockFunc              func(seqNum uint64) containers.PromiseInterface[uint64]
+	GetBatchParentChainBlocksFunc             func(first uint64, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks]
+	GetBatchMessageRangeFunc                  func(batchNum uint64) containers.PromiseInterface[execution.MessageRange]
+	GetMessageL1InfoFunc                      func(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info]
+	FindBatchesInParentChainRangeFunc         func(firstBlock uint64, lastBlock uint64) containers.PromiseInterface[[]uint64]
+	PrefetchBatchesFunc                       func(first uint64, last uint64) containers.PromiseInterface[struct{}]
+	CapabilitiesFunc                          func() containers.PromiseInterface[execution.CapabilitySet]
+	SyncedFunc                                func() containers.PromiseInterface[bool]
+	HealthyFunc                               func() containers.PromiseInterface[execution.HealthStatus]
+	PingFunc                                  func(ctx context.Context) containers.PromiseInterface[execution.PingResult]
+	SyncProgressSnapshotFunc                  func(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot]
+	FullSyncProgressMapFunc                   func() containers.PromiseInterface[map[string]interface{}]
+	SyncTargetMessageCountFunc                func() containers.PromiseInterface[execution.SyncTarget]
+	CatchUpEstimateFunc                       func() containers.PromiseInterface[execution.CatchUpEstimate]
+	GetBatchCompressionStatsFunc              func() containers.PromiseInterface[execution.BatchCompressionStats]
+	GetBatchPostingLagFunc                    func() containers.PromiseInterface[execution.PostingLag]
+	GetSyncModeFunc                           func() containers.PromiseInterface[execution.SyncMode]
+	GetChainSpecFunc                          func(ctx context.Context) containers.PromiseInterface[execution.ChainSpec]
+	MessageIndexToBlockNumberFunc             func(pos arbutil.MessageIndex) containers.PromiseInterface[uint64]
+	BlockNumberToMessageIndexFunc             func(block uint64) containers.PromiseInterface[arbutil.MessageIndex]
+	GetMessageAccHashFunc                     func(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash]
+	GetCheckpointInfoFunc                     func(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo]
+	VerifyExecutionCheckpointFunc             func(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}]
+	GetBatchPostingReportFunc                 func(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo]
+	GetBatchPostingReportsFunc                func(first uint64, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo]
+	FindBatchesContainingKindFunc             func(first uint64, last uint64, kind uint8) containers.PromiseInterface[[]uint64]
+	GetSafeMsgCountFunc                       func(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo]
+	GetFinalizedMsgCountFunc                  func(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo]
+	GetSafeMsgCountWithHashFunc               func(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash]
+	GetFinalizedMsgCountWithHashFunc          func(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash]
+	ValidatedMessageCountFunc                 func() containers.PromiseInterface[arbutil.MessageIndex]
+	SetLagThresholdFunc                       func(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}]
+	ClearLagThresholdFunc                     func() containers.PromiseInterface[struct{}]
+	WriteMessageFromSequencerFunc             func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}]
+	WriteMessageFromSequencerWithDeadlineFunc func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time]
+	WriteMessageFromSequencerIdempotentFunc   func(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}]
+	ExpectChosenSequencerFunc                 func() containers.PromiseInterface[struct{}]
+	SequencerWriteBacklogFunc                 func() containers.PromiseInterface[execution.BacklogStatus]
+	DrainSequencerQueueFunc                   func(ctx context.Context) containers.PromiseInterface[execution.DrainResult]
+	ComputeMessageL1FeeFunc                   func(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate]
+	CloseFunc                                 func() error
+}
+
+var _ execution.FullConsensusClient = (*MockFullConsensusClient)(nil)
+
+// NewMockFullConsensusClient returns a mock without stubs, failing t on unexpected calls.
+func NewMockFullConsensusClient(t testing.TB) *MockFullConsensusClient {
+	return &MockFullConsensusClient{callCounter: callCounter{t: t}}
+}
+
+func (m *MockFullConsensusClient) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	m.called("FetchBatch")
+	if m.FetchBatchFunc != nil {
+		return m.FetchBatchFunc(ctx, batchNum)
+	}
+	var r0 execution.FetchedBatch
+	return containers.NewReadyPromise(r0, m.notStubbed("FetchBatch"))
+}
+
+func (m *MockFullConsensusClient) FetchBatchChunk(ctx context.Context, batchNum uint64, offset uint64, length uint64) containers.PromiseInterface[[]byte] {
+	m.called("FetchBatchChunk")
+	if m.FetchBatchChunkFunc != nil {
+		return m.FetchBatchChunkFunc(ctx, batchNum, offset, length)
+	}
+	var r0 []byte
+	return containers.NewReadyPromise(r0, m.notStubbed("FetchBatchChunk"))
+}
+
+func (m *MockFullConsensusClient) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	m.called("GetBatchSize")
+	if m.GetBatchSizeFunc != nil {
+		return m.GetBatchSizeFunc(ctx, batchNum)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchSize"))
+}
+
+func (m *MockFullConsensusClient) GetBatchCount() containers.PromiseInterface[uint64] {
+	m.called("GetBatchCount")
+	if m.GetBatchCountFunc != nil {
+		return m.GetBatchCountFunc()
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchCount"))
+}
+
+func (m *MockFullConsensusClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	m.called("FindInboxBatchContainingMessage")
+	if m.FindInboxBatchContainingMessageFunc != nil {
+		return m.FindInboxBatchContainingMessageFunc(message)
+	}
+	var r0 execution.BatchLookup
+	return containers.NewReadyPromise(r0, m.notStubbed("FindInboxBatchContainingMessage"))
+}
+
+func (m *MockFullConsensusClient) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	m.called("GetBatchParentChainBlock")
+	if m.GetBatchParentChainBlockFunc != nil {
+		return m.GetBatchParentChainBlockFunc(seqNum)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchParentChainBlock"))
+}
+
+func (m *MockFullConsensusClient) GetBatchParentChainBlocks(first uint64, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	m.called("GetBatchParentChainBlocks")
+	if m.GetBatchParentChainBlocksFunc != nil {
+		return m.GetBatchParentChainBlocksFunc(first, last)
+	}
+	var r0 execution.BatchParentChainBlocks
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchParentChainBlocks"))
+}
+
+func (m *MockFullConsensusClient) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	m.called("GetBatchMessageRange")
+	if m.GetBatchMessageRangeFunc != nil {
+		return m.GetBatchMessageRangeFunc(batchNum)
+	}
+	var r0 execution.MessageRange
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchMessageRange"))
+}
+
+func (m *MockFullConsensusClient) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	m.called("GetMessageL1Info")
+	if m.GetMessageL1InfoFunc != nil {
+		return m.GetMessageL1InfoFunc(ctx, pos)
+	}
+	var r0 execution.L1Info
+	return containers.NewReadyPromise(r0, m.notStubbed("GetMessageL1Info"))
+}
+
+func (m *MockFullConsensusClient) FindBatchesInParentChainRange(firstBlock uint64, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	m.called("FindBatchesInParentChainRange")
+	if m.FindBatchesInParentChainRangeFunc != nil {
+		return m.FindBatchesInParentChainRangeFunc(firstBlock, lastBlock)
+	}
+	var r0 []uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("FindBatchesInParentChainRange"))
+}
+
+func (m *MockFullConsensusClient) PrefetchBatches(first uint64, last uint64) containers.PromiseInterface[struct{}] {
+	m.called("PrefetchBatches")
+	if m.PrefetchBatchesFunc != nil {
+		return m.PrefetchBatchesFunc(first, last)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("PrefetchBatches"))
+}
+
+func (m *MockFullConsensusClient) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	m.called("Capabilities")
+	if m.CapabilitiesFunc != nil {
+		return m.CapabilitiesFunc()
+	}
+	var r0 execution.CapabilitySet
+	return containers.NewReadyPromise(r0, m.notStubbed("Capabilities"))
+}
+
+func (m *MockFullConsensusClient) Synced() containers.PromiseInterface[bool] {
+	m.called("Synced")
+	if m.SyncedFunc != nil {
+		return m.SyncedFunc()
+	}
+	var r0 bool
+	return containers.NewReadyPromise(r0, m.notStubbed("Synced"))
+}
+
+func (m *MockFullConsensusClient) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	m.called("Healthy")
+	if m.HealthyFunc != nil {
+		return m.HealthyFunc()
+	}
+	var r0 execution.HealthStatus
+	return containers.NewReadyPromise(r0, m.notStubbed("Healthy"))
+}
+
+func (m *MockFullConsensusClient) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	m.called("Ping")
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx)
+	}
+	var r0 execution.PingResult
+	return containers.NewReadyPromise(r0, m.notStubbed("Ping"))
+}
+
+func (m *MockFullConsensusClient) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	m.called("SyncProgressSnapshot")
+	if m.SyncProgressSnapshotFunc != nil {
+		return m.SyncProgressSnapshotFunc(ctx)
+	}
+	var r0 execution.SyncProgressSnapshot
+	return containers.NewReadyPromise(r0, m.notStubbed("SyncProgressSnapshot"))
+}
+
+func (m *MockFullConsensusClient) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	m.called("FullSyncProgressMap")
+	if m.FullSyncProgressMapFunc != nil {
+		return m.FullSyncProgressMapFunc()
+	}
+	var r0 map[string]interface{}
+	return containers.NewReadyPromise(r0, m.notStubbed("FullSyncProgressMap"))
+}
+
+func (m *MockFullConsensusClient) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	m.called("SyncTargetMessageCount")
+	if m.SyncTargetMessageCountFunc != nil {
+		return m.SyncTargetMessageCountFunc()
+	}
+	var r0 execution.SyncTarget
+	return containers.NewReadyPromise(r0, m.notStubbed("SyncTargetMessageCount"))
+}
+
+func (m *MockFullConsensusClient) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	m.called("CatchUpEstimate")
+	if m.CatchUpEstimateFunc != nil {
+		return m.CatchUpEstimateFunc()
+	}
+	var r0 execution.CatchUpEstimate
+	return containers.NewReadyPromise(r0, m.notStubbed("CatchUpEstimate"))
+}
+
+func (m *MockFullConsensusClient) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	m.called("GetBatchCompressionStats")
+	if m.GetBatchCompressionStatsFunc != nil {
+		return m.GetBatchCompressionStatsFunc()
+	}
+	var r0 execution.BatchCompressionStats
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchCompressionStats"))
+}
+
+func (m *MockFullConsensusClient) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	m.called("GetBatchPostingLag")
+	if m.GetBatchPostingLagFunc != nil {
+		return m.GetBatchPostingLagFunc()
+	}
+	var r0 execution.PostingLag
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingLag"))
+}
+
+func (m *MockFullConsensusClient) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	m.called("GetSyncMode")
+	if m.GetSyncModeFunc != nil {
+		return m.GetSyncModeFunc()
+	}
+	var r0 execution.SyncMode
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSyncMode"))
+}
+
+func (m *MockFullConsensusClient) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	m.called("GetChainSpec")
+	if m.GetChainSpecFunc != nil {
+		return m.GetChainSpecFunc(ctx)
+	}
+	var r0 execution.ChainSpec
+	return containers.NewReadyPromise(r0, m.notStubbed("GetChainSpec"))
+}
+
+func (m *MockFullConsensusClient) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	m.called("MessageIndexToBlockNumber")
+	if m.MessageIndexToBlockNumberFunc != nil {
+		return m.MessageIndexToBlockNumberFunc(pos)
+	}
+	var r0 uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("MessageIndexToBlockNumber"))
+}
+
+func (m *MockFullConsensusClient) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	m.called("BlockNumberToMessageIndex")
+	if m.BlockNumberToMessageIndexFunc != nil {
+		return m.BlockNumberToMessageIndexFunc(block)
+	}
+	var r0 arbutil.MessageIndex
+	return containers.NewReadyPromise(r0, m.notStubbed("BlockNumberToMessageIndex"))
+}
+
+func (m *MockFullConsensusClient) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	m.called("GetMessageAccHash")
+	if m.GetMessageAccHashFunc != nil {
+		return m.GetMessageAccHashFunc(pos)
+	}
+	var r0 common.Hash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetMessageAccHash"))
+}
+
+func (m *MockFullConsensusClient) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	m.called("GetCheckpointInfo")
+	if m.GetCheckpointInfoFunc != nil {
+		return m.GetCheckpointInfoFunc(ctx)
+	}
+	var r0 execution.CheckpointInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetCheckpointInfo"))
+}
+
+func (m *MockFullConsensusClient) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	m.called("VerifyExecutionCheckpoint")
+	if m.VerifyExecutionCheckpointFunc != nil {
+		return m.VerifyExecutionCheckpointFunc(pos, blockHash)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("VerifyExecutionCheckpoint"))
+}
+
+func (m *MockFullConsensusClient) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	m.called("GetBatchPostingReport")
+	if m.GetBatchPostingReportFunc != nil {
+		return m.GetBatchPostingReportFunc(seqNum)
+	}
+	var r0 execution.PostingReportInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingReport"))
+}
+
+func (m *MockFullConsensusClient) GetBatchPostingReports(first uint64, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	m.called("GetBatchPostingReports")
+	if m.GetBatchPostingReportsFunc != nil {
+		return m.GetBatchPostingReportsFunc(first, last)
+	}
+	var r0 []execution.PostingReportInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetBatchPostingReports"))
+}
+
+func (m *MockFullConsensusClient) FindBatchesContainingKind(first uint64, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	m.called("FindBatchesContainingKind")
+	if m.FindBatchesContainingKindFunc != nil {
+		return m.FindBatchesContainingKindFunc(first, last, kind)
+	}
+	var r0 []uint64
+	return containers.NewReadyPromise(r0, m.notStubbed("FindBatchesContainingKind"))
+}
+
+func (m *MockFullConsensusClient) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	m.called("GetSafeMsgCount")
+	if m.GetSafeMsgCountFunc != nil {
+		return m.GetSafeMsgCountFunc(ctx)
+	}
+	var r0 execution.SafeMsgInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSafeMsgCount"))
+}
+
+func (m *MockFullConsensusClient) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	m.called("GetFinalizedMsgCount")
+	if m.GetFinalizedMsgCountFunc != nil {
+		return m.GetFinalizedMsgCountFunc(ctx)
+	}
+	var r0 execution.FinalizedMsgInfo
+	return containers.NewReadyPromise(r0, m.notStubbed("GetFinalizedMsgCount"))
+}
+
+func (m *MockFullConsensusClient) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	m.called("GetSafeMsgCountWithHash")
+	if m.GetSafeMsgCountWithHashFunc != nil {
+		return m.GetSafeMsgCountWithHashFunc(ctx)
+	}
+	var r0 execution.MsgCountWithHash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetSafeMsgCountWithHash"))
+}
+
+func (m *MockFullConsensusClient) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	m.called("GetFinalizedMsgCountWithHash")
+	if m.GetFinalizedMsgCountWithHashFunc != nil {
+		return m.GetFinalizedMsgCountWithHashFunc(ctx)
+	}
+	var r0 execution.MsgCountWithHash
+	return containers.NewReadyPromise(r0, m.notStubbed("GetFinalizedMsgCountWithHash"))
+}
+
+func (m *MockFullConsensusClient) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	m.called("ValidatedMessageCount")
+	if m.ValidatedMessageCountFunc != nil {
+		return m.ValidatedMessageCountFunc()
+	}
+	var r0 arbutil.MessageIndex
+	return containers.NewReadyPromise(r0, m.notStubbed("ValidatedMessageCount"))
+}
+
+func (m *MockFullConsensusClient) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	m.called("SetLagThreshold")
+	if m.SetLagThresholdFunc != nil {
+		return m.SetLagThresholdFunc(severity, messages, onExceed, onRecovery)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("SetLagThreshold"))
+}
+
+func (m *MockFullConsensusClient) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	m.called("ClearLagThreshold")
+	if m.ClearLagThresholdFunc != nil {
+		return m.ClearLagThresholdFunc()
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("ClearLagThreshold"))
+}
+
+func (m *MockFullConsensusClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	m.called("WriteMessageFromSequencer")
+	if m.WriteMessageFromSequencerFunc != nil {
+		return m.WriteMessageFromSequencerFunc(pos, msgWithMeta, msgResult)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencer"))
+}
+
+func (m *MockFullConsensusClient) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	m.called("WriteMessageFromSequencerWithDeadline")
+	if m.WriteMessageFromSequencerWithDeadlineFunc != nil {
+		return m.WriteMessageFromSequencerWithDeadlineFunc(pos, msgWithMeta, msgResult, deadline)
+	}
+	var r0 time.Time
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencerWithDeadline"))
+}
+
+func (m *MockFullConsensusClient) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	m.called("WriteMessageFromSequencerIdempotent")
+	if m.WriteMessageFromSequencerIdempotentFunc != nil {
+		return m.WriteMessageFromSequencerIdempotentFunc(pos, msgWithMeta, msgResult, key)
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("WriteMessageFromSequencerIdempotent"))
+}
+
+func (m *MockFullConsensusClient) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	m.called("ExpectChosenSequencer")
+	if m.ExpectChosenSequencerFunc != nil {
+		return m.ExpectChosenSequencerFunc()
+	}
+	var r0 struct{}
+	return containers.NewReadyPromise(r0, m.notStubbed("ExpectChosenSequencer"))
+}
+
+func (m *MockFullConsensusClient) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	m.called("SequencerWriteBacklog")
+	if m.SequencerWriteBacklogFunc != nil {
+		return m.SequencerWriteBacklogFunc()
+	}
+	var r0 execution.BacklogStatus
+	return containers.NewReadyPromise(r0, m.notStubbed("SequencerWriteBacklog"))
+}
+
+func (m *MockFullConsensusClient) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	m.called("DrainSequencerQueue")
+	if m.DrainSequencerQueueFunc != nil {
+		return m.DrainSequencerQueueFunc(ctx)
+	}
+	var r0 execution.DrainResult
+	return containers.NewReadyPromise(r0, m.notStubbed("DrainSequencerQueue"))
+}
+
+func (m *MockFullConsensusClient) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	m.called("ComputeMessageL1Fee")
+	if m.ComputeMessageL1FeeFunc != nil {
+		return m.ComputeMessageL1FeeFunc(msgWithMeta)
+	}
+	var r0 execution.MessageFeeEstimate
+	return containers.NewReadyPromise(r0, m.notStubbed("ComputeMessageL1Fee"))
+}
+
+func (m *MockFullConsensusClient) Close() error {
+	m.called("Close")
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	return m.notStubbed("Close")
+}
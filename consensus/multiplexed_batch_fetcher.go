@@ -0,0 +1,371 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var ErrUnknownChain = errors.New("no batch fetcher registered for chain")
+
+type chainFetcher struct {
+	chainID string
+	fetcher execution.BatchFetcher
+}
+
+// MultiplexedBatchFetcher serves deployments posting batches to more than one parent chain.
+// Calls for a batch go to the fetcher of the chain batchNumToChain assigns it to, and fail with
+// ErrUnknownChain if that chain isn't registered. Calls for a message or parent chain block range
+// are fanned out to every registered chain. Chains can be added and removed while calls are in flight.
+type MultiplexedBatchFetcher struct {
+	batchNumToChain func(batchNum uint64) string
+
+	mutex    sync.RWMutex
+	fetchers map[string]execution.BatchFetcher
+}
+
+var _ execution.BatchFetcher = (*MultiplexedBatchFetcher)(nil)
+
+func NewMultiplexedBatchFetcher(batchNumToChain func(batchNum uint64) string) *MultiplexedBatchFetcher {
+	return &MultiplexedBatchFetcher{
+		batchNumToChain: batchNumToChain,
+		fetchers:        make(map[string]execution.BatchFetcher),
+	}
+}
+
+func (m *MultiplexedBatchFetcher) AddChain(chainID string, fetcher execution.BatchFetcher) error {
+	if fetcher == nil {
+		return fmt.Errorf("nil batch fetcher for chain %q", chainID)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.fetchers[chainID]; ok {
+		return fmt.Errorf("batch fetcher for chain %q already registered", chainID)
+	}
+	m.fetchers[chainID] = fetcher
+	return nil
+}
+
+// RemoveChain unregisters the chain. Calls already routed to its fetcher are unaffected.
+func (m *MultiplexedBatchFetcher) RemoveChain(chainID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.fetchers[chainID]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownChain, chainID)
+	}
+	delete(m.fetchers, chainID)
+	return nil
+}
+
+func (m *MultiplexedBatchFetcher) route(batchNum uint64) (execution.BatchFetcher, error) {
+	chainID := m.batchNumToChain(batchNum)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	fetcher, ok := m.fetchers[chainID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, routed to by batch %d", ErrUnknownChain, chainID, batchNum)
+	}
+	return fetcher, nil
+}
+
+// chains returns the registered chains, ordered by chain ID
+func (m *MultiplexedBatchFetcher) chains() ([]chainFetcher, error) {
+	m.mutex.RLock()
+	chains := make([]chainFetcher, 0, len(m.fetchers))
+	for chainID, fetcher := range m.fetchers {
+		chains = append(chains, chainFetcher{chainID: chainID, fetcher: fetcher})
+	}
+	m.mutex.RUnlock()
+	if len(chains) == 0 {
+		return nil, errors.New("no chains registered with the multiplexed batch fetcher")
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].chainID < chains[j].chainID })
+	return chains, nil
+}
+
+type chainResult[T any] struct {
+	chainID string
+	result  T
+	err     error
+}
+
+// callChains calls call for every chain in parallel, and sends each result to the returned channel
+// once it's ready, until ctx is done. The returned function cancels the calls still running.
+func callChains[T any](ctx context.Context, chains []chainFetcher, call func(context.Context, execution.BatchFetcher) containers.PromiseInterface[T]) (<-chan chainResult[T], func()) {
+	// Buffered, so the forwarding goroutines never block
+	results := make(chan chainResult[T], len(chains))
+	promises := make([]containers.PromiseInterface[T], 0, len(chains))
+	for _, chain := range chains {
+		promise := call(ctx, chain.fetcher)
+		promises = append(promises, promise)
+		go func(chainID string, promise containers.PromiseInterface[T]) {
+			select {
+			case <-promise.ReadyChan():
+				result, err := promise.Current()
+				results <- chainResult[T]{chainID: chainID, result: result, err: err}
+			case <-ctx.Done():
+			}
+		}(chain.chainID, promise)
+	}
+	return results, func() {
+		for _, promise := range promises {
+			promise.Cancel()
+		}
+	}
+}
+
+// fanOut calls call for every registered chain in parallel, and resolves with the first result that
+// settles the call, cancelling the calls of the other chains. If no result settles it, fanOut waits
+// for every chain and resolves with the last result without an error, or the errors of all chains.
+// check, if not nil, turns a chain's result into an error if the chain shouldn't have returned it.
+// Cancelling the returned promise, or ctx, cancels the calls still running.
+func fanOut[T any](ctx context.Context, m *MultiplexedBatchFetcher, call func(context.Context, execution.BatchFetcher) containers.PromiseInterface[T], check func(chainID string, result T) error, settles func(T) bool) containers.PromiseInterface[T] {
+	var zero T
+	chains, err := m.chains()
+	if err != nil {
+		return containers.NewReadyPromise(zero, err)
+	}
+	return launchPromise(ctx, func(ctx context.Context) (T, error) {
+		results, cancelCalls := callChains(ctx, chains, call)
+		defer cancelCalls()
+		var errs []error
+		unsettled := zero
+		succeeded := false
+		for range chains {
+			var res chainResult[T]
+			select {
+			case res = <-results:
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+			if res.err == nil && check != nil {
+				res.err = check(res.chainID, res.result)
+			}
+			if res.err != nil {
+				errs = append(errs, fmt.Errorf("chain %q: %w", res.chainID, res.err))
+				continue
+			}
+			if settles(res.result) {
+				return res.result, nil
+			}
+			unsettled = res.result
+			succeeded = true
+		}
+		if succeeded {
+			return unsettled, nil
+		}
+		return zero, errors.Join(errs...)
+	})
+}
+
+// allChains calls call for every registered chain in parallel, and resolves with their results,
+// in the order of the chains. The first chain failing fails the promise, cancelling the other calls.
+// Cancelling the returned promise cancels the calls still running.
+func allChains[T any](m *MultiplexedBatchFetcher, call func(execution.BatchFetcher) containers.PromiseInterface[T]) containers.PromiseInterface[[]T] {
+	chains, err := m.chains()
+	if err != nil {
+		return containers.NewReadyPromise[[]T](nil, err)
+	}
+	return launchPromise(context.Background(), func(ctx context.Context) ([]T, error) {
+		results, cancelCalls := callChains(ctx, chains, func(_ context.Context, fetcher execution.BatchFetcher) containers.PromiseInterface[T] {
+			return call(fetcher)
+		})
+		defer cancelCalls()
+		byChain := make(map[string]T, len(chains))
+		for range chains {
+			select {
+			case res := <-results:
+				if res.err != nil {
+					return nil, fmt.Errorf("chain %q: %w", res.chainID, res.err)
+				}
+				byChain[res.chainID] = res.result
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		ordered := make([]T, 0, len(chains))
+		for _, chain := range chains {
+			ordered = append(ordered, byChain[chain.chainID])
+		}
+		return ordered, nil
+	})
+}
+
+func (m *MultiplexedBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	fetcher, err := m.route(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+	}
+	return fetcher.FetchBatch(ctx, batchNum)
+}
+
+func (m *MultiplexedBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	fetcher, err := m.route(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise[[]byte](nil, err)
+	}
+	return fetcher.FetchBatchChunk(ctx, batchNum, offset, length)
+}
+
+func (m *MultiplexedBatchFetcher) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	fetcher, err := m.route(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return fetcher.GetBatchSize(ctx, batchNum)
+}
+
+func (m *MultiplexedBatchFetcher) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	fetcher, err := m.route(seqNum)
+	if err != nil {
+		return containers.NewReadyPromise[uint64](0, err)
+	}
+	return fetcher.GetBatchParentChainBlock(seqNum)
+}
+
+func (m *MultiplexedBatchFetcher) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	fetcher, err := m.route(batchNum)
+	if err != nil {
+		return containers.NewReadyPromise(execution.MessageRange{}, err)
+	}
+	return fetcher.GetBatchMessageRange(batchNum)
+}
+
+// GetBatchCount returns the highest batch count of any chain, as batch numbers are shared by all chains.
+// The chains are asked in parallel.
+func (m *MultiplexedBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	counts := allChains(m, func(fetcher execution.BatchFetcher) containers.PromiseInterface[uint64] {
+		return fetcher.GetBatchCount()
+	})
+	return containers.Map(context.Background(), counts, func(counts []uint64) (uint64, error) {
+		var highest uint64
+		for _, count := range counts {
+			if count > highest {
+				highest = count
+			}
+		}
+		return highest, nil
+	})
+}
+
+// FindInboxBatchContainingMessage asks every chain in parallel, returning the first batch found.
+// A batch found on a chain it isn't routed to is an error of that chain, as the batch's other
+// calls would be served by another chain.
+func (m *MultiplexedBatchFetcher) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	return fanOut(context.Background(), m, func(_ context.Context, fetcher execution.BatchFetcher) containers.PromiseInterface[execution.BatchLookup] {
+		return fetcher.FindInboxBatchContainingMessage(message)
+	}, func(chainID string, lookup execution.BatchLookup) error {
+		if !lookup.Found {
+			return nil
+		}
+		return m.checkRoutedTo(chainID, lookup.Batch, message)
+	}, func(lookup execution.BatchLookup) bool { return lookup.Found })
+}
+
+// checkRoutedTo fails if the batch a chain found the message in isn't routed to that chain
+func (m *MultiplexedBatchFetcher) checkRoutedTo(chainID string, batchNum uint64, message arbutil.MessageIndex) error {
+	if routed := m.batchNumToChain(batchNum); routed != chainID {
+		return fmt.Errorf("message %d found in batch %d, which is routed to chain %q", message, batchNum, routed)
+	}
+	return nil
+}
+
+// GetMessageL1Info asks every chain in parallel, returning the first that posted the message
+// in a batch routed to it. The message is pending if no chain posted it yet.
+func (m *MultiplexedBatchFetcher) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	return fanOut(ctx, m, func(ctx context.Context, fetcher execution.BatchFetcher) containers.PromiseInterface[execution.L1Info] {
+		return fetcher.GetMessageL1Info(ctx, pos)
+	}, func(chainID string, info execution.L1Info) error {
+		if info.Pending {
+			return nil
+		}
+		return m.checkRoutedTo(chainID, info.BatchNum, pos)
+	}, func(info execution.L1Info) bool { return !info.Pending })
+}
+
+// FindBatchesInParentChainRange returns the batches of every chain posted within the block range
+// of that chain, in order.
+func (m *MultiplexedBatchFetcher) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	found := allChains(m, func(fetcher execution.BatchFetcher) containers.PromiseInterface[[]uint64] {
+		return fetcher.FindBatchesInParentChainRange(firstBlock, lastBlock)
+	})
+	return containers.Map(context.Background(), found, func(found [][]uint64) ([]uint64, error) {
+		batches := []uint64{}
+		for _, chainBatches := range found {
+			batches = append(batches, chainBatches...)
+		}
+		sort.Slice(batches, func(i, j int) bool { return batches[i] < batches[j] })
+		return batches, nil
+	})
+}
+
+// GetBatchParentChainBlocks splits the range into runs of consecutive batches routed to the same
+// chain, like PrefetchBatches, and combines the blocks each chain returns for its run.
+func (m *MultiplexedBatchFetcher) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	if err := checkBatchRange(first, last); err != nil {
+		return containers.NewReadyPromise(execution.BatchParentChainBlocks{}, err)
+	}
+	var runs []containers.PromiseInterface[execution.BatchParentChainBlocks]
+	runStart := first
+	for batchNum := first; batchNum <= last; batchNum++ {
+		if batchNum < last && m.batchNumToChain(batchNum+1) == m.batchNumToChain(batchNum) {
+			continue
+		}
+		fetcher, err := m.route(batchNum)
+		if err != nil {
+			return containers.NewReadyPromise(execution.BatchParentChainBlocks{}, err)
+		}
+		runs = append(runs, fetcher.GetBatchParentChainBlocks(runStart, batchNum))
+		runStart = batchNum + 1
+		if batchNum == last {
+			// Avoids overflowing when last is the highest batch number
+			break
+		}
+	}
+	return containers.Map(context.Background(), containers.All(context.Background(), runs...), func(results []execution.BatchParentChainBlocks) (execution.BatchParentChainBlocks, error) {
+		blocks := execution.BatchParentChainBlocks{First: first}
+		for _, result := range results {
+			blocks.Blocks = append(blocks.Blocks, result.Blocks...)
+			blocks.Found = append(blocks.Found, result.Found...)
+		}
+		return blocks, nil
+	})
+}
+
+// PrefetchBatches splits the range into runs of consecutive batches routed to the same chain,
+// and prefetches each run from its chain. Like GetBatchParentChainBlocks, the range must be at
+// most MaxBatchParentChainBlocksRange batches, as every batch of it is routed.
+func (m *MultiplexedBatchFetcher) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	if err := checkBatchRange(first, last); err != nil {
+		return containers.NewReadyPromise(struct{}{}, err)
+	}
+	var prefetches []containers.PromiseInterface[struct{}]
+	runStart := first
+	for batchNum := first; batchNum <= last; batchNum++ {
+		if batchNum < last && m.batchNumToChain(batchNum+1) == m.batchNumToChain(batchNum) {
+			continue
+		}
+		fetcher, err := m.route(batchNum)
+		if err != nil {
+			return containers.NewReadyPromise(struct{}{}, err)
+		}
+		prefetches = append(prefetches, fetcher.PrefetchBatches(runStart, batchNum))
+		runStart = batchNum + 1
+		if batchNum == last {
+			// Avoids overflowing when last is the highest batch number
+			break
+		}
+	}
+	return containers.Map(context.Background(), containers.All(context.Background(), prefetches...), func([]struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	})
+}
@@ -0,0 +1,204 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+func newChainFake(t *testing.T, chainID string, batches int) *consensustest.FakeConsensusClient {
+	fake := consensustest.NewFakeConsensusClient()
+	for i := 0; i < batches; i++ {
+		batch := consensustest.FakeBatch{Data: []byte(fmt.Sprint(chainID, i)), ParentChainBlock: uint64(10 * (i + 1)), MessageCount: arbutil.MessageIndex(i + 1)}
+		if err := fake.AddBatches(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fake
+}
+
+func TestMultiplexedBatchFetcher(t *testing.T) {
+	ctx := context.Background()
+	// Batches 0 and 1 are posted to chain a, the later ones to chain b
+	multiplexer := consensus.NewMultiplexedBatchFetcher(func(batchNum uint64) string {
+		if batchNum < 2 {
+			return "a"
+		}
+		return "b"
+	})
+	if _, err := multiplexer.GetBatchCount().Await(ctx); err == nil {
+		t.Fatal("batch count returned without chains")
+	}
+	chainA := newChainFake(t, "a", 2)
+	if err := multiplexer.AddChain("a", chainA); err != nil {
+		t.Fatal(err)
+	}
+	if err := multiplexer.AddChain("b", newChainFake(t, "b", 4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := multiplexer.AddChain("a", chainA); err == nil {
+		t.Fatal("chain registered twice")
+	}
+
+	for batchNum, expected := range []string{"a0", "a1", "b2", "b3"} {
+		batch, err := multiplexer.FetchBatch(ctx, uint64(batchNum)).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(batch.Data) != expected {
+			t.Fatal("batch routed to the wrong chain", batchNum, string(batch.Data))
+		}
+	}
+	if count, err := multiplexer.GetBatchCount().Await(ctx); err != nil || count != 4 {
+		t.Fatal("unexpected batch count", count, err)
+	}
+
+	// Only chain b has a batch with the third message
+	lookup, err := multiplexer.FindInboxBatchContainingMessage(2).Await(ctx)
+	if err != nil || !lookup.Found || lookup.Batch != 2 {
+		t.Fatal("unexpected batch containing message", lookup, err)
+	}
+	if lookup, err := multiplexer.FindInboxBatchContainingMessage(10).Await(ctx); err != nil || lookup.Found {
+		t.Fatal("unexpected batch for a message not yet posted", lookup, err)
+	}
+	info, err := multiplexer.GetMessageL1Info(ctx, 3).Await(ctx)
+	if err != nil || info.Pending || info.BatchNum != 3 {
+		t.Fatal("unexpected message L1 info", info, err)
+	}
+
+	batches, err := multiplexer.FindBatchesInParentChainRange(10, 20).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(batches) != "[0 0 1 1]" {
+		t.Fatal("unexpected batches in parent chain range", batches)
+	}
+	if _, err := multiplexer.PrefetchBatches(0, 3).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := multiplexer.RemoveChain("b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := multiplexer.FetchBatch(ctx, 2).Await(ctx); !errors.Is(err, consensus.ErrUnknownChain) {
+		t.Fatal("expected unknown chain, got", err)
+	}
+	if _, err := multiplexer.PrefetchBatches(0, 3).Await(ctx); !errors.Is(err, consensus.ErrUnknownChain) {
+		t.Fatal("expected unknown chain for prefetch, got", err)
+	}
+	if _, err := multiplexer.FetchBatch(ctx, 1).Await(ctx); err != nil {
+		t.Fatal("remaining chain unavailable", err)
+	}
+	if err := multiplexer.RemoveChain("b"); !errors.Is(err, consensus.ErrUnknownChain) {
+		t.Fatal("expected unknown chain removing it again, got", err)
+	}
+
+	// A chain failing doesn't hide a result found on another one
+	chainB := newChainFake(t, "b", 4)
+	chainB.FailNextCalls(1, errors.New("parent chain down"))
+	if err := multiplexer.AddChain("b", chainB); err != nil {
+		t.Fatal(err)
+	}
+	if lookup, err := multiplexer.FindInboxBatchContainingMessage(0).Await(ctx); err != nil || !lookup.Found || lookup.Batch != 0 {
+		t.Fatal("result of the healthy chain not returned", lookup, err)
+	}
+
+	// Chain b also has a batch 0, but batch 0 is routed to chain a
+	if err := multiplexer.RemoveChain("a"); err != nil {
+		t.Fatal(err)
+	}
+	if lookup, err := multiplexer.FindInboxBatchContainingMessage(0).Await(ctx); err == nil {
+		t.Fatal("batch found on a chain it isn't routed to", lookup)
+	}
+	if info, err := multiplexer.GetMessageL1Info(ctx, 0).Await(ctx); err == nil {
+		t.Fatal("message L1 info of a batch on a chain it isn't routed to", info)
+	}
+	if lookup, err := multiplexer.FindInboxBatchContainingMessage(2).Await(ctx); err != nil || !lookup.Found || lookup.Batch != 2 {
+		t.Fatal("unexpected batch routed to the chain it was found on", lookup, err)
+	}
+}
+
+// hungChain never answers batch counts and message lookups, counting the calls cancelled
+type hungChain struct {
+	*consensustest.FakeConsensusClient
+	cancelled atomic.Int64
+}
+
+func (h *hungChain) GetBatchCount() containers.PromiseInterface[uint64] {
+	promise := containers.NewPromise[uint64](func() { h.cancelled.Add(1) })
+	return &promise
+}
+
+func (h *hungChain) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	promise := containers.NewPromise[execution.BatchLookup](func() { h.cancelled.Add(1) })
+	return &promise
+}
+
+func TestMultiplexedBatchFetcherHungChain(t *testing.T) {
+	multiplexer := consensus.NewMultiplexedBatchFetcher(func(batchNum uint64) string {
+		if batchNum < 2 {
+			return "a"
+		}
+		return "b"
+	})
+	hung := &hungChain{FakeConsensusClient: newChainFake(t, "b", 0)}
+	if err := multiplexer.AddChain("a", newChainFake(t, "a", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := multiplexer.AddChain("b", hung); err != nil {
+		t.Fatal(err)
+	}
+
+	// A settling result doesn't wait for the hung chain, and cancels its call
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lookup, err := multiplexer.FindInboxBatchContainingMessage(1).Await(ctx)
+	if err != nil || !lookup.Found || lookup.Batch != 1 {
+		t.Fatal("unexpected batch containing message", lookup, err)
+	}
+	if hung.cancelled.Load() == 0 {
+		t.Fatal("call of the hung chain not cancelled once the lookup settled")
+	}
+
+	// The caller can give up on the hung chain, which cancels its call
+	before := hung.cancelled.Load()
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shortCancel()
+	if _, err := multiplexer.GetBatchCount().Await(shortCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected the batch count to time out, got", err)
+	}
+	for hung.cancelled.Load() == before {
+		if ctx.Err() != nil {
+			t.Fatal("call of the hung chain not cancelled after giving up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMultiplexedBatchFetcherPrefetchRange(t *testing.T) {
+	ctx := context.Background()
+	multiplexer := consensus.NewMultiplexedBatchFetcher(func(uint64) string { return "a" })
+	if err := multiplexer.AddChain("a", newChainFake(t, "a", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := multiplexer.PrefetchBatches(0, 1).Await(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, bounds := range [][2]uint64{{1, 0}, {0, execution.MaxBatchParentChainBlocksRange}} {
+		if _, err := multiplexer.PrefetchBatches(bounds[0], bounds[1]).Await(ctx); err == nil {
+			t.Fatal("expected an error for the prefetch range", bounds)
+		}
+	}
+}
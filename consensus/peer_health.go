@@ -0,0 +1,180 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type PeerStatus struct {
+	IsHealthy         bool
+	SafeMsgCount      arbutil.MessageIndex
+	FinalizedMsgCount arbutil.MessageIndex
+	Lag               arbutil.MessageIndex
+	LastContact       time.Time
+}
+
+// PeerHealthChecker queries the health of other consensus nodes, e.g. a hot standby.
+type PeerHealthChecker interface {
+	QueryPeer(ctx context.Context, peerAddr string) (PeerStatus, error)
+	ListPeers() []string
+}
+
+type PeerHealthMonitorConfig struct {
+	Interval     time.Duration `koanf:"interval"`
+	QueryTimeout time.Duration `koanf:"query-timeout"`
+}
+
+var DefaultPeerHealthMonitorConfig = PeerHealthMonitorConfig{
+	Interval:     10 * time.Second,
+	QueryTimeout: 5 * time.Second,
+}
+
+var TestPeerHealthMonitorConfig = PeerHealthMonitorConfig{
+	Interval:     10 * time.Millisecond,
+	QueryTimeout: time.Second,
+}
+
+func PeerHealthMonitorConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".interval", DefaultPeerHealthMonitorConfig.Interval, "how often to query the health of all peers")
+	f.Duration(prefix+".query-timeout", DefaultPeerHealthMonitorConfig.QueryTimeout, "timeout for a single peer health query")
+}
+
+func (c *PeerHealthMonitorConfig) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("peer health monitor interval must be positive")
+	}
+	if c.QueryTimeout <= 0 {
+		return errors.New("peer health monitor query-timeout must be positive")
+	}
+	return nil
+}
+
+type peerState struct {
+	status  PeerStatus
+	healthy bool
+}
+
+// PeerHealthMonitor periodically queries all peers listed by a PeerHealthChecker.
+// OnPeerUnhealthy is called when a peer becomes unhealthy (or its query fails), with the
+// query error if any, and OnPeerRecovered when it's healthy again. Peers start out healthy.
+// Hooks are called from the monitor's goroutine, one at a time.
+type PeerHealthMonitor struct {
+	stopwaiter.StopWaiter
+	config  *PeerHealthMonitorConfig
+	checker PeerHealthChecker
+
+	OnPeerUnhealthy func(peerAddr string, status PeerStatus, err error)
+	OnPeerRecovered func(peerAddr string, status PeerStatus)
+
+	mutex sync.Mutex
+	peers map[string]*peerState
+}
+
+func NewPeerHealthMonitor(config *PeerHealthMonitorConfig, checker PeerHealthChecker) (*PeerHealthMonitor, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &PeerHealthMonitor{
+		config:  config,
+		checker: checker,
+		peers:   make(map[string]*peerState),
+	}, nil
+}
+
+func (m *PeerHealthMonitor) Start(ctxIn context.Context) {
+	m.StopWaiter.Start(ctxIn, m)
+	m.CallIteratively(func(ctx context.Context) time.Duration {
+		m.checkPeers(ctx)
+		return m.config.Interval
+	})
+}
+
+// PeerStatus returns the last status queried for the peer, and false if it wasn't queried yet.
+func (m *PeerHealthMonitor) PeerStatus(peerAddr string) (PeerStatus, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	state, ok := m.peers[peerAddr]
+	if !ok {
+		return PeerStatus{}, false
+	}
+	return state.status, true
+}
+
+type peerQueryResult struct {
+	peerAddr string
+	status   PeerStatus
+	err      error
+}
+
+func (m *PeerHealthMonitor) checkPeers(ctx context.Context) {
+	peerAddrs := m.checker.ListPeers()
+	results := make([]peerQueryResult, len(peerAddrs))
+	var wg sync.WaitGroup
+	for i, peerAddr := range peerAddrs {
+		wg.Add(1)
+		go func(i int, peerAddr string) {
+			defer wg.Done()
+			queryCtx, cancel := context.WithTimeout(ctx, m.config.QueryTimeout)
+			defer cancel()
+			status, err := m.checker.QueryPeer(queryCtx, peerAddr)
+			results[i] = peerQueryResult{peerAddr: peerAddr, status: status, err: err}
+		}(i, peerAddr)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	listed := make(map[string]struct{}, len(peerAddrs))
+	for _, result := range results {
+		listed[result.peerAddr] = struct{}{}
+		m.updatePeer(result)
+	}
+	m.mutex.Lock()
+	for peerAddr := range m.peers {
+		if _, ok := listed[peerAddr]; !ok {
+			delete(m.peers, peerAddr)
+		}
+	}
+	m.mutex.Unlock()
+}
+
+func (m *PeerHealthMonitor) updatePeer(result peerQueryResult) {
+	healthy := result.err == nil && result.status.IsHealthy
+	m.mutex.Lock()
+	state, ok := m.peers[result.peerAddr]
+	if !ok {
+		state = &peerState{healthy: true}
+		m.peers[result.peerAddr] = state
+	}
+	if result.err == nil {
+		state.status = result.status
+	}
+	wasHealthy := state.healthy
+	state.healthy = healthy
+	status := state.status
+	m.mutex.Unlock()
+
+	if wasHealthy && !healthy {
+		log.Warn("consensus peer unhealthy", "peer", result.peerAddr, "lag", status.Lag, "err", result.err)
+		if m.OnPeerUnhealthy != nil {
+			m.OnPeerUnhealthy(result.peerAddr, status, result.err)
+		}
+	} else if !wasHealthy && healthy {
+		log.Info("consensus peer recovered", "peer", result.peerAddr, "lag", status.Lag)
+		if m.OnPeerRecovered != nil {
+			m.OnPeerRecovered(result.peerAddr, status)
+		}
+	}
+}
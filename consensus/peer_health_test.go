@@ -0,0 +1,88 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type fakePeerChecker struct {
+	mutex    sync.Mutex
+	statuses map[string]PeerStatus
+	errs     map[string]error
+}
+
+func (c *fakePeerChecker) QueryPeer(ctx context.Context, peerAddr string) (PeerStatus, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.statuses[peerAddr], c.errs[peerAddr]
+}
+
+func (c *fakePeerChecker) ListPeers() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var peers []string
+	for peer := range c.statuses {
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+func (c *fakePeerChecker) set(peerAddr string, status PeerStatus, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.statuses[peerAddr] = status
+	c.errs[peerAddr] = err
+}
+
+func TestPeerHealthMonitorHooks(t *testing.T) {
+	ctx := context.Background()
+	checker := &fakePeerChecker{statuses: make(map[string]PeerStatus), errs: make(map[string]error)}
+	checker.set("primary", PeerStatus{IsHealthy: true}, nil)
+	checker.set("standby", PeerStatus{IsHealthy: true}, nil)
+	monitor, err := NewPeerHealthMonitor(&TestPeerHealthMonitorConfig, checker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unhealthy := make(map[string]int)
+	recovered := make(map[string]int)
+	unhealthyErrs := make(map[string]error)
+	monitor.OnPeerUnhealthy = func(peerAddr string, status PeerStatus, err error) {
+		unhealthy[peerAddr]++
+		unhealthyErrs[peerAddr] = err
+	}
+	monitor.OnPeerRecovered = func(peerAddr string, status PeerStatus) {
+		recovered[peerAddr]++
+	}
+
+	monitor.checkPeers(ctx)
+	if len(unhealthy) != 0 || len(recovered) != 0 {
+		t.Fatal("hooks called for healthy peers", unhealthy, recovered)
+	}
+
+	errUnreachable := errors.New("unreachable")
+	checker.set("primary", PeerStatus{IsHealthy: false, Lag: 100}, nil)
+	checker.set("standby", PeerStatus{}, errUnreachable)
+	monitor.checkPeers(ctx)
+	monitor.checkPeers(ctx)
+	if unhealthy["primary"] != 1 || unhealthy["standby"] != 1 {
+		t.Fatal("unhealthy hook not called exactly once per peer", unhealthy)
+	}
+	if unhealthyErrs["primary"] != nil || !errors.Is(unhealthyErrs["standby"], errUnreachable) {
+		t.Fatal("unexpected errors passed to unhealthy hook", unhealthyErrs)
+	}
+	status, ok := monitor.PeerStatus("primary")
+	if !ok || status.Lag != 100 {
+		t.Fatal("unexpected status for unhealthy peer", status)
+	}
+
+	checker.set("primary", PeerStatus{IsHealthy: true}, nil)
+	monitor.checkPeers(ctx)
+	if recovered["primary"] != 1 || recovered["standby"] != 0 {
+		t.Fatal("unexpected recovered hook calls", recovered)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type PoolConfig struct {
+	Size                int           `koanf:"size"`
+	MaxErrorRate        float64       `koanf:"max-error-rate"`
+	HealthCheckInterval time.Duration `koanf:"health-check-interval"`
+}
+
+var DefaultPoolConfig = PoolConfig{
+	Size:                4,
+	MaxErrorRate:        0.2,
+	HealthCheckInterval: 10 * time.Second,
+}
+
+var TestPoolConfig = PoolConfig{
+	Size:                2,
+	MaxErrorRate:        0.2,
+	HealthCheckInterval: 10 * time.Millisecond,
+}
+
+func PoolConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Int(prefix+".size", DefaultPoolConfig.Size, "number of batch fetchers in the pool, each with its own parent chain connection")
+	f.Float64(prefix+".max-error-rate", DefaultPoolConfig.MaxErrorRate, "fraction of recent calls to a batch fetcher that may fail before it's temporarily removed from the pool")
+	f.Duration(prefix+".health-check-interval", DefaultPoolConfig.HealthCheckInterval, "how often to check the error rate of the batch fetchers in the pool")
+}
+
+func (c *PoolConfig) Validate() error {
+	if c.Size <= 0 {
+		return errors.New("batch fetcher pool size must be positive")
+	}
+	if c.MaxErrorRate <= 0 || c.MaxErrorRate > 1 {
+		return fmt.Errorf("batch fetcher pool max-error-rate must be in (0, 1], got %v", c.MaxErrorRate)
+	}
+	if c.HealthCheckInterval <= 0 {
+		return errors.New("batch fetcher pool health-check-interval must be positive")
+	}
+	return nil
+}
+
+// number of most recent calls the error rate of a backend is computed over
+const recentCallsWindow = 100
+
+// a backend isn't removed before this many calls were recorded for it
+const minCallsForErrorRate = 10
+
+type pooledBackend struct {
+	fetcher execution.BatchFetcher
+
+	mutex   sync.Mutex
+	results [recentCallsWindow]bool // true for a failed call
+	next    int
+	count   int
+	errors  int
+	healthy bool
+}
+
+func (b *pooledBackend) record(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.count == recentCallsWindow {
+		if b.results[b.next] {
+			b.errors--
+		}
+	} else {
+		b.count++
+	}
+	b.results[b.next] = failed
+	if failed {
+		b.errors++
+	}
+	b.next = (b.next + 1) % recentCallsWindow
+}
+
+func (b *pooledBackend) errorRate() (float64, int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.count == 0 {
+		return 0, 0
+	}
+	return float64(b.errors) / float64(b.count), b.count
+}
+
+func (b *pooledBackend) isHealthy() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.healthy
+}
+
+func (b *pooledBackend) setHealthy(healthy bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.healthy = healthy
+}
+
+// remove takes the backend out of the rotation, clearing its recent calls so that
+// restoring it only depends on how it does afterwards.
+func (b *pooledBackend) remove() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.healthy = false
+	b.results = [recentCallsWindow]bool{}
+	b.next = 0
+	b.count = 0
+	b.errors = 0
+}
+
+// PooledBatchFetcher distributes BatchFetcher calls round-robin across a pool of backends,
+// each expected to use its own parent chain connection.
+// A backend whose recent error rate exceeds MaxErrorRate is removed from the rotation, and
+// probed on every health check until its error rate since then drops below MaxErrorRate / 2.
+// If every backend is removed, calls are distributed across all of them.
+type PooledBatchFetcher struct {
+	stopwaiter.StopWaiter
+	config   *PoolConfig
+	backends []*pooledBackend
+	counter  atomic.Uint64
+}
+
+var _ execution.BatchFetcher = (*PooledBatchFetcher)(nil)
+
+// NewPooledBatchFetcher creates config.Size backends by calling newFetcher with each index.
+func NewPooledBatchFetcher(config *PoolConfig, newFetcher func(index int) (execution.BatchFetcher, error)) (*PooledBatchFetcher, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	backends := make([]*pooledBackend, 0, config.Size)
+	for i := 0; i < config.Size; i++ {
+		fetcher, err := newFetcher(i)
+		if err != nil {
+			return nil, fmt.Errorf("creating batch fetcher %d: %w", i, err)
+		}
+		backends = append(backends, &pooledBackend{fetcher: fetcher, healthy: true})
+	}
+	return &PooledBatchFetcher{
+		config:   config,
+		backends: backends,
+	}, nil
+}
+
+func (p *PooledBatchFetcher) Start(ctxIn context.Context) {
+	p.StopWaiter.Start(ctxIn, p)
+	p.CallIteratively(p.checkHealth)
+}
+
+// HealthyBackends returns the number of backends currently in the rotation.
+func (p *PooledBatchFetcher) HealthyBackends() int {
+	healthy := 0
+	for _, backend := range p.backends {
+		if backend.isHealthy() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+func (p *PooledBatchFetcher) checkHealth(ctx context.Context) time.Duration {
+	for i, backend := range p.backends {
+		if !backend.isHealthy() {
+			// Removed backends get no regular calls, so probe them to update their error rate.
+			// The batch count can be read even before any batch was posted, and a probe
+			// taking longer than the health check interval counts as failed.
+			probeCtx, cancel := context.WithTimeout(ctx, p.config.HealthCheckInterval)
+			_, err := backend.fetcher.GetBatchCount().Await(probeCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return 0
+			}
+			backend.record(err != nil)
+		}
+		rate, calls := backend.errorRate()
+		if calls < minCallsForErrorRate {
+			continue
+		}
+		if backend.isHealthy() {
+			if rate > p.config.MaxErrorRate {
+				log.Warn("removing batch fetcher from pool", "index", i, "errorRate", rate)
+				backend.remove()
+			}
+		} else if rate < p.config.MaxErrorRate/2 {
+			log.Info("restoring batch fetcher to pool", "index", i, "errorRate", rate)
+			backend.setHealthy(true)
+		}
+	}
+	return p.config.HealthCheckInterval
+}
+
+func (p *PooledBatchFetcher) pick() *pooledBackend {
+	start := p.counter.Add(1)
+	for i := 0; i < len(p.backends); i++ {
+		backend := p.backends[(start+uint64(i))%uint64(len(p.backends))]
+		if backend.isHealthy() {
+			return backend
+		}
+	}
+	return p.backends[start%uint64(len(p.backends))]
+}
+
+// callerError reports whether err is the caller's mistake, or asking too early, rather than the backend's fault
+func callerError(err error) bool {
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	return errors.Is(err, execution.ErrBatchOffsetOutOfRange) || errors.As(err, &notYetPostedErr)
+}
+
+// recorded makes the call to backend in the background, and records whether it failed once it
+// settles. Calls the caller gave up on, and failures ignore matches, aren't held against the backend.
+func recorded[T any](ctx context.Context, backend *pooledBackend, ignore func(error) bool, call func(ctx context.Context) containers.PromiseInterface[T]) containers.PromiseInterface[T] {
+	return launchPromise(ctx, func(ctx context.Context) (T, error) {
+		result, err := call(ctx).Await(ctx)
+		if ctx.Err() == nil && (ignore == nil || !ignore(err)) {
+			backend.record(err != nil)
+		}
+		return result, err
+	})
+}
+
+func (p *PooledBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	backend := p.pick()
+	return recorded(ctx, backend, callerError, func(ctx context.Context) containers.PromiseInterface[execution.FetchedBatch] {
+		return backend.fetcher.FetchBatch(ctx, batchNum)
+	})
+}
+
+func (p *PooledBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	backend := p.pick()
+	return recorded(ctx, backend, callerError, func(ctx context.Context) containers.PromiseInterface[[]byte] {
+		return backend.fetcher.FetchBatchChunk(ctx, batchNum, offset, length)
+	})
+}
+
+func (p *PooledBatchFetcher) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	backend := p.pick()
+	return recorded(ctx, backend, callerError, func(ctx context.Context) containers.PromiseInterface[uint64] {
+		return backend.fetcher.GetBatchSize(ctx, batchNum)
+	})
+}
+
+func (p *PooledBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, nil, func(context.Context) containers.PromiseInterface[uint64] {
+		return backend.fetcher.GetBatchCount()
+	})
+}
+
+func (p *PooledBatchFetcher) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, nil, func(context.Context) containers.PromiseInterface[execution.BatchLookup] {
+		return backend.fetcher.FindInboxBatchContainingMessage(message)
+	})
+}
+
+func (p *PooledBatchFetcher) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, callerError, func(context.Context) containers.PromiseInterface[uint64] {
+		return backend.fetcher.GetBatchParentChainBlock(seqNum)
+	})
+}
+
+func (p *PooledBatchFetcher) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	// An invalid range is the caller's mistake, so it's rejected before reaching a backend
+	if err := checkBatchRange(first, last); err != nil {
+		return containers.NewReadyPromise(execution.BatchParentChainBlocks{}, err)
+	}
+	backend := p.pick()
+	return recorded(context.Background(), backend, callerError, func(context.Context) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+		return backend.fetcher.GetBatchParentChainBlocks(first, last)
+	})
+}
+
+func (p *PooledBatchFetcher) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, callerError, func(context.Context) containers.PromiseInterface[execution.MessageRange] {
+		return backend.fetcher.GetBatchMessageRange(batchNum)
+	})
+}
+
+func (p *PooledBatchFetcher) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	backend := p.pick()
+	return recorded(ctx, backend, nil, func(ctx context.Context) containers.PromiseInterface[execution.L1Info] {
+		return backend.fetcher.GetMessageL1Info(ctx, pos)
+	})
+}
+
+func (p *PooledBatchFetcher) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, nil, func(context.Context) containers.PromiseInterface[[]uint64] {
+		return backend.fetcher.FindBatchesInParentChainRange(firstBlock, lastBlock)
+	})
+}
+
+func (p *PooledBatchFetcher) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	backend := p.pick()
+	return recorded(context.Background(), backend, callerError, func(context.Context) containers.PromiseInterface[struct{}] {
+		return backend.fetcher.PrefetchBatches(first, last)
+	})
+}
@@ -0,0 +1,329 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var errBackendDown = errors.New("backend down")
+
+type fakeBatchFetcher struct {
+	failing      atomic.Bool
+	notYetPosted atomic.Bool
+	// noBatches fails the calls for a batch, as no batch was posted yet
+	noBatches atomic.Bool
+	// hung makes GetBatchCount never resolve until it's cancelled
+	hung      atomic.Bool
+	cancelled atomic.Int64
+	calls     atomic.Int64
+}
+
+func (f *fakeBatchFetcher) batchResult() error {
+	if err := f.result(); err != nil {
+		return err
+	}
+	if f.noBatches.Load() {
+		return &execution.ErrBatchNotYetPosted{BatchNum: 0}
+	}
+	return nil
+}
+
+func (f *fakeBatchFetcher) result() error {
+	f.calls.Add(1)
+	if f.failing.Load() {
+		return errBackendDown
+	}
+	if f.notYetPosted.Load() {
+		return &execution.ErrBatchNotYetPosted{BatchNum: 1, LatestPostedBatch: 0}
+	}
+	return nil
+}
+
+func (f *fakeBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	return containers.NewReadyPromise(execution.FetchedBatch{Data: []byte{byte(batchNum)}}, f.result())
+}
+
+func (f *fakeBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	return containers.NewReadyPromise([]byte{}, f.result())
+}
+
+func (f *fakeBatchFetcher) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	return containers.NewReadyPromise[uint64](1, f.result())
+}
+
+func (f *fakeBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	if f.hung.Load() {
+		f.calls.Add(1)
+		promise := containers.NewPromise[uint64](func() { f.cancelled.Add(1) })
+		return &promise
+	}
+	if f.noBatches.Load() {
+		return containers.NewReadyPromise[uint64](0, f.result())
+	}
+	return containers.NewReadyPromise[uint64](1, f.result())
+}
+
+func (f *fakeBatchFetcher) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	return containers.NewReadyPromise(execution.BatchLookup{Batch: 0, Found: true}, f.result())
+}
+
+func (f *fakeBatchFetcher) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	return containers.NewReadyPromise[uint64](0, f.batchResult())
+}
+
+func (f *fakeBatchFetcher) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	return containers.NewReadyPromise(execution.BatchParentChainBlocks{First: first}, f.result())
+}
+
+func (f *fakeBatchFetcher) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	return containers.NewReadyPromise(execution.MessageRange{}, f.result())
+}
+
+func (f *fakeBatchFetcher) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	return containers.NewReadyPromise(execution.L1Info{}, f.result())
+}
+
+func (f *fakeBatchFetcher) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	return containers.NewReadyPromise([]uint64{}, f.result())
+}
+
+func (f *fakeBatchFetcher) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, f.result())
+}
+
+func TestPooledBatchFetcherRemovesAndRestoresBackend(t *testing.T) {
+	ctx := context.Background()
+	fakes := []*fakeBatchFetcher{{}, {}}
+	config := TestPoolConfig
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fakes[index], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Round-robin across healthy backends
+	for i := 0; i < 10; i++ {
+		if _, err := pool.FetchBatch(ctx, uint64(i)).Await(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fakes[0].calls.Load() != 5 || fakes[1].calls.Load() != 5 {
+		t.Fatal("calls not distributed round-robin", fakes[0].calls.Load(), fakes[1].calls.Load())
+	}
+
+	fakes[1].failing.Store(true)
+	for i := 0; i < 2*minCallsForErrorRate; i++ {
+		_, _ = pool.FetchBatch(ctx, uint64(i)).Await(ctx)
+	}
+	pool.checkHealth(ctx)
+	if pool.HealthyBackends() != 1 {
+		t.Fatal("failing backend not removed, healthy backends:", pool.HealthyBackends())
+	}
+	before := fakes[1].calls.Load()
+	for i := 0; i < 10; i++ {
+		if _, err := pool.FetchBatch(ctx, uint64(i)).Await(ctx); err != nil {
+			t.Fatal("call routed to removed backend", err)
+		}
+	}
+	if fakes[1].calls.Load() != before {
+		t.Fatal("removed backend received calls")
+	}
+	if _, calls := pool.backends[1].errorRate(); calls != 0 {
+		t.Fatal("calls before removal still counted", calls)
+	}
+
+	// Once the backend recovers, it's restored after enough successful health check probes,
+	// however many calls failed before it was removed
+	fakes[1].failing.Store(false)
+	for i := 0; i < minCallsForErrorRate-1; i++ {
+		pool.checkHealth(ctx)
+	}
+	if pool.HealthyBackends() != 1 {
+		t.Fatal("backend restored before enough probes")
+	}
+	pool.checkHealth(ctx)
+	if pool.HealthyBackends() != 2 {
+		t.Fatal("recovered backend not restored")
+	}
+	rate, _ := pool.backends[1].errorRate()
+	if rate >= config.MaxErrorRate/2 {
+		t.Fatal("backend restored with error rate", rate)
+	}
+}
+
+func TestPooledBatchFetcherAllBackendsRemoved(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBatchFetcher{}
+	fake.failing.Store(true)
+	config := TestPoolConfig
+	config.Size = 1
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fake, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < minCallsForErrorRate; i++ {
+		_, _ = pool.GetBatchParentChainBlock(0).Await(ctx)
+	}
+	pool.checkHealth(ctx)
+	if pool.HealthyBackends() != 0 {
+		t.Fatal("failing backend not removed")
+	}
+	// Calls still go somewhere rather than failing outright
+	if _, err := pool.GetBatchParentChainBlock(0).Await(ctx); !errors.Is(err, errBackendDown) {
+		t.Fatal("unexpected error with no healthy backends", err)
+	}
+}
+
+func TestPooledBatchFetcherProbesBeforeFirstBatch(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBatchFetcher{}
+	config := TestPoolConfig
+	config.Size = 1
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fake, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.failing.Store(true)
+	for i := 0; i < minCallsForErrorRate; i++ {
+		_, _ = pool.FetchBatch(ctx, 0).Await(ctx)
+	}
+	pool.checkHealth(ctx)
+	if pool.HealthyBackends() != 0 {
+		t.Fatal("failing backend not removed")
+	}
+
+	// No batch is posted yet, but the probes still succeed
+	fake.failing.Store(false)
+	fake.noBatches.Store(true)
+	for i := 0; i < minCallsForErrorRate; i++ {
+		pool.checkHealth(ctx)
+	}
+	if pool.HealthyBackends() != 1 {
+		t.Fatal("backend not restored before the first batch was posted")
+	}
+}
+
+func TestPooledBatchFetcherIgnoresBatchesNotYetPosted(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBatchFetcher{}
+	fake.notYetPosted.Store(true)
+	config := TestPoolConfig
+	config.Size = 1
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fake, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2*minCallsForErrorRate; i++ {
+		_, err := pool.FetchBatch(ctx, 1).Await(ctx)
+		var notYetPostedErr *ErrBatchNotYetPosted
+		if !errors.As(err, &notYetPostedErr) {
+			t.Fatal("expected batch not yet posted, got", err)
+		}
+	}
+	pool.checkHealth(ctx)
+	if pool.HealthyBackends() != 1 {
+		t.Fatal("backend removed for batches not yet posted")
+	}
+}
+
+func TestPooledBatchFetcherRecordsRangeCalls(t *testing.T) {
+	ctx := context.Background()
+	calls := map[string]func(pool *PooledBatchFetcher) error{
+		"GetBatchParentChainBlocks": func(pool *PooledBatchFetcher) error {
+			_, err := pool.GetBatchParentChainBlocks(0, 1).Await(ctx)
+			return err
+		},
+		"GetBatchMessageRange": func(pool *PooledBatchFetcher) error {
+			_, err := pool.GetBatchMessageRange(0).Await(ctx)
+			return err
+		},
+		"PrefetchBatches": func(pool *PooledBatchFetcher) error {
+			_, err := pool.PrefetchBatches(0, 1).Await(ctx)
+			return err
+		},
+	}
+	for name, call := range calls {
+		fake := &fakeBatchFetcher{}
+		fake.failing.Store(true)
+		config := TestPoolConfig
+		config.Size = 1
+		pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+			return fake, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < minCallsForErrorRate; i++ {
+			if err := call(pool); !errors.Is(err, errBackendDown) {
+				t.Fatal(name, "unexpected error", err)
+			}
+		}
+		pool.checkHealth(ctx)
+		if pool.HealthyBackends() != 0 {
+			t.Fatal(name, "failures not recorded against the backend")
+		}
+	}
+
+	// An invalid range doesn't reach the backend
+	fake := &fakeBatchFetcher{}
+	config := TestPoolConfig
+	config.Size = 1
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fake, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.GetBatchParentChainBlocks(1, 0).Await(ctx); err == nil {
+		t.Fatal("expected error for invalid range")
+	}
+	if fake.calls.Load() != 0 {
+		t.Fatal("invalid range reached the backend")
+	}
+}
+
+func TestPooledBatchFetcherCancelsHungCall(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBatchFetcher{}
+	fake.hung.Store(true)
+	config := TestPoolConfig
+	config.Size = 1
+	pool, err := NewPooledBatchFetcher(&config, func(index int) (execution.BatchFetcher, error) {
+		return fake, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := pool.GetBatchCount()
+	for fake.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	count.Cancel()
+	if _, err := count.Await(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected cancelled call, got", err)
+	}
+	if fake.cancelled.Load() != 1 {
+		t.Fatal("backend call not cancelled")
+	}
+	// The caller gave up, so it isn't held against the backend
+	if _, calls := pool.backends[0].errorRate(); calls != 0 {
+		t.Fatal("cancelled call recorded", calls)
+	}
+}
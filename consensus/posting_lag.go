@@ -0,0 +1,35 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"time"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// NewPostingLag computes the posting lag from the message count of the latest posted batch and
+// the message count, and the timestamp of message posted if it exists. The posted count should be
+// read first, so a batch posted in between only makes the lag look larger. A posted count beyond
+// the message count, as seen in a reorg, is reported as no lag rather than a negative one.
+func NewPostingLag(posterEnabled bool, msgCount, posted arbutil.MessageIndex, oldestUnpostedTimestamp uint64, now time.Time) execution.PostingLag {
+	lag := execution.PostingLag{
+		PosterEnabled:  posterEnabled,
+		MessageCount:   msgCount,
+		PostedMsgCount: posted,
+	}
+	if posted >= msgCount {
+		return lag
+	}
+	lag.UnpostedMessages = msgCount - posted
+	// Messages without a header have no timestamp to age them by
+	if oldestUnpostedTimestamp == 0 {
+		return lag
+	}
+	if age := now.Sub(time.Unix(int64(oldestUnpostedTimestamp), 0)); age > 0 {
+		lag.OldestUnpostedAge = age
+	}
+	return lag
+}
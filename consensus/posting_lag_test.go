@@ -0,0 +1,28 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPostingLag(t *testing.T) {
+	now := time.Unix(1000, 0)
+	lag := NewPostingLag(true, 10, 7, 900, now)
+	if !lag.PosterEnabled || lag.UnpostedMessages != 3 || lag.OldestUnpostedAge != 100*time.Second {
+		t.Fatal("unexpected posting lag", lag)
+	}
+
+	// A batch posted after the posted count was read can't make the lag negative
+	lag = NewPostingLag(false, 10, 12, 900, now)
+	if lag.PosterEnabled || lag.UnpostedMessages != 0 || lag.OldestUnpostedAge != 0 {
+		t.Fatal("unexpected posting lag with the posted count beyond the message count", lag)
+	}
+
+	// Nor can a message timestamped ahead of the local clock
+	if lag := NewPostingLag(true, 10, 7, 1100, now); lag.OldestUnpostedAge != 0 {
+		t.Fatal("unexpected age of a message from the future", lag.OldestUnpostedAge)
+	}
+}
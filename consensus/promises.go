@@ -0,0 +1,27 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+// launchPromise runs f in its own goroutine, and resolves the returned promise with its result.
+// Cancelling the promise, or ctx, cancels the context f is given.
+func launchPromise[T any](ctx context.Context, f func(ctx context.Context) (T, error)) containers.PromiseInterface[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	promise := containers.NewPromise[T](cancel)
+	go func() {
+		defer cancel()
+		result, err := f(ctx)
+		if err != nil {
+			promise.ProduceError(err)
+		} else {
+			promise.Produce(result)
+		}
+	}()
+	return &promise
+}
@@ -0,0 +1,672 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type RecorderConfig struct {
+	Directory   string `koanf:"directory"`
+	MaxFileSize int64  `koanf:"max-file-size"`
+}
+
+var DefaultRecorderConfig = RecorderConfig{
+	Directory:   "",
+	MaxFileSize: 256 * 1024 * 1024,
+}
+
+func RecorderConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".directory", DefaultRecorderConfig.Directory, "directory to write consensus call recordings to")
+	f.Int64(prefix+".max-file-size", DefaultRecorderConfig.MaxFileSize, "size in bytes after which a new recording file is started")
+}
+
+func (c *RecorderConfig) Validate() error {
+	if c.Directory == "" {
+		return errors.New("consensus recorder directory must be set")
+	}
+	if c.MaxFileSize <= 0 {
+		return errors.New("consensus recorder max-file-size must be positive")
+	}
+	return nil
+}
+
+var errRecordingClosed = errors.New("recording closed")
+
+// recordingFilePattern names recording files so they sort in recording order
+const recordingFilePattern = "consensus-recording-%06d.jsonl"
+
+// RecordedError keeps enough of an error for replay to satisfy errors.Is and errors.As
+// for the typed errors of the execution package.
+type RecordedError struct {
+	Kind            string               `json:"kind,omitempty"`
+	Message         string               `json:"message"`
+	OldestAvailable uint64               `json:"oldestAvailable,omitempty"`
+	Pos             arbutil.MessageIndex `json:"pos,omitempty"`
+	Expected        arbutil.MessageIndex `json:"expected,omitempty"`
+	Deadline        time.Time            `json:"deadline,omitempty"`
+	RetryAfter      time.Duration        `json:"retryAfter,omitempty"`
+	Block           uint64               `json:"block,omitempty"`
+	Genesis         uint64               `json:"genesis,omitempty"`
+	Head            arbutil.MessageIndex `json:"head,omitempty"`
+	Ceiling         arbutil.MessageIndex `json:"ceiling,omitempty"`
+	BatchNum        uint64               `json:"batchNum,omitempty"`
+	LatestPosted    uint64               `json:"latestPosted,omitempty"`
+	Component       string               `json:"component,omitempty"`
+	FailedPos       arbutil.MessageIndex `json:"failedPos,omitempty"`
+	// Block hashes are pointers to be omitted when empty
+	ExpectedBlockHash *common.Hash `json:"expectedBlockHash,omitempty"`
+	BlockHash         *common.Hash `json:"blockHash,omitempty"`
+}
+
+const (
+	recordedErrorBatchNotFound         = "batchNotFound"
+	recordedErrorBatchNotYetPosted     = "batchNotYetPosted"
+	recordedErrorBatchPruned           = "batchPruned"
+	recordedErrorBatchOffsetOutOfRange = "batchOffsetOutOfRange"
+	recordedErrorCommitDeadline        = "commitDeadlineExceeded"
+	recordedErrorRetrySequencer        = "retrySequencer"
+	recordedErrorInsertLockTaken       = "sequencerInsertLockTaken"
+	recordedErrorBackpressure          = "backpressure"
+	recordedErrorSequencerNotActive    = "sequencerNotActive"
+	recordedErrorConflictingMessage    = "conflictingMessage"
+	recordedErrorMsgGap                = "msgGap"
+	recordedErrorRateLimited           = "rateLimited"
+	recordedErrorCircuitOpen           = "circuitOpen"
+	recordedErrorBatchPosterNotEnabled = "batchPosterNotEnabled"
+	recordedErrorBlockBeforeGenesis    = "blockBeforeGenesis"
+	recordedErrorMessageBeyondHead     = "messageBeyondHead"
+	recordedErrorMessageCeiling        = "messageCeilingExceeded"
+	recordedErrorComponentUnavailable  = "componentUnavailable"
+	recordedErrorEarlierWriteFailed    = "earlierWriteFailed"
+	recordedErrorIdempotencyConflict   = "idempotencyConflict"
+	recordedErrorCheckpointMismatch    = "checkpointMismatch"
+	recordedErrorSnapshotTooOld        = "snapshotTooOld"
+	recordedErrorClientClosed          = "clientClosed"
+	recordedErrorDraining              = "draining"
+)
+
+func newRecordedError(err error) *RecordedError {
+	if err == nil {
+		return nil
+	}
+	recorded := &RecordedError{Message: err.Error()}
+	var notFoundErr *execution.ErrBatchNotFound
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	var prunedErr *execution.ErrBatchPruned
+	var deadlineErr *execution.ErrCommitDeadlineExceeded
+	var conflictErr *ErrConflictingMessage
+	var gapErr *ErrMsgGap
+	var rateLimitErr *ErrRateLimited
+	var beforeGenesisErr *ErrBlockBeforeGenesis
+	var beyondHeadErr *ErrMessageBeyondHead
+	var ceilingErr *ErrMessageCeilingExceeded
+	var componentErr *ErrComponentUnavailable
+	var earlierWriteErr *ErrEarlierWriteFailed
+	var checkpointErr *ErrCheckpointMismatch
+	var snapshotErr *ErrSnapshotTooOld
+	switch {
+	case errors.As(err, &notFoundErr):
+		recorded.Kind = recordedErrorBatchNotFound
+		recorded.BatchNum = notFoundErr.BatchNum
+	case errors.As(err, &notYetPostedErr):
+		recorded.Kind = recordedErrorBatchNotYetPosted
+		recorded.BatchNum = notYetPostedErr.BatchNum
+		recorded.LatestPosted = notYetPostedErr.LatestPostedBatch
+	case errors.As(err, &prunedErr):
+		recorded.Kind = recordedErrorBatchPruned
+		recorded.OldestAvailable = prunedErr.OldestAvailable
+	case errors.As(err, &deadlineErr):
+		recorded.Kind = recordedErrorCommitDeadline
+		recorded.Pos = deadlineErr.Pos
+		recorded.Deadline = deadlineErr.Deadline
+	case errors.As(err, &conflictErr):
+		recorded.Kind = recordedErrorConflictingMessage
+		recorded.Pos = conflictErr.Pos
+		recorded.Expected = conflictErr.Expected
+	case errors.As(err, &gapErr):
+		recorded.Kind = recordedErrorMsgGap
+		recorded.Pos = gapErr.Pos
+		recorded.Expected = gapErr.Expected
+	case errors.As(err, &rateLimitErr):
+		recorded.Kind = recordedErrorRateLimited
+		recorded.RetryAfter = rateLimitErr.RetryAfter
+	case errors.As(err, &beforeGenesisErr):
+		recorded.Kind = recordedErrorBlockBeforeGenesis
+		recorded.Block = beforeGenesisErr.Block
+		recorded.Genesis = beforeGenesisErr.Genesis
+	case errors.As(err, &beyondHeadErr):
+		recorded.Kind = recordedErrorMessageBeyondHead
+		recorded.Pos = beyondHeadErr.Pos
+		recorded.Head = beyondHeadErr.Head
+	case errors.As(err, &ceilingErr):
+		recorded.Kind = recordedErrorMessageCeiling
+		recorded.Pos = ceilingErr.Pos
+		recorded.Ceiling = ceilingErr.Ceiling
+	case errors.As(err, &componentErr):
+		recorded.Kind = recordedErrorComponentUnavailable
+		recorded.Component = componentErr.Component
+	case errors.As(err, &earlierWriteErr):
+		recorded.Kind = recordedErrorEarlierWriteFailed
+		recorded.Pos = earlierWriteErr.Pos
+		recorded.FailedPos = earlierWriteErr.FailedPos
+	case errors.As(err, &checkpointErr):
+		recorded.Kind = recordedErrorCheckpointMismatch
+		recorded.Pos = checkpointErr.Pos
+		recorded.ExpectedBlockHash = &checkpointErr.ExpectedBlockHash
+		recorded.BlockHash = &checkpointErr.BlockHash
+	case errors.As(err, &snapshotErr):
+		recorded.Kind = recordedErrorSnapshotTooOld
+		recorded.Pos = snapshotErr.Pos
+	case errors.Is(err, ErrSequencerNotActive):
+		recorded.Kind = recordedErrorSequencerNotActive
+	case errors.Is(err, ErrCircuitOpen):
+		recorded.Kind = recordedErrorCircuitOpen
+	case errors.Is(err, execution.ErrBatchOffsetOutOfRange):
+		recorded.Kind = recordedErrorBatchOffsetOutOfRange
+	case errors.Is(err, execution.ErrRetrySequencer):
+		recorded.Kind = recordedErrorRetrySequencer
+	case errors.Is(err, execution.ErrSequencerInsertLockTaken):
+		recorded.Kind = recordedErrorInsertLockTaken
+	case errors.Is(err, execution.ErrBackpressure):
+		recorded.Kind = recordedErrorBackpressure
+	case errors.Is(err, execution.ErrBatchPosterNotEnabled):
+		recorded.Kind = recordedErrorBatchPosterNotEnabled
+	case errors.Is(err, execution.ErrIdempotencyConflict):
+		recorded.Kind = recordedErrorIdempotencyConflict
+	case errors.Is(err, execution.ErrClientClosed):
+		recorded.Kind = recordedErrorClientClosed
+	case errors.Is(err, execution.ErrDraining):
+		recorded.Kind = recordedErrorDraining
+	}
+	return recorded
+}
+
+func (e *RecordedError) toError() error {
+	if e == nil {
+		return nil
+	}
+	var sentinel error
+	switch e.Kind {
+	case recordedErrorBatchNotFound:
+		return fmt.Errorf("%w (recorded: %s)", &execution.ErrBatchNotFound{BatchNum: e.BatchNum}, e.Message)
+	case recordedErrorBatchNotYetPosted:
+		return fmt.Errorf("%w (recorded: %s)", &execution.ErrBatchNotYetPosted{BatchNum: e.BatchNum, LatestPostedBatch: e.LatestPosted}, e.Message)
+	case recordedErrorBatchPruned:
+		return fmt.Errorf("%w (recorded: %s)", &execution.ErrBatchPruned{OldestAvailable: e.OldestAvailable}, e.Message)
+	case recordedErrorCommitDeadline:
+		return fmt.Errorf("%w (recorded: %s)", &execution.ErrCommitDeadlineExceeded{Pos: e.Pos, Deadline: e.Deadline}, e.Message)
+	case recordedErrorConflictingMessage:
+		return fmt.Errorf("%w (recorded: %s)", &ErrConflictingMessage{Pos: e.Pos, Expected: e.Expected}, e.Message)
+	case recordedErrorMsgGap:
+		return fmt.Errorf("%w (recorded: %s)", &ErrMsgGap{Pos: e.Pos, Expected: e.Expected}, e.Message)
+	case recordedErrorRateLimited:
+		return fmt.Errorf("%w (recorded: %s)", &ErrRateLimited{RetryAfter: e.RetryAfter}, e.Message)
+	case recordedErrorBlockBeforeGenesis:
+		return fmt.Errorf("%w (recorded: %s)", &ErrBlockBeforeGenesis{Block: e.Block, Genesis: e.Genesis}, e.Message)
+	case recordedErrorMessageBeyondHead:
+		return fmt.Errorf("%w (recorded: %s)", &ErrMessageBeyondHead{Pos: e.Pos, Head: e.Head}, e.Message)
+	case recordedErrorMessageCeiling:
+		return fmt.Errorf("%w (recorded: %s)", &ErrMessageCeilingExceeded{Pos: e.Pos, Ceiling: e.Ceiling}, e.Message)
+	case recordedErrorComponentUnavailable:
+		return fmt.Errorf("%w (recorded: %s)", &ErrComponentUnavailable{Component: e.Component}, e.Message)
+	case recordedErrorEarlierWriteFailed:
+		return fmt.Errorf("%w (recorded: %s)", &ErrEarlierWriteFailed{Pos: e.Pos, FailedPos: e.FailedPos}, e.Message)
+	case recordedErrorCheckpointMismatch:
+		checkpointErr := &ErrCheckpointMismatch{Pos: e.Pos}
+		if e.ExpectedBlockHash != nil {
+			checkpointErr.ExpectedBlockHash = *e.ExpectedBlockHash
+		}
+		if e.BlockHash != nil {
+			checkpointErr.BlockHash = *e.BlockHash
+		}
+		return fmt.Errorf("%w (recorded: %s)", checkpointErr, e.Message)
+	case recordedErrorSnapshotTooOld:
+		return fmt.Errorf("%w (recorded: %s)", &ErrSnapshotTooOld{Pos: e.Pos}, e.Message)
+	case recordedErrorSequencerNotActive:
+		return NewSequencerNotActiveError("recorded: " + e.Message)
+	case recordedErrorCircuitOpen:
+		sentinel = ErrCircuitOpen
+	case recordedErrorBatchOffsetOutOfRange:
+		sentinel = execution.ErrBatchOffsetOutOfRange
+	case recordedErrorRetrySequencer:
+		sentinel = execution.ErrRetrySequencer
+	case recordedErrorInsertLockTaken:
+		sentinel = execution.ErrSequencerInsertLockTaken
+	case recordedErrorBackpressure:
+		sentinel = execution.ErrBackpressure
+	case recordedErrorBatchPosterNotEnabled:
+		sentinel = execution.ErrBatchPosterNotEnabled
+	case recordedErrorIdempotencyConflict:
+		sentinel = execution.ErrIdempotencyConflict
+	case recordedErrorClientClosed:
+		sentinel = execution.ErrClientClosed
+	case recordedErrorDraining:
+		sentinel = execution.ErrDraining
+	default:
+		return errors.New(e.Message)
+	}
+	return fmt.Errorf("%w (recorded: %s)", sentinel, e.Message)
+}
+
+// RecordedCall is a single line of a recording file.
+// Args is the JSON array of the call's arguments, and Result the JSON encoding of its results.
+type RecordedCall struct {
+	Time   time.Time       `json:"time"`
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RecordedError  `json:"error,omitempty"`
+}
+
+// RecordingConsensusClient forwards every call to a FullConsensusClient, and appends the call
+// with its arguments, results and errors to recording files in config.Directory, unredacted.
+// Each call is written out as it completes, so large batches aren't held in memory.
+// A new file is started once the current one exceeds config.MaxFileSize.
+// Every call waits for its promise before returning, so calls are recorded in the order they
+// complete. Lag threshold callbacks aren't recorded, and PrefetchBatches is recorded without its result.
+type RecordingConsensusClient struct {
+	inner  execution.FullConsensusClient
+	config *RecorderConfig
+
+	mutex     sync.Mutex
+	fileIndex int
+	file      *os.File
+	size      int64
+	err       error
+}
+
+var _ execution.FullConsensusClient = (*RecordingConsensusClient)(nil)
+
+func NewRecordingConsensusClient(inner execution.FullConsensusClient, config *RecorderConfig) (*RecordingConsensusClient, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(config.Directory, 0o755); err != nil {
+		return nil, err
+	}
+	// Never overwrite an earlier recording
+	existing, err := filepath.Glob(filepath.Join(config.Directory, "consensus-recording-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	r := &RecordingConsensusClient{
+		inner:     inner,
+		config:    config,
+		fileIndex: len(existing),
+	}
+	if err := r.openFile(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// The mutex must be held
+func (r *RecordingConsensusClient) openFile() error {
+	path := filepath.Join(r.config.Directory, fmt.Sprintf(recordingFilePattern, r.fileIndex))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.size = 0
+	return nil
+}
+
+// The mutex must be held
+func (r *RecordingConsensusClient) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Err returns the first error writing the recording, after which nothing more is recorded.
+func (r *RecordingConsensusClient) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
+// Close closes the current recording file, and then the recorded client.
+// Calls after Close aren't recorded.
+func (r *RecordingConsensusClient) Close() error {
+	r.mutex.Lock()
+	err := r.closeFile()
+	if r.err == nil {
+		r.err = errRecordingClosed
+	}
+	r.mutex.Unlock()
+	return errors.Join(err, r.inner.Close())
+}
+
+func (r *RecordingConsensusClient) record(method string, args []interface{}, result interface{}, callErr error) {
+	call := RecordedCall{
+		Time:   time.Now(),
+		Method: method,
+		Error:  newRecordedError(callErr),
+	}
+	var err error
+	call.Args, err = json.Marshal(args)
+	if err == nil && result != nil && callErr == nil {
+		call.Result, err = json.Marshal(result)
+	}
+	var line []byte
+	if err == nil {
+		line, err = json.Marshal(&call)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err == nil {
+		// Written per call, so a crash loses at most the call in progress
+		line = append(line, '\n')
+		_, err = r.file.Write(line)
+	}
+	if err == nil {
+		r.size += int64(len(line))
+		if r.size >= r.config.MaxFileSize {
+			err = r.closeFile()
+			if err == nil {
+				r.fileIndex++
+				err = r.openFile()
+			}
+		}
+	}
+	if err != nil {
+		log.Error("failed recording consensus call, recording stopped", "method", method, "err", err)
+		r.err = err
+	}
+}
+
+func (r *RecordingConsensusClient) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	batch, err := r.inner.FetchBatch(ctx, batchNum).Await(ctx)
+	r.record("FetchBatch", []interface{}{batchNum}, batch, err)
+	return containers.NewReadyPromise(batch, err)
+}
+
+func (r *RecordingConsensusClient) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	chunk, err := r.inner.FetchBatchChunk(ctx, batchNum, offset, length).Await(ctx)
+	r.record("FetchBatchChunk", []interface{}{batchNum, offset, length}, chunk, err)
+	return containers.NewReadyPromise(chunk, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	size, err := r.inner.GetBatchSize(ctx, batchNum).Await(ctx)
+	r.record("GetBatchSize", []interface{}{batchNum}, size, err)
+	return containers.NewReadyPromise(size, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchCount() containers.PromiseInterface[uint64] {
+	count, err := r.inner.GetBatchCount().Await(context.Background())
+	r.record("GetBatchCount", []interface{}{}, count, err)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *RecordingConsensusClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	lookup, err := r.inner.FindInboxBatchContainingMessage(message).Await(context.Background())
+	r.record("FindInboxBatchContainingMessage", []interface{}{message}, lookup, err)
+	return containers.NewReadyPromise(lookup, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	block, err := r.inner.GetBatchParentChainBlock(seqNum).Await(context.Background())
+	r.record("GetBatchParentChainBlock", []interface{}{seqNum}, block, err)
+	return containers.NewReadyPromise(block, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	blocks, err := r.inner.GetBatchParentChainBlocks(first, last).Await(context.Background())
+	r.record("GetBatchParentChainBlocks", []interface{}{first, last}, blocks, err)
+	return containers.NewReadyPromise(blocks, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	messageRange, err := r.inner.GetBatchMessageRange(batchNum).Await(context.Background())
+	r.record("GetBatchMessageRange", []interface{}{batchNum}, messageRange, err)
+	return containers.NewReadyPromise(messageRange, err)
+}
+
+func (r *RecordingConsensusClient) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	info, err := r.inner.GetMessageL1Info(ctx, pos).Await(ctx)
+	r.record("GetMessageL1Info", []interface{}{pos}, info, err)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *RecordingConsensusClient) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	batches, err := r.inner.FindBatchesInParentChainRange(firstBlock, lastBlock).Await(context.Background())
+	r.record("FindBatchesInParentChainRange", []interface{}{firstBlock, lastBlock}, batches, err)
+	return containers.NewReadyPromise(batches, err)
+}
+
+func (r *RecordingConsensusClient) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	r.record("PrefetchBatches", []interface{}{first, last}, nil, nil)
+	return r.inner.PrefetchBatches(first, last)
+}
+
+func (r *RecordingConsensusClient) Synced() containers.PromiseInterface[bool] {
+	synced, err := r.inner.Synced().Await(context.Background())
+	r.record("Synced", []interface{}{}, synced, err)
+	return containers.NewReadyPromise(synced, err)
+}
+
+func (r *RecordingConsensusClient) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	health, err := r.inner.Healthy().Await(context.Background())
+	r.record("Healthy", []interface{}{}, health, err)
+	return containers.NewReadyPromise(health, err)
+}
+
+func (r *RecordingConsensusClient) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	result, err := r.inner.Ping(ctx).Await(ctx)
+	r.record("Ping", []interface{}{}, result, err)
+	return containers.NewReadyPromise(result, err)
+}
+
+func (r *RecordingConsensusClient) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	snapshot, err := r.inner.SyncProgressSnapshot(ctx).Await(ctx)
+	r.record("SyncProgressSnapshot", []interface{}{}, snapshot, err)
+	return containers.NewReadyPromise(snapshot, err)
+}
+
+func (r *RecordingConsensusClient) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	progress, err := r.inner.FullSyncProgressMap().Await(context.Background())
+	r.record("FullSyncProgressMap", []interface{}{}, progress, err)
+	return containers.NewReadyPromise(progress, err)
+}
+
+func (r *RecordingConsensusClient) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	target, err := r.inner.SyncTargetMessageCount().Await(context.Background())
+	r.record("SyncTargetMessageCount", []interface{}{}, target, err)
+	return containers.NewReadyPromise(target, err)
+}
+
+func (r *RecordingConsensusClient) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	estimate, err := r.inner.CatchUpEstimate().Await(context.Background())
+	r.record("CatchUpEstimate", []interface{}{}, estimate, err)
+	return containers.NewReadyPromise(estimate, err)
+}
+
+func (r *RecordingConsensusClient) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	capabilities, err := r.inner.Capabilities().Await(context.Background())
+	r.record("Capabilities", []interface{}{}, capabilities, err)
+	return containers.NewReadyPromise(capabilities, err)
+}
+
+func (r *RecordingConsensusClient) FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	batches, err := r.inner.FindBatchesContainingKind(first, last, kind).Await(context.Background())
+	r.record("FindBatchesContainingKind", []interface{}{first, last, kind}, batches, err)
+	return containers.NewReadyPromise(batches, err)
+}
+
+func (r *RecordingConsensusClient) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	mode, err := r.inner.GetSyncMode().Await(context.Background())
+	r.record("GetSyncMode", []interface{}{}, mode, err)
+	return containers.NewReadyPromise(mode, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	lag, err := r.inner.GetBatchPostingLag().Await(context.Background())
+	r.record("GetBatchPostingLag", []interface{}{}, lag, err)
+	return containers.NewReadyPromise(lag, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	stats, err := r.inner.GetBatchCompressionStats().Await(context.Background())
+	r.record("GetBatchCompressionStats", []interface{}{}, stats, err)
+	return containers.NewReadyPromise(stats, err)
+}
+
+func (r *RecordingConsensusClient) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	block, err := r.inner.MessageIndexToBlockNumber(pos).Await(context.Background())
+	r.record("MessageIndexToBlockNumber", []interface{}{pos}, block, err)
+	return containers.NewReadyPromise(block, err)
+}
+
+func (r *RecordingConsensusClient) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	hash, err := r.inner.GetMessageAccHash(pos).Await(context.Background())
+	r.record("GetMessageAccHash", []interface{}{pos}, hash, err)
+	return containers.NewReadyPromise(hash, err)
+}
+
+func (r *RecordingConsensusClient) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	checkpoint, err := r.inner.GetCheckpointInfo(ctx).Await(ctx)
+	r.record("GetCheckpointInfo", []interface{}{}, checkpoint, err)
+	return containers.NewReadyPromise(checkpoint, err)
+}
+
+func (r *RecordingConsensusClient) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	_, err := r.inner.VerifyExecutionCheckpoint(pos, blockHash).Await(context.Background())
+	r.record("VerifyExecutionCheckpoint", []interface{}{pos, blockHash}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	report, err := r.inner.GetBatchPostingReport(seqNum).Await(context.Background())
+	r.record("GetBatchPostingReport", []interface{}{seqNum}, report, err)
+	return containers.NewReadyPromise(report, err)
+}
+
+func (r *RecordingConsensusClient) GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	reports, err := r.inner.GetBatchPostingReports(first, last).Await(context.Background())
+	r.record("GetBatchPostingReports", []interface{}{first, last}, reports, err)
+	return containers.NewReadyPromise(reports, err)
+}
+
+func (r *RecordingConsensusClient) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	pos, err := r.inner.BlockNumberToMessageIndex(block).Await(context.Background())
+	r.record("BlockNumberToMessageIndex", []interface{}{block}, pos, err)
+	return containers.NewReadyPromise(pos, err)
+}
+
+func (r *RecordingConsensusClient) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	spec, err := r.inner.GetChainSpec(ctx).Await(ctx)
+	r.record("GetChainSpec", []interface{}{}, spec, err)
+	return containers.NewReadyPromise(spec, err)
+}
+
+func (r *RecordingConsensusClient) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	info, err := r.inner.GetSafeMsgCount(ctx).Await(ctx)
+	r.record("GetSafeMsgCount", []interface{}{}, info, err)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *RecordingConsensusClient) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	info, err := r.inner.GetFinalizedMsgCount(ctx).Await(ctx)
+	r.record("GetFinalizedMsgCount", []interface{}{}, info, err)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *RecordingConsensusClient) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	count, err := r.inner.GetSafeMsgCountWithHash(ctx).Await(ctx)
+	r.record("GetSafeMsgCountWithHash", []interface{}{}, count, err)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *RecordingConsensusClient) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	count, err := r.inner.GetFinalizedMsgCountWithHash(ctx).Await(ctx)
+	r.record("GetFinalizedMsgCountWithHash", []interface{}{}, count, err)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *RecordingConsensusClient) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	count, err := r.inner.ValidatedMessageCount().Await(context.Background())
+	r.record("ValidatedMessageCount", []interface{}{}, count, err)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *RecordingConsensusClient) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	_, err := r.inner.SetLagThreshold(severity, messages, onExceed, onRecovery).Await(context.Background())
+	r.record("SetLagThreshold", []interface{}{severity, messages}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *RecordingConsensusClient) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	_, err := r.inner.ClearLagThreshold().Await(context.Background())
+	r.record("ClearLagThreshold", []interface{}{}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *RecordingConsensusClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	_, err := r.inner.WriteMessageFromSequencer(pos, msgWithMeta, msgResult).Await(context.Background())
+	r.record("WriteMessageFromSequencer", []interface{}{pos, msgWithMeta, msgResult}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *RecordingConsensusClient) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	committed, err := r.inner.WriteMessageFromSequencerWithDeadline(pos, msgWithMeta, msgResult, deadline).Await(context.Background())
+	// The deadline is relative to the wall clock of the recording, so replay can't match on it
+	r.record("WriteMessageFromSequencerWithDeadline", []interface{}{pos, msgWithMeta, msgResult}, committed, err)
+	return containers.NewReadyPromise(committed, err)
+}
+
+func (r *RecordingConsensusClient) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	_, err := r.inner.WriteMessageFromSequencerIdempotent(pos, msgWithMeta, msgResult, key).Await(context.Background())
+	r.record("WriteMessageFromSequencerIdempotent", []interface{}{pos, msgWithMeta, msgResult, key}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
+
+func (r *RecordingConsensusClient) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	backlog, err := r.inner.SequencerWriteBacklog().Await(context.Background())
+	r.record("SequencerWriteBacklog", []interface{}{}, backlog, err)
+	return containers.NewReadyPromise(backlog, err)
+}
+
+func (r *RecordingConsensusClient) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	result, err := r.inner.DrainSequencerQueue(ctx).Await(ctx)
+	r.record("DrainSequencerQueue", []interface{}{}, result, err)
+	return containers.NewReadyPromise(result, err)
+}
+
+func (r *RecordingConsensusClient) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	estimate, err := r.inner.ComputeMessageL1Fee(msgWithMeta).Await(context.Background())
+	r.record("ComputeMessageL1Fee", []interface{}{msgWithMeta}, estimate, err)
+	return containers.NewReadyPromise(estimate, err)
+}
+
+func (r *RecordingConsensusClient) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	_, err := r.inner.ExpectChosenSequencer().Await(context.Background())
+	r.record("ExpectChosenSequencer", []interface{}{}, nil, err)
+	return containers.NewReadyPromise(struct{}{}, err)
+}
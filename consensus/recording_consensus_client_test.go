@@ -0,0 +1,171 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/consensus"
+	"github.com/offchainlabs/nitro/consensus/consensustest"
+	"github.com/offchainlabs/nitro/execution"
+)
+
+func TestRecordAndReplayConsensusClient(t *testing.T) {
+	ctx := context.Background()
+	fake := consensustest.NewFakeConsensusClient()
+	fake.AddMessages(make([]arbostypes.MessageWithMetadata, 2)...)
+	err := fake.AddBatches(consensustest.FakeBatch{Data: bytes.Repeat([]byte{1}, 1000), BlockHash: common.HexToHash("0x1234"), ParentChainBlock: 5, MessageCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	config := consensus.RecorderConfig{Directory: dir, MaxFileSize: 512}
+	recorder, err := consensus.NewRecordingConsensusClient(fake, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type results struct {
+		batch       execution.FetchedBatch
+		notFoundErr error
+		writeErr    error
+		accHash     common.Hash
+		verifyErr   error
+		reportErr   error
+	}
+	calls := func(client execution.FullConsensusClient) results {
+		var res results
+		var err error
+		res.batch, err = client.FetchBatch(ctx, 0).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, res.notFoundErr = client.GetBatchSize(ctx, 1).Await(ctx)
+		if _, err := client.WriteMessageFromSequencer(2, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx); err != nil {
+			t.Fatal(err)
+		}
+		_, res.writeErr = client.WriteMessageFromSequencer(2, arbostypes.EmptyTestMessageWithMetadata, execution.MessageResult{}).Await(ctx)
+		res.accHash, err = client.GetMessageAccHash(2).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, res.verifyErr = client.VerifyExecutionCheckpoint(1, common.HexToHash("0x5678")).Await(ctx)
+		_, res.reportErr = client.GetBatchPostingReport(1).Await(ctx)
+		return res
+	}
+	recorded := calls(recorder)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Err() == nil {
+		t.Fatal("recording not stopped by close")
+	}
+	if _, err := fake.GetBatchCount().Await(ctx); !errors.Is(err, consensus.ErrClientClosed) {
+		t.Fatal("recorded client not closed by close", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatal("recording not rotated, files:", files)
+	}
+
+	replay, err := consensus.NewReplayConsensusClient(&consensus.ReplayConfig{Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := calls(replay)
+	if !bytes.Equal(replayed.batch.Data, recorded.batch.Data) || replayed.batch.BlockHash != recorded.batch.BlockHash || replayed.accHash != recorded.accHash {
+		t.Fatal("replayed batch doesn't match recording")
+	}
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if !errors.As(replayed.notFoundErr, &notYetPostedErr) || notYetPostedErr.BatchNum != 1 {
+		t.Fatal("replayed error lost its type", replayed.notFoundErr)
+	}
+	var conflictErr *consensus.ErrConflictingMessage
+	if !errors.As(replayed.writeErr, &conflictErr) || conflictErr.Pos != 2 || conflictErr.Expected != 3 {
+		t.Fatal("replayed write error lost its type", replayed.writeErr)
+	}
+	var checkpointErr *consensus.ErrCheckpointMismatch
+	if !errors.As(replayed.verifyErr, &checkpointErr) || checkpointErr.Pos != 1 || checkpointErr.BlockHash != common.HexToHash("0x5678") {
+		t.Fatal("replayed checkpoint error lost its type", replayed.verifyErr)
+	}
+	if !errors.As(replayed.reportErr, &notYetPostedErr) || notYetPostedErr.BatchNum != 1 {
+		t.Fatal("replayed posting report error lost its type", replayed.reportErr)
+	}
+	if !replay.Done() || replay.Err() != nil {
+		t.Fatal("replay not done", replay.Err())
+	}
+
+	// A call that's not in the recording fails, and so does everything after it
+	if _, err := replay.GetBatchCount().Await(ctx); !errors.Is(err, consensus.ErrReplayDiverged) {
+		t.Fatal("expected divergence after end of recording, got", err)
+	}
+	replay, err = consensus.NewReplayConsensusClient(&consensus.ReplayConfig{Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.FetchBatch(ctx, 1).Await(ctx); !errors.Is(err, consensus.ErrReplayDiverged) {
+		t.Fatal("expected divergence for different arguments, got", err)
+	}
+	if _, err := replay.FetchBatch(ctx, 0).Await(ctx); !errors.Is(err, consensus.ErrReplayDiverged) {
+		t.Fatal("replay continued after divergence", err)
+	}
+	if err := replay.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.GetBatchCount().Await(ctx); !errors.Is(err, consensus.ErrClientClosed) {
+		t.Fatal("expected closed replay to fail with client closed, got", err)
+	}
+}
+
+func TestReplayConsensusClientTimeScale(t *testing.T) {
+	ctx := context.Background()
+	fake := consensustest.NewFakeConsensusClient()
+	dir := t.TempDir()
+	recorder, err := consensus.NewRecordingConsensusClient(fake, &consensus.RecorderConfig{Directory: dir, MaxFileSize: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const gap = 200 * time.Millisecond
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(gap)
+		}
+		if _, err := recorder.GetBatchCount().Await(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying at half the recorded pace spreads the calls twice as far apart
+	replay, err := consensus.NewReplayConsensusClient(&consensus.ReplayConfig{Directory: dir, TimeScale: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := replay.GetBatchCount().Await(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*gap {
+		t.Fatal("replay at time scale 2 took", elapsed, "for calls recorded", gap, "apart")
+	}
+
+	if _, err := consensus.NewReplayConsensusClient(&consensus.ReplayConfig{Directory: dir, TimeScale: -1}); err == nil {
+		t.Fatal("expected a negative time scale to be rejected")
+	}
+}
@@ -0,0 +1,490 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var ErrReplayDiverged = errors.New("call diverged from consensus recording")
+
+// ReplayConfig configures a ReplayConsensusClient. Directory holds the recording files.
+// If TimeScale is positive, each replayed call returns no earlier than it did in the recording,
+// relative to the first call, with the time between calls multiplied by TimeScale: 1 replays in
+// real time, 0.5 twice as fast and 2 half as fast. If it's zero, calls return immediately.
+type ReplayConfig struct {
+	Directory string
+	TimeScale float64
+}
+
+func (c *ReplayConfig) Validate() error {
+	if c.Directory == "" {
+		return errors.New("consensus replay directory must be set")
+	}
+	if c.TimeScale < 0 {
+		return errors.New("consensus replay time scale must not be negative")
+	}
+	return nil
+}
+
+// ReplayConsensusClient serves the calls of a recording made by RecordingConsensusClient, in
+// recorded order, streaming the recording files rather than loading them.
+// A call whose method or arguments differ from the next recorded call, or made after the
+// recording is exhausted, fails with ErrReplayDiverged, and so does every call after it.
+type ReplayConsensusClient struct {
+	timeScale float64
+
+	mutex     sync.Mutex
+	files     []string
+	fileIndex int
+	file      *os.File
+	decoder   *json.Decoder
+	calls     uint64
+	err       error
+	closed    bool
+	// when the first call was recorded and replayed, to pace the later ones
+	firstRecorded time.Time
+	firstReplayed time.Time
+}
+
+var _ execution.FullConsensusClient = (*ReplayConsensusClient)(nil)
+
+func NewReplayConsensusClient(config *ReplayConfig) (*ReplayConsensusClient, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(config.Directory, "consensus-recording-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no consensus recording files in %s", config.Directory)
+	}
+	sort.Strings(files)
+	return &ReplayConsensusClient{timeScale: config.TimeScale, files: files}, nil
+}
+
+// Err returns the divergence (or error reading the recording) that stopped the replay, if any.
+func (r *ReplayConsensusClient) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
+// Done returns whether every recorded call was replayed.
+func (r *ReplayConsensusClient) Done() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return false
+	}
+	for {
+		if r.decoder != nil && r.decoder.More() {
+			return false
+		}
+		if r.fileIndex >= len(r.files) {
+			return true
+		}
+		if err := r.openNextFile(); err != nil {
+			r.err = err
+			return false
+		}
+	}
+}
+
+// Close fails the calls after it with ErrClientClosed, rather than replaying them
+func (r *ReplayConsensusClient) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.closed = true
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.decoder = nil
+	return err
+}
+
+// The mutex must be held
+func (r *ReplayConsensusClient) openNextFile() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+	file, err := os.Open(r.files[r.fileIndex])
+	if err != nil {
+		return err
+	}
+	r.fileIndex++
+	r.file = file
+	r.decoder = json.NewDecoder(file)
+	return nil
+}
+
+// The mutex must be held
+func (r *ReplayConsensusClient) nextCall() (*RecordedCall, error) {
+	for {
+		if r.decoder != nil {
+			var call RecordedCall
+			err := r.decoder.Decode(&call)
+			if err == nil {
+				return &call, nil
+			}
+			if !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("reading %s: %w", r.files[r.fileIndex-1], err)
+			}
+		}
+		if r.fileIndex >= len(r.files) {
+			return nil, nil
+		}
+		if err := r.openNextFile(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replay matches the call against the next recorded one, decoding its result into result
+// (unless nil) and returning its error, once the call is due if the replay is paced.
+func (r *ReplayConsensusClient) replay(method string, args []interface{}, result interface{}) error {
+	delay, err := r.replayCall(method, args, result)
+	if delay > 0 {
+		// Slept without the mutex, so later calls are matched in order but paced independently
+		time.Sleep(delay)
+	}
+	return err
+}
+
+// The mutex must be held
+func (r *ReplayConsensusClient) delayLocked(recorded time.Time) time.Duration {
+	if r.timeScale == 0 {
+		return 0
+	}
+	now := time.Now()
+	if r.firstReplayed.IsZero() {
+		r.firstRecorded = recorded
+		r.firstReplayed = now
+		return 0
+	}
+	due := r.firstReplayed.Add(time.Duration(float64(recorded.Sub(r.firstRecorded)) * r.timeScale))
+	return due.Sub(now)
+}
+
+// replayCall returns how long to wait before returning the matched call's error
+func (r *ReplayConsensusClient) replayCall(method string, args []interface{}, result interface{}) (time.Duration, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return 0, execution.ErrClientClosed
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return 0, err
+	}
+	call, err := r.nextCall()
+	if err != nil {
+		r.err = err
+		return 0, err
+	}
+	callNum := r.calls
+	r.calls++
+	if call == nil {
+		r.err = fmt.Errorf("%w: call %d %s%s made after the end of the recording", ErrReplayDiverged, callNum, method, encodedArgs)
+	} else if call.Method != method || !bytes.Equal(call.Args, encodedArgs) {
+		r.err = fmt.Errorf("%w: call %d is %s%s, but %s%s was recorded", ErrReplayDiverged, callNum, method, encodedArgs, call.Method, call.Args)
+	}
+	if r.err != nil {
+		log.Error("consensus replay diverged", "err", r.err)
+		return 0, r.err
+	}
+	if result != nil && call.Error == nil && len(call.Result) > 0 {
+		if err := json.Unmarshal(call.Result, result); err != nil {
+			r.err = fmt.Errorf("decoding recorded result of call %d %s: %w", callNum, method, err)
+			return 0, r.err
+		}
+	}
+	return r.delayLocked(call.Time), call.Error.toError()
+}
+
+func (r *ReplayConsensusClient) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	var batch execution.FetchedBatch
+	err := r.replay("FetchBatch", []interface{}{batchNum}, &batch)
+	return containers.NewReadyPromise(batch, err)
+}
+
+func (r *ReplayConsensusClient) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	var chunk []byte
+	err := r.replay("FetchBatchChunk", []interface{}{batchNum, offset, length}, &chunk)
+	return containers.NewReadyPromise(chunk, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	var size uint64
+	err := r.replay("GetBatchSize", []interface{}{batchNum}, &size)
+	return containers.NewReadyPromise(size, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchCount() containers.PromiseInterface[uint64] {
+	var count uint64
+	err := r.replay("GetBatchCount", []interface{}{}, &count)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *ReplayConsensusClient) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	var lookup execution.BatchLookup
+	err := r.replay("FindInboxBatchContainingMessage", []interface{}{message}, &lookup)
+	return containers.NewReadyPromise(lookup, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	var block uint64
+	err := r.replay("GetBatchParentChainBlock", []interface{}{seqNum}, &block)
+	return containers.NewReadyPromise(block, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	var blocks execution.BatchParentChainBlocks
+	err := r.replay("GetBatchParentChainBlocks", []interface{}{first, last}, &blocks)
+	return containers.NewReadyPromise(blocks, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	var messageRange execution.MessageRange
+	err := r.replay("GetBatchMessageRange", []interface{}{batchNum}, &messageRange)
+	return containers.NewReadyPromise(messageRange, err)
+}
+
+func (r *ReplayConsensusClient) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	var info execution.L1Info
+	err := r.replay("GetMessageL1Info", []interface{}{pos}, &info)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *ReplayConsensusClient) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	var batches []uint64
+	err := r.replay("FindBatchesInParentChainRange", []interface{}{firstBlock, lastBlock}, &batches)
+	return containers.NewReadyPromise(batches, err)
+}
+
+func (r *ReplayConsensusClient) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("PrefetchBatches", []interface{}{first, last}, nil))
+}
+
+func (r *ReplayConsensusClient) Synced() containers.PromiseInterface[bool] {
+	var synced bool
+	err := r.replay("Synced", []interface{}{}, &synced)
+	return containers.NewReadyPromise(synced, err)
+}
+
+func (r *ReplayConsensusClient) Healthy() containers.PromiseInterface[execution.HealthStatus] {
+	var health execution.HealthStatus
+	err := r.replay("Healthy", []interface{}{}, &health)
+	return containers.NewReadyPromise(health, err)
+}
+
+// Ping returns the recorded time, not the current one.
+func (r *ReplayConsensusClient) Ping(ctx context.Context) containers.PromiseInterface[execution.PingResult] {
+	var result execution.PingResult
+	err := r.replay("Ping", []interface{}{}, &result)
+	return containers.NewReadyPromise(result, err)
+}
+
+func (r *ReplayConsensusClient) SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[execution.SyncProgressSnapshot] {
+	var snapshot execution.SyncProgressSnapshot
+	err := r.replay("SyncProgressSnapshot", []interface{}{}, &snapshot)
+	return containers.NewReadyPromise(snapshot, err)
+}
+
+// FullSyncProgressMap returns the recorded map as decoded from JSON, so numbers are float64.
+func (r *ReplayConsensusClient) FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}] {
+	var progress map[string]interface{}
+	err := r.replay("FullSyncProgressMap", []interface{}{}, &progress)
+	return containers.NewReadyPromise(progress, err)
+}
+
+func (r *ReplayConsensusClient) SyncTargetMessageCount() containers.PromiseInterface[execution.SyncTarget] {
+	var target execution.SyncTarget
+	err := r.replay("SyncTargetMessageCount", []interface{}{}, &target)
+	return containers.NewReadyPromise(target, err)
+}
+
+func (r *ReplayConsensusClient) CatchUpEstimate() containers.PromiseInterface[execution.CatchUpEstimate] {
+	var estimate execution.CatchUpEstimate
+	err := r.replay("CatchUpEstimate", []interface{}{}, &estimate)
+	return containers.NewReadyPromise(estimate, err)
+}
+
+func (r *ReplayConsensusClient) Capabilities() containers.PromiseInterface[execution.CapabilitySet] {
+	var capabilities execution.CapabilitySet
+	err := r.replay("Capabilities", []interface{}{}, &capabilities)
+	return containers.NewReadyPromise(capabilities, err)
+}
+
+func (r *ReplayConsensusClient) FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64] {
+	var batches []uint64
+	err := r.replay("FindBatchesContainingKind", []interface{}{first, last, kind}, &batches)
+	return containers.NewReadyPromise(batches, err)
+}
+
+func (r *ReplayConsensusClient) GetSyncMode() containers.PromiseInterface[execution.SyncMode] {
+	var mode execution.SyncMode
+	err := r.replay("GetSyncMode", []interface{}{}, &mode)
+	return containers.NewReadyPromise(mode, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchPostingLag() containers.PromiseInterface[execution.PostingLag] {
+	var lag execution.PostingLag
+	err := r.replay("GetBatchPostingLag", []interface{}{}, &lag)
+	return containers.NewReadyPromise(lag, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchCompressionStats() containers.PromiseInterface[execution.BatchCompressionStats] {
+	var stats execution.BatchCompressionStats
+	err := r.replay("GetBatchCompressionStats", []interface{}{}, &stats)
+	return containers.NewReadyPromise(stats, err)
+}
+
+func (r *ReplayConsensusClient) MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64] {
+	var block uint64
+	err := r.replay("MessageIndexToBlockNumber", []interface{}{pos}, &block)
+	return containers.NewReadyPromise(block, err)
+}
+
+func (r *ReplayConsensusClient) GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash] {
+	var hash common.Hash
+	err := r.replay("GetMessageAccHash", []interface{}{pos}, &hash)
+	return containers.NewReadyPromise(hash, err)
+}
+
+func (r *ReplayConsensusClient) GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[execution.CheckpointInfo] {
+	var checkpoint execution.CheckpointInfo
+	err := r.replay("GetCheckpointInfo", []interface{}{}, &checkpoint)
+	return containers.NewReadyPromise(checkpoint, err)
+}
+
+func (r *ReplayConsensusClient) VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("VerifyExecutionCheckpoint", []interface{}{pos, blockHash}, nil))
+}
+
+func (r *ReplayConsensusClient) GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[execution.PostingReportInfo] {
+	var report execution.PostingReportInfo
+	err := r.replay("GetBatchPostingReport", []interface{}{seqNum}, &report)
+	return containers.NewReadyPromise(report, err)
+}
+
+func (r *ReplayConsensusClient) GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]execution.PostingReportInfo] {
+	var reports []execution.PostingReportInfo
+	err := r.replay("GetBatchPostingReports", []interface{}{first, last}, &reports)
+	return containers.NewReadyPromise(reports, err)
+}
+
+func (r *ReplayConsensusClient) BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex] {
+	var pos arbutil.MessageIndex
+	err := r.replay("BlockNumberToMessageIndex", []interface{}{block}, &pos)
+	return containers.NewReadyPromise(pos, err)
+}
+
+func (r *ReplayConsensusClient) GetChainSpec(ctx context.Context) containers.PromiseInterface[execution.ChainSpec] {
+	var spec execution.ChainSpec
+	err := r.replay("GetChainSpec", []interface{}{}, &spec)
+	return containers.NewReadyPromise(spec, err)
+}
+
+func (r *ReplayConsensusClient) GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[execution.SafeMsgInfo] {
+	var info execution.SafeMsgInfo
+	err := r.replay("GetSafeMsgCount", []interface{}{}, &info)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *ReplayConsensusClient) GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[execution.FinalizedMsgInfo] {
+	var info execution.FinalizedMsgInfo
+	err := r.replay("GetFinalizedMsgCount", []interface{}{}, &info)
+	return containers.NewReadyPromise(info, err)
+}
+
+func (r *ReplayConsensusClient) GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	var count execution.MsgCountWithHash
+	err := r.replay("GetSafeMsgCountWithHash", []interface{}{}, &count)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *ReplayConsensusClient) GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[execution.MsgCountWithHash] {
+	var count execution.MsgCountWithHash
+	err := r.replay("GetFinalizedMsgCountWithHash", []interface{}{}, &count)
+	return containers.NewReadyPromise(count, err)
+}
+
+func (r *ReplayConsensusClient) ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex] {
+	var count arbutil.MessageIndex
+	err := r.replay("ValidatedMessageCount", []interface{}{}, &count)
+	return containers.NewReadyPromise(count, err)
+}
+
+// SetLagThreshold replays the recorded result, but the callbacks are never called.
+func (r *ReplayConsensusClient) SetLagThreshold(severity execution.LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("SetLagThreshold", []interface{}{severity, messages}, nil))
+}
+
+func (r *ReplayConsensusClient) ClearLagThreshold() containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("ClearLagThreshold", []interface{}{}, nil))
+}
+
+func (r *ReplayConsensusClient) WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("WriteMessageFromSequencer", []interface{}{pos, msgWithMeta, msgResult}, nil))
+}
+
+func (r *ReplayConsensusClient) WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, deadline time.Time) containers.PromiseInterface[time.Time] {
+	var committed time.Time
+	err := r.replay("WriteMessageFromSequencerWithDeadline", []interface{}{pos, msgWithMeta, msgResult}, &committed)
+	return containers.NewReadyPromise(committed, err)
+}
+
+func (r *ReplayConsensusClient) WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult execution.MessageResult, key string) containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("WriteMessageFromSequencerIdempotent", []interface{}{pos, msgWithMeta, msgResult, key}, nil))
+}
+
+func (r *ReplayConsensusClient) SequencerWriteBacklog() containers.PromiseInterface[execution.BacklogStatus] {
+	var backlog execution.BacklogStatus
+	err := r.replay("SequencerWriteBacklog", []interface{}{}, &backlog)
+	return containers.NewReadyPromise(backlog, err)
+}
+
+func (r *ReplayConsensusClient) DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[execution.DrainResult] {
+	var result execution.DrainResult
+	err := r.replay("DrainSequencerQueue", []interface{}{}, &result)
+	return containers.NewReadyPromise(result, err)
+}
+
+func (r *ReplayConsensusClient) ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[execution.MessageFeeEstimate] {
+	var estimate execution.MessageFeeEstimate
+	err := r.replay("ComputeMessageL1Fee", []interface{}{msgWithMeta}, &estimate)
+	return containers.NewReadyPromise(estimate, err)
+}
+
+func (r *ReplayConsensusClient) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, r.replay("ExpectChosenSequencer", []interface{}{}, nil))
+}
@@ -0,0 +1,82 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/offchainlabs/nitro/execution"
+)
+
+// SequencerElectionOptions configures WaitForChosenSequencer. The sequencer is polled every
+// PollInterval, and counts as chosen once RequireConsecutiveConfirmations polls in a row found it
+// chosen. If MaxWait is positive, waiting fails once it passed. OnElectionPending, if set, is
+// called after every poll that found the sequencer not chosen.
+type SequencerElectionOptions struct {
+	PollInterval                    time.Duration
+	MaxWait                         time.Duration
+	OnElectionPending               func(waitedSoFar time.Duration)
+	RequireConsecutiveConfirmations int
+}
+
+var DefaultSequencerElectionOptions = SequencerElectionOptions{
+	PollInterval:                    500 * time.Millisecond,
+	MaxWait:                         0,
+	RequireConsecutiveConfirmations: 1,
+}
+
+func (o *SequencerElectionOptions) Validate() error {
+	if o.PollInterval <= 0 {
+		return errors.New("sequencer election poll interval must be positive")
+	}
+	if o.MaxWait < 0 {
+		return errors.New("sequencer election max wait must not be negative")
+	}
+	if o.RequireConsecutiveConfirmations < 1 {
+		return errors.New("sequencer election must require at least one confirmation")
+	}
+	return nil
+}
+
+// WaitForChosenSequencer polls sequencer.ExpectChosenSequencer until it's the chosen sequencer.
+// ExpectChosenSequencer itself doesn't wait: it only reads the coordinator's local view of
+// whether this node holds the sequencer lock. Errors other than ErrSequencerNotActive are
+// returned immediately.
+func WaitForChosenSequencer(ctx context.Context, sequencer execution.ConsensusSequencer, opts *SequencerElectionOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	start := time.Now()
+	confirmations := 0
+	for {
+		_, err := sequencer.ExpectChosenSequencer().Await(ctx)
+		if err == nil {
+			confirmations++
+			if confirmations >= opts.RequireConsecutiveConfirmations {
+				return nil
+			}
+		} else if !errors.Is(err, ErrSequencerNotActive) {
+			return err
+		} else {
+			confirmations = 0
+			waited := time.Since(start)
+			if opts.MaxWait > 0 && waited >= opts.MaxWait {
+				return fmt.Errorf("still not the chosen sequencer after %v: %w", waited, err)
+			}
+			if opts.OnElectionPending != nil {
+				opts.OnElectionPending(waited)
+			}
+		}
+		timer := time.NewTimer(opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
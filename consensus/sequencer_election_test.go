@@ -0,0 +1,79 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type electionSequencer struct {
+	// calls to methods other than ExpectChosenSequencer panic
+	execution.ConsensusSequencer
+	// the result of each poll, repeating the last one once exhausted
+	chosen []bool
+	err    error
+	polls  int
+}
+
+func (s *electionSequencer) ExpectChosenSequencer() containers.PromiseInterface[struct{}] {
+	return containers.NewReadyPromise(struct{}{}, s.poll())
+}
+
+func (s *electionSequencer) poll() error {
+	if s.err != nil {
+		return s.err
+	}
+	chosen := s.chosen[len(s.chosen)-1]
+	if s.polls < len(s.chosen) {
+		chosen = s.chosen[s.polls]
+	}
+	s.polls++
+	if !chosen {
+		return NewSequencerNotActiveError("not main sequencer")
+	}
+	return nil
+}
+
+func TestWaitForChosenSequencer(t *testing.T) {
+	ctx := context.Background()
+	opts := SequencerElectionOptions{PollInterval: time.Millisecond, RequireConsecutiveConfirmations: 2}
+	var pending int
+	opts.OnElectionPending = func(time.Duration) { pending++ }
+
+	// A single poll finding the sequencer chosen isn't enough, so the flap is waited out
+	sequencer := &electionSequencer{chosen: []bool{false, true, false, true, true, false}}
+	if err := WaitForChosenSequencer(ctx, sequencer, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if sequencer.polls != 5 || pending != 2 {
+		t.Fatal("unexpected polls", sequencer.polls, "and pending callbacks", pending)
+	}
+
+	opts.MaxWait = 20 * time.Millisecond
+	err := WaitForChosenSequencer(ctx, &electionSequencer{chosen: []bool{false}}, &opts)
+	if !errors.Is(err, ErrSequencerNotActive) {
+		t.Fatal("expected waiting past max wait to fail with sequencer not active, got", err)
+	}
+
+	errOther := errors.New("other failure")
+	if err := WaitForChosenSequencer(ctx, &electionSequencer{err: errOther}, &opts); !errors.Is(err, errOther) {
+		t.Fatal("expected other errors to be returned immediately, got", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	opts.MaxWait = 0
+	if err := WaitForChosenSequencer(cancelled, &electionSequencer{chosen: []bool{false}}, &opts); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected cancellation, got", err)
+	}
+	if err := WaitForChosenSequencer(ctx, sequencer, &SequencerElectionOptions{PollInterval: time.Millisecond}); err == nil {
+		t.Fatal("expected options without confirmations to be rejected")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	tieredFetcherFastHitCounter = metrics.NewRegisteredCounter("arb/consensus/tiered_fetcher/fast/hits", nil)
+	tieredFetcherSlowHitCounter = metrics.NewRegisteredCounter("arb/consensus/tiered_fetcher/slow/hits", nil)
+)
+
+// BatchStore is the fast tier of a TieredBatchFetcher, holding batches already fetched from the
+// slow tier. It must be safe for concurrent use.
+type BatchStore interface {
+	GetBatch(batchNum uint64) ([]byte, common.Hash, bool)
+	PutBatch(batchNum uint64, data []byte, blockHash common.Hash)
+	RemoveBatch(batchNum uint64)
+}
+
+type storedBatch struct {
+	data      []byte
+	blockHash common.Hash
+}
+
+// LruBatchStore is an in-memory BatchStore keeping the most recently used batches.
+type LruBatchStore struct {
+	mutex sync.Mutex
+	cache *containers.LruCache[uint64, storedBatch]
+}
+
+var _ BatchStore = (*LruBatchStore)(nil)
+
+func NewLruBatchStore(size int) *LruBatchStore {
+	return &LruBatchStore{cache: containers.NewLruCache[uint64, storedBatch](size)}
+}
+
+func (s *LruBatchStore) GetBatch(batchNum uint64) ([]byte, common.Hash, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	batch, ok := s.cache.Get(batchNum)
+	return batch.data, batch.blockHash, ok
+}
+
+func (s *LruBatchStore) PutBatch(batchNum uint64, data []byte, blockHash common.Hash) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cache.Add(batchNum, storedBatch{data: data, blockHash: blockHash})
+}
+
+func (s *LruBatchStore) RemoveBatch(batchNum uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cache.Remove(batchNum)
+}
+
+type TieredFetchStats struct {
+	FastHits uint64
+	SlowHits uint64
+}
+
+// TieredBatchFetcher serves batch data from a fast tier when it has the batch, and otherwise falls
+// through to a slow tier, adding what the slow tier returns to the fast tier. Calls for anything
+// other than batch data always go to the slow tier. The fast tier isn't told about reorgs, so
+// batches posted again must be dropped from it with Invalidate. Batch data served from the fast
+// tier is shared with it, so callers must not modify it.
+type TieredBatchFetcher struct {
+	fast BatchStore
+	slow execution.BatchFetcher
+
+	fastHits atomic.Uint64
+	slowHits atomic.Uint64
+}
+
+var _ execution.BatchFetcher = (*TieredBatchFetcher)(nil)
+
+func NewTieredBatchFetcher(fast BatchStore, slow execution.BatchFetcher) *TieredBatchFetcher {
+	return &TieredBatchFetcher{
+		fast: fast,
+		slow: slow,
+	}
+}
+
+// Stats returns how many batches each tier has served since the fetcher was created.
+func (t *TieredBatchFetcher) Stats() TieredFetchStats {
+	return TieredFetchStats{
+		FastHits: t.fastHits.Load(),
+		SlowHits: t.slowHits.Load(),
+	}
+}
+
+// Invalidate drops the batch from the fast tier, so it's fetched from the slow tier again.
+func (t *TieredBatchFetcher) Invalidate(batchNum uint64) {
+	t.fast.RemoveBatch(batchNum)
+}
+
+func (t *TieredBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	if data, blockHash, ok := t.fast.GetBatch(batchNum); ok {
+		t.fastHits.Add(1)
+		tieredFetcherFastHitCounter.Inc(1)
+		return containers.NewReadyPromise(execution.FetchedBatch{Data: data, BlockHash: blockHash}, nil)
+	}
+	batch, err := t.slow.FetchBatch(ctx, batchNum).Await(ctx)
+	if err != nil {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, err)
+	}
+	t.slowHits.Add(1)
+	tieredFetcherSlowHitCounter.Inc(1)
+	t.fast.PutBatch(batchNum, batch.Data, batch.BlockHash)
+	return containers.NewReadyPromise(batch, nil)
+}
+
+func (t *TieredBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	if data, _, ok := t.fast.GetBatch(batchNum); ok {
+		t.fastHits.Add(1)
+		tieredFetcherFastHitCounter.Inc(1)
+		size := uint64(len(data))
+		if offset >= size {
+			return containers.NewReadyPromise[[]byte](nil, fmt.Errorf("%w: offset %d, batch %d has %d bytes", execution.ErrBatchOffsetOutOfRange, offset, batchNum, size))
+		}
+		end := size
+		if length < size-offset {
+			end = offset + length
+		}
+		return containers.NewReadyPromise(data[offset:end], nil)
+	}
+	// Chunks are fetched to avoid fetching the whole batch, so they aren't added to the fast tier
+	return t.slow.FetchBatchChunk(ctx, batchNum, offset, length)
+}
+
+func (t *TieredBatchFetcher) GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64] {
+	if data, _, ok := t.fast.GetBatch(batchNum); ok {
+		return containers.NewReadyPromise(uint64(len(data)), nil)
+	}
+	return t.slow.GetBatchSize(ctx, batchNum)
+}
+
+func (t *TieredBatchFetcher) GetBatchCount() containers.PromiseInterface[uint64] {
+	return t.slow.GetBatchCount()
+}
+
+func (t *TieredBatchFetcher) FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[execution.BatchLookup] {
+	return t.slow.FindInboxBatchContainingMessage(message)
+}
+
+func (t *TieredBatchFetcher) GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64] {
+	return t.slow.GetBatchParentChainBlock(seqNum)
+}
+
+func (t *TieredBatchFetcher) GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[execution.BatchParentChainBlocks] {
+	return t.slow.GetBatchParentChainBlocks(first, last)
+}
+
+func (t *TieredBatchFetcher) GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[execution.MessageRange] {
+	return t.slow.GetBatchMessageRange(batchNum)
+}
+
+func (t *TieredBatchFetcher) GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[execution.L1Info] {
+	return t.slow.GetMessageL1Info(ctx, pos)
+}
+
+func (t *TieredBatchFetcher) FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64] {
+	return t.slow.FindBatchesInParentChainRange(firstBlock, lastBlock)
+}
+
+func (t *TieredBatchFetcher) PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}] {
+	return t.slow.PrefetchBatches(first, last)
+}
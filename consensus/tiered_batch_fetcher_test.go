@@ -0,0 +1,102 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/execution"
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+type slowBatchFetcher struct {
+	// calls to methods other than FetchBatch and FetchBatchChunk panic
+	execution.BatchFetcher
+	batches map[uint64][]byte
+	fetches int
+}
+
+func (f *slowBatchFetcher) FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[execution.FetchedBatch] {
+	f.fetches++
+	data, ok := f.batches[batchNum]
+	if !ok {
+		return containers.NewReadyPromise(execution.FetchedBatch{}, &execution.ErrBatchNotYetPosted{BatchNum: batchNum})
+	}
+	return containers.NewReadyPromise(execution.FetchedBatch{Data: data, BlockHash: common.BytesToHash(data)}, nil)
+}
+
+func (f *slowBatchFetcher) FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte] {
+	f.fetches++
+	return containers.NewReadyPromise(f.batches[batchNum][offset:offset+length], nil)
+}
+
+func TestTieredBatchFetcher(t *testing.T) {
+	ctx := context.Background()
+	slow := &slowBatchFetcher{batches: map[uint64][]byte{1: []byte("batch one"), 2: []byte("batch two")}}
+	fetcher := NewTieredBatchFetcher(NewLruBatchStore(10), slow)
+
+	// The first fetch falls through to the slow tier, and later ones are served by the fast tier
+	for i := 0; i < 3; i++ {
+		batch, err := fetcher.FetchBatch(ctx, 1).Await(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(batch.Data) != "batch one" || batch.BlockHash != common.BytesToHash(batch.Data) {
+			t.Fatal("unexpected batch", string(batch.Data), batch.BlockHash)
+		}
+	}
+	if slow.fetches != 1 {
+		t.Fatal("slow tier fetched", slow.fetches, "times, expected once")
+	}
+	if stats := fetcher.Stats(); stats.FastHits != 2 || stats.SlowHits != 1 {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// Chunks of batches in the fast tier are served from it
+	chunk, err := fetcher.FetchBatchChunk(ctx, 1, 6, 100).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chunk) != "one" || slow.fetches != 1 {
+		t.Fatal("unexpected chunk", string(chunk), "after", slow.fetches, "slow fetches")
+	}
+	if _, err := fetcher.FetchBatchChunk(ctx, 1, 9, 1).Await(ctx); !errors.Is(err, execution.ErrBatchOffsetOutOfRange) {
+		t.Fatal("expected an out of range offset to fail, got", err)
+	}
+	// and chunks of other batches from the slow tier, without adding them to the fast tier
+	if chunk, err := fetcher.FetchBatchChunk(ctx, 2, 0, 5).Await(ctx); err != nil || string(chunk) != "batch" {
+		t.Fatal("unexpected chunk", string(chunk), err)
+	}
+	if _, _, ok := fetcher.fast.GetBatch(2); ok {
+		t.Fatal("chunk added to the fast tier")
+	}
+	size, err := fetcher.GetBatchSize(ctx, 1).Await(ctx)
+	if err != nil || size != 9 {
+		t.Fatal("unexpected batch size", size, err)
+	}
+
+	// Failed fetches aren't stored
+	var notYetPostedErr *execution.ErrBatchNotYetPosted
+	if _, err := fetcher.FetchBatch(ctx, 3).Await(ctx); !errors.As(err, &notYetPostedErr) {
+		t.Fatal("expected fetching a batch not yet posted to fail, got", err)
+	}
+	if _, _, ok := fetcher.fast.GetBatch(3); ok {
+		t.Fatal("failed fetch added to the fast tier")
+	}
+
+	// Invalidated batches are fetched from the slow tier again
+	slow.batches[1] = []byte("reorged batch one")
+	fetcher.Invalidate(1)
+	batch, err := fetcher.FetchBatch(ctx, 1).Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(batch.Data) != "reorged batch one" {
+		t.Fatal("invalidated batch served from the fast tier", string(batch.Data))
+	}
+}
@@ -85,6 +85,9 @@ func (r *BlockRecorder) RecordBlockCreation(
 
 	recordingdb, chaincontext, recordingKV, err := r.recordingDatabase.PrepareRecording(ctx, prevHeader, stateLogFunc)
 	if err != nil {
+		if prevHeader != nil && !r.execEngine.bc.HasState(prevHeader.Root) {
+			return nil, fmt.Errorf("%w: %w", &execution.ErrRecordingStatePruned{Pos: pos}, err)
+		}
 		return nil, err
 	}
 	defer func() { r.recordingDatabase.Dereference(prevHeader) }()
@@ -123,16 +126,16 @@ func (r *BlockRecorder) RecordBlockCreation(
 	var readBatchInfo []validator.BatchInfo
 	if msg != nil {
 		batchFetcher := func(batchNum uint64) ([]byte, error) {
-			data, blockHash, err := r.execEngine.consensus.FetchBatch(ctx, batchNum)
+			batch, err := r.execEngine.consensus.FetchBatch(ctx, batchNum).Await(ctx)
 			if err != nil {
 				return nil, err
 			}
 			readBatchInfo = append(readBatchInfo, validator.BatchInfo{
 				Number:    batchNum,
-				BlockHash: blockHash,
-				Data:      data,
+				BlockHash: batch.BlockHash,
+				Data:      batch.Data,
 			})
-			return data, nil
+			return batch.Data, nil
 		}
 		// Re-fetch the batch instead of using our cached cost,
 		// as the replay binary won't have the cache populated.
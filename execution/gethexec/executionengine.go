@@ -382,7 +382,7 @@ func (s *ExecutionEngine) sequencerWrapper(sequencerFunc func() (*types.Block, e
 		}
 		// We got SequencerInsertLockTaken
 		// option 1: there was a race, we are no longer main sequencer
-		chosenErr := s.consensus.ExpectChosenSequencer()
+		_, chosenErr := s.consensus.ExpectChosenSequencer().Await(s.GetContext())
 		if chosenErr != nil {
 			return nil, chosenErr
 		}
@@ -512,7 +512,7 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 		return nil, err
 	}
 
-	err = s.consensus.WriteMessageFromSequencer(pos, msgWithMeta, *msgResult)
+	_, err = s.consensus.WriteMessageFromSequencer(pos, msgWithMeta, *msgResult).Await(s.GetContext())
 	if err != nil {
 		return nil, err
 	}
@@ -569,7 +569,7 @@ func (s *ExecutionEngine) sequenceDelayedMessageWithBlockMutex(message *arbostyp
 		return nil, err
 	}
 
-	err = s.consensus.WriteMessageFromSequencer(pos, messageWithMeta, *msgResult)
+	_, err = s.consensus.WriteMessageFromSequencer(pos, messageWithMeta, *msgResult).Await(s.GetContext())
 	if err != nil {
 		return nil, err
 	}
@@ -590,11 +590,7 @@ func (s *ExecutionEngine) GetGenesisBlockNumber() uint64 {
 }
 
 func (s *ExecutionEngine) BlockNumberToMessageIndex(blockNum uint64) (arbutil.MessageIndex, error) {
-	genesis := s.GetGenesisBlockNumber()
-	if blockNum < genesis {
-		return 0, fmt.Errorf("blockNum %d < genesis %d", blockNum, genesis)
-	}
-	return arbutil.MessageIndex(blockNum - genesis), nil
+	return arbutil.BlockNumberToMessageIndex(blockNum, s.GetGenesisBlockNumber())
 }
 
 func (s *ExecutionEngine) MessageIndexToBlockNumber(messageNum arbutil.MessageIndex) uint64 {
@@ -626,8 +622,8 @@ func (s *ExecutionEngine) createBlockFromNextMessage(msg *arbostypes.MessageWith
 	defer statedb.StopPrefetcher()
 
 	batchFetcher := func(num uint64) ([]byte, error) {
-		data, _, err := s.consensus.FetchBatch(s.GetContext(), num)
-		return data, err
+		batch, err := s.consensus.FetchBatch(s.GetContext(), num).Await(s.GetContext())
+		return batch.Data, err
 	}
 
 	block, receipts, err := arbos.ProduceBlock(
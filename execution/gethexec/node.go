@@ -39,19 +39,20 @@ func DangerousConfigAddOptions(prefix string, f *flag.FlagSet) {
 }
 
 type Config struct {
-	ParentChainReader         headerreader.Config              `koanf:"parent-chain-reader" reload:"hot"`
-	Sequencer                 SequencerConfig                  `koanf:"sequencer" reload:"hot"`
-	RecordingDatabase         arbitrum.RecordingDatabaseConfig `koanf:"recording-database"`
-	TxPreChecker              TxPreCheckerConfig               `koanf:"tx-pre-checker" reload:"hot"`
-	Forwarder                 ForwarderConfig                  `koanf:"forwarder"`
-	ForwardingTarget          string                           `koanf:"forwarding-target"`
-	SecondaryForwardingTarget []string                         `koanf:"secondary-forwarding-target"`
-	Caching                   CachingConfig                    `koanf:"caching"`
-	RPC                       arbitrum.Config                  `koanf:"rpc"`
-	TxLookupLimit             uint64                           `koanf:"tx-lookup-limit"`
-	Dangerous                 DangerousConfig                  `koanf:"dangerous"`
-	EnablePrefetchBlock       bool                             `koanf:"enable-prefetch-block"`
-	SyncMonitor               SyncMonitorConfig                `koanf:"sync-monitor"`
+	ParentChainReader          headerreader.Config              `koanf:"parent-chain-reader" reload:"hot"`
+	Sequencer                  SequencerConfig                  `koanf:"sequencer" reload:"hot"`
+	RecordingDatabase          arbitrum.RecordingDatabaseConfig `koanf:"recording-database"`
+	TxPreChecker               TxPreCheckerConfig               `koanf:"tx-pre-checker" reload:"hot"`
+	Forwarder                  ForwarderConfig                  `koanf:"forwarder"`
+	ForwardingTarget           string                           `koanf:"forwarding-target"`
+	SecondaryForwardingTarget  []string                         `koanf:"secondary-forwarding-target"`
+	Caching                    CachingConfig                    `koanf:"caching"`
+	RPC                        arbitrum.Config                  `koanf:"rpc"`
+	TxLookupLimit              uint64                           `koanf:"tx-lookup-limit"`
+	Dangerous                  DangerousConfig                  `koanf:"dangerous"`
+	EnablePrefetchBlock        bool                             `koanf:"enable-prefetch-block"`
+	SyncMonitor                SyncMonitorConfig                `koanf:"sync-monitor"`
+	ValidateConsensusChainSpec bool                             `koanf:"validate-consensus-chain-spec"`
 
 	forwardingTarget string
 }
@@ -88,21 +89,23 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".tx-lookup-limit", ConfigDefault.TxLookupLimit, "retain the ability to lookup transactions by hash for the past N blocks (0 = all blocks)")
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	f.Bool(prefix+".enable-prefetch-block", ConfigDefault.EnablePrefetchBlock, "enable prefetching of blocks")
+	f.Bool(prefix+".validate-consensus-chain-spec", ConfigDefault.ValidateConsensusChainSpec, "refuse to start if the consensus node follows a different chain")
 }
 
 var ConfigDefault = Config{
-	RPC:                       arbitrum.DefaultConfig,
-	Sequencer:                 DefaultSequencerConfig,
-	ParentChainReader:         headerreader.DefaultConfig,
-	RecordingDatabase:         arbitrum.DefaultRecordingDatabaseConfig,
-	ForwardingTarget:          "",
-	SecondaryForwardingTarget: []string{},
-	TxPreChecker:              DefaultTxPreCheckerConfig,
-	TxLookupLimit:             126_230_400, // 1 year at 4 blocks per second
-	Caching:                   DefaultCachingConfig,
-	Dangerous:                 DefaultDangerousConfig,
-	Forwarder:                 DefaultNodeForwarderConfig,
-	EnablePrefetchBlock:       true,
+	RPC:                        arbitrum.DefaultConfig,
+	Sequencer:                  DefaultSequencerConfig,
+	ParentChainReader:          headerreader.DefaultConfig,
+	RecordingDatabase:          arbitrum.DefaultRecordingDatabaseConfig,
+	ForwardingTarget:           "",
+	SecondaryForwardingTarget:  []string{},
+	TxPreChecker:               DefaultTxPreCheckerConfig,
+	TxLookupLimit:              126_230_400, // 1 year at 4 blocks per second
+	Caching:                    DefaultCachingConfig,
+	Dangerous:                  DefaultDangerousConfig,
+	Forwarder:                  DefaultNodeForwarderConfig,
+	EnablePrefetchBlock:        true,
+	ValidateConsensusChainSpec: true,
 }
 
 func ConfigDefaultNonSequencerTest() *Config {
@@ -405,9 +408,50 @@ func (n *ExecutionNode) ForwardTo(url string) error {
 	}
 }
 
-func (n *ExecutionNode) SetConsensusClient(consensus execution.FullConsensusClient) {
+func (n *ExecutionNode) SetConsensusClient(ctx context.Context, consensus execution.FullConsensusClient) error {
+	if n.ConfigFetcher().ValidateConsensusChainSpec {
+		if err := n.validateConsensusChainSpec(ctx, consensus); err != nil {
+			return err
+		}
+	}
 	n.ExecEngine.SetConsensus(consensus)
 	n.SyncMonitor.SetConsensusInfo(consensus)
+	return nil
+}
+
+// validateConsensusChainSpec checks that consensus follows the chain this node executes.
+// The parent chain ID is only compared if both sides read the parent chain.
+func (n *ExecutionNode) validateConsensusChainSpec(ctx context.Context, consensus execution.ConsensusInfo) error {
+	remote, err := consensus.GetChainSpec(ctx).Await(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting consensus chain spec: %w", err)
+	}
+	chainConfig := n.ExecEngine.bc.Config()
+	local := execution.ChainSpec{
+		ChainID:         chainConfig.ChainID.Uint64(),
+		GenesisBlockNum: chainConfig.ArbitrumChainParams.GenesisBlockNum,
+	}
+	if n.ParentChainReader != nil {
+		parentChainID, err := n.ParentChainReader.Client().ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting parent chain ID: %w", err)
+		}
+		local.ParentChainID = parentChainID.Uint64()
+	}
+	return checkChainSpec(local, remote)
+}
+
+func checkChainSpec(local, remote execution.ChainSpec) error {
+	if remote.ChainID != local.ChainID {
+		return fmt.Errorf("consensus chain ID %d doesn't match execution chain ID %d", remote.ChainID, local.ChainID)
+	}
+	if remote.GenesisBlockNum != local.GenesisBlockNum {
+		return fmt.Errorf("consensus genesis block number %d doesn't match execution genesis block number %d", remote.GenesisBlockNum, local.GenesisBlockNum)
+	}
+	if remote.ParentChainID != 0 && local.ParentChainID != 0 && remote.ParentChainID != local.ParentChainID {
+		return fmt.Errorf("consensus parent chain ID %d doesn't match execution parent chain ID %d", remote.ParentChainID, local.ParentChainID)
+	}
+	return nil
 }
 
 func (n *ExecutionNode) MessageIndexToBlockNumber(messageNum arbutil.MessageIndex) uint64 {
@@ -77,6 +77,7 @@ type SequencerConfig struct {
 	ExpectedSurplusSoftThreshold string          `koanf:"expected-surplus-soft-threshold" reload:"hot"`
 	ExpectedSurplusHardThreshold string          `koanf:"expected-surplus-hard-threshold" reload:"hot"`
 	EnableProfiling              bool            `koanf:"enable-profiling" reload:"hot"`
+	MaxTxL1FeeGwei               float64         `koanf:"max-tx-l1-fee-gwei" reload:"hot"`
 	expectedSurplusSoftThreshold int
 	expectedSurplusHardThreshold int
 }
@@ -108,6 +109,9 @@ func (c *SequencerConfig) Validate() error {
 	if c.MaxTxDataSize > arbostypes.MaxL2MessageSize-50000 {
 		return errors.New("max-tx-data-size too large for MaxL2MessageSize")
 	}
+	if c.MaxTxL1FeeGwei < 0 {
+		return errors.New("max-tx-l1-fee-gwei cannot be negative")
+	}
 	return nil
 }
 
@@ -130,6 +134,7 @@ var DefaultSequencerConfig = SequencerConfig{
 	ExpectedSurplusSoftThreshold: "default",
 	ExpectedSurplusHardThreshold: "default",
 	EnableProfiling:              false,
+	MaxTxL1FeeGwei:               0,
 }
 
 var TestSequencerConfig = SequencerConfig{
@@ -148,6 +153,7 @@ var TestSequencerConfig = SequencerConfig{
 	ExpectedSurplusSoftThreshold: "default",
 	ExpectedSurplusHardThreshold: "default",
 	EnableProfiling:              false,
+	MaxTxL1FeeGwei:               0,
 }
 
 func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -166,6 +172,7 @@ func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".expected-surplus-soft-threshold", DefaultSequencerConfig.ExpectedSurplusSoftThreshold, "if expected surplus is lower than this value, warnings are posted")
 	f.String(prefix+".expected-surplus-hard-threshold", DefaultSequencerConfig.ExpectedSurplusHardThreshold, "if expected surplus is lower than this value, new incoming transactions will be denied")
 	f.Bool(prefix+".enable-profiling", DefaultSequencerConfig.EnableProfiling, "enable CPU profiling and tracing")
+	f.Float64(prefix+".max-tx-l1-fee-gwei", DefaultSequencerConfig.MaxTxL1FeeGwei, "reject transactions whose estimated parent chain fee, including their share of the batch's fixed cost, exceeds this (0 = unlimited)")
 }
 
 type txQueueItem struct {
@@ -334,6 +341,9 @@ type Sequencer struct {
 	expectedSurplusMutex   sync.RWMutex
 	expectedSurplus        int64
 	expectedSurplusUpdated bool
+
+	// unix nanoseconds, as transactions are published concurrently
+	nextAllowedFeeEstimateLog atomic.Int64
 }
 
 func NewSequencer(execEngine *ExecutionEngine, l1Reader *headerreader.HeaderReader, configFetcher SequencerConfigFetcher) (*Sequencer, error) {
@@ -454,6 +464,10 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 	queueCtx, cancelFunc := ctxWithTimeout(parentCtx, queueTimeout)
 	defer cancelFunc()
 
+	if err := s.checkTxL1Fee(queueCtx, config, txBytes); err != nil {
+		return err
+	}
+
 	// Just to be safe, make sure we don't run over twice the queue timeout
 	abortCtx, cancel := ctxWithTimeout(parentCtx, queueTimeout*2)
 	defer cancel()
@@ -540,7 +554,8 @@ func (s *Sequencer) CheckHealth(ctx context.Context) error {
 	if pauseChan != nil {
 		return nil
 	}
-	return s.execEngine.consensus.ExpectChosenSequencer()
+	_, err := s.execEngine.consensus.ExpectChosenSequencer().Await(ctx)
+	return err
 }
 
 func (s *Sequencer) ForwardTarget() string {
@@ -609,6 +624,51 @@ func (s *Sequencer) Pause() {
 
 var ErrNoSequencer = errors.New("sequencer temporarily not available")
 
+// ErrTxL1FeeExceeded is returned for a transaction whose estimated parent chain fee, Fee, exceeds
+// FeeCap, the highest fee the sequencer was configured to accept transactions with.
+type ErrTxL1FeeExceeded struct {
+	Fee    *big.Int
+	FeeCap *big.Int
+}
+
+func (e *ErrTxL1FeeExceeded) Error() string {
+	return fmt.Sprintf("transaction has estimated parent chain fee %v, exceeding the cap %v", e.Fee, e.FeeCap)
+}
+
+// checkTxL1Fee fails with *ErrTxL1FeeExceeded if the transaction's estimated parent chain fee, with
+// its share of the batch's fixed cost, exceeds max-tx-l1-fee-gwei. It runs before the transaction
+// is queued, so only user transactions are capped and delayed messages are always sequenced. The
+// fee is only estimated when a cap is set, and transactions are accepted while it can't be, so
+// losing the parent chain doesn't stop the sequencer.
+func (s *Sequencer) checkTxL1Fee(ctx context.Context, config *SequencerConfig, txBytes []byte) error {
+	if config.MaxTxL1FeeGwei <= 0 || s.execEngine.consensus == nil {
+		return nil
+	}
+	feeCap := arbmath.FloatToBig(config.MaxTxL1FeeGwei * params.GWei)
+	msg := arbostypes.MessageWithMetadata{
+		Message: &arbostypes.L1IncomingMessage{
+			Header: &arbostypes.L1IncomingMessageHeader{Kind: arbostypes.L1MessageType_L2Message},
+			L2msg:  append([]byte{arbos.L2MessageKind_SignedTx}, txBytes...),
+		},
+	}
+	estimate, err := s.execEngine.consensus.ComputeMessageL1Fee(msg).Await(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		now := time.Now().UnixNano()
+		next := s.nextAllowedFeeEstimateLog.Load()
+		if now > next && s.nextAllowedFeeEstimateLog.CompareAndSwap(next, now+int64(time.Minute)) {
+			log.Warn("not enforcing max-tx-l1-fee-gwei, failed to estimate a transaction's parent chain fee", "err", err)
+		}
+		return nil
+	}
+	if estimate.BatchAmortizedFee != nil && estimate.BatchAmortizedFee.Cmp(feeCap) > 0 {
+		return &ErrTxL1FeeExceeded{Fee: estimate.BatchAmortizedFee, FeeCap: feeCap}
+	}
+	return nil
+}
+
 func (s *Sequencer) GetPauseAndForwarder() (chan struct{}, *TxForwarder) {
 	s.activeMutex.Lock()
 	defer s.activeMutex.Unlock()
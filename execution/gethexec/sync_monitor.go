@@ -2,7 +2,10 @@ package gethexec
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/execution"
 	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
@@ -27,19 +30,44 @@ type SyncMonitor struct {
 	config    *SyncMonitorConfig
 	consensus execution.ConsensusInfo
 	exec      *ExecutionEngine
+
+	safeLabel      blockLabel
+	finalizedLabel blockLabel
+}
+
+// blockLabel is the last block returned for a label, kept while execution disagrees with consensus.
+type blockLabel struct {
+	name  string
+	mutex sync.Mutex
+	block uint64
+	set   bool
 }
 
 func NewSyncMonitor(config *SyncMonitorConfig, exec *ExecutionEngine) *SyncMonitor {
 	return &SyncMonitor{
-		config: config,
-		exec:   exec,
+		config:         config,
+		exec:           exec,
+		safeLabel:      blockLabel{name: "safe"},
+		finalizedLabel: blockLabel{name: "finalized"},
 	}
 }
 
 func (s *SyncMonitor) FullSyncProgressMap() map[string]interface{} {
-	res := s.consensus.FullSyncProgressMap()
+	ctx := context.Background()
+	res, err := s.consensus.FullSyncProgressMap().Await(ctx)
+	if err != nil {
+		res = map[string]interface{}{"consensusSyncProgressError": err}
+	}
 
-	res["consensusSyncTarget"] = s.consensus.SyncTargetMessageCount()
+	syncTarget, err := s.consensus.SyncTargetMessageCount().Await(ctx)
+	if err != nil {
+		res["consensusSyncTargetError"] = err
+	} else {
+		res["consensusSyncTarget"] = syncTarget.Count
+		res["consensusSyncTargetEstablished"] = syncTarget.Established
+		res["consensusSyncTargetConfidence"] = syncTarget.Confidence.String()
+		res["consensusSyncTargetSourcePeers"] = syncTarget.SourcePeers
+	}
 
 	header, err := s.exec.getCurrentHeader()
 	if err != nil {
@@ -69,33 +97,32 @@ func (s *SyncMonitor) SafeBlockNumber(ctx context.Context) (uint64, error) {
 	if s.consensus == nil {
 		return 0, errors.New("not set up for safeblock")
 	}
-	msg, err := s.consensus.GetSafeMsgCount(ctx)
+	count, err := s.consensus.GetSafeMsgCountWithHash(ctx).Await(ctx)
 	if err != nil {
 		return 0, err
 	}
-	if s.config.SafeBlockWaitForBlockValidator {
-		latestValidatedCount, err := s.consensus.ValidatedMessageCount()
-		if err != nil {
-			return 0, err
-		}
-		if msg > latestValidatedCount {
-			msg = latestValidatedCount
-		}
-	}
-	block := s.exec.MessageIndexToBlockNumber(msg - 1)
-	return block, nil
+	return s.checkedBlockNumber(ctx, &s.safeLabel, count, s.config.SafeBlockWaitForBlockValidator)
 }
 
 func (s *SyncMonitor) FinalizedBlockNumber(ctx context.Context) (uint64, error) {
 	if s.consensus == nil {
 		return 0, errors.New("not set up for safeblock")
 	}
-	msg, err := s.consensus.GetFinalizedMsgCount(ctx)
+	count, err := s.consensus.GetFinalizedMsgCountWithHash(ctx).Await(ctx)
 	if err != nil {
 		return 0, err
 	}
-	if s.config.FinalizedBlockWaitForBlockValidator {
-		latestValidatedCount, err := s.consensus.ValidatedMessageCount()
+	return s.checkedBlockNumber(ctx, &s.finalizedLabel, count, s.config.FinalizedBlockWaitForBlockValidator)
+}
+
+// checkedBlockNumber returns the block of the last message counted, or of the last validated one if
+// waitForValidator is set. If consensus stored a block hash for the message, and execution produced a
+// different block for it, the chains diverged: the block last returned for the label is returned
+// again, so the label doesn't advance.
+func (s *SyncMonitor) checkedBlockNumber(ctx context.Context, label *blockLabel, count execution.MsgCountWithHash, waitForValidator bool) (uint64, error) {
+	msg := count.Count
+	if waitForValidator {
+		latestValidatedCount, err := s.consensus.ValidatedMessageCount().Await(ctx)
 		if err != nil {
 			return 0, err
 		}
@@ -104,18 +131,36 @@ func (s *SyncMonitor) FinalizedBlockNumber(ctx context.Context) (uint64, error)
 		}
 	}
 	block := s.exec.MessageIndexToBlockNumber(msg - 1)
+	label.mutex.Lock()
+	defer label.mutex.Unlock()
+	// The hash is of the last message counted, so a block capped by validation can't be checked
+	if msg == count.Count && count.BlockHash != nil {
+		header := s.exec.bc.GetHeaderByNumber(block)
+		if header != nil && header.Hash() != *count.BlockHash {
+			log.Error("block hash mismatch with consensus, not advancing block label", "label", label.name, "block", block, "hash", header.Hash(), "consensusHash", *count.BlockHash)
+			if !label.set {
+				return 0, fmt.Errorf("%s block %d has hash %v, consensus has %v", label.name, block, header.Hash(), *count.BlockHash)
+			}
+			return label.block, nil
+		}
+	}
+	label.block = block
+	label.set = true
 	return block, nil
 }
 
 func (s *SyncMonitor) Synced() bool {
-	if s.consensus.Synced() {
-		built, err := s.exec.HeadMessageNumber()
-		consensusSyncTarget := s.consensus.SyncTargetMessageCount()
-		if err == nil && built+1 >= consensusSyncTarget {
-			return true
-		}
+	ctx := context.Background()
+	synced, err := s.consensus.Synced().Await(ctx)
+	if err != nil || !synced {
+		return false
+	}
+	built, err := s.exec.HeadMessageNumber()
+	if err != nil {
+		return false
 	}
-	return false
+	consensusSyncTarget, err := s.consensus.SyncTargetMessageCount().Await(ctx)
+	return err == nil && consensusSyncTarget.Established && built+1 >= consensusSyncTarget.Count
 }
 
 func (s *SyncMonitor) SetConsensusInfo(consensus execution.ConsensusInfo) {
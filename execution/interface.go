@@ -1,14 +1,20 @@
 package execution
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/validator"
 )
 
@@ -27,6 +33,68 @@ type RecordResult struct {
 
 var ErrRetrySequencer = errors.New("please retry transaction")
 var ErrSequencerInsertLockTaken = errors.New("insert lock taken")
+var ErrBackpressure = errors.New("sequencer write backlog above high-water mark")
+var ErrBatchOffsetOutOfRange = errors.New("batch offset out of range")
+var ErrBatchPosterNotEnabled = errors.New("batch poster not enabled")
+var ErrIdempotencyConflict = errors.New("idempotency key reused for a different sequencer write")
+var ErrClientClosed = errors.New("consensus client closed")
+var ErrDraining = errors.New("sequencer queue draining")
+
+// ErrBatchNotFound is returned for a batch that doesn't exist, so retrying won't help
+type ErrBatchNotFound struct {
+	BatchNum uint64
+}
+
+func (e *ErrBatchNotFound) Error() string {
+	return fmt.Sprintf("batch %d not found", e.BatchNum)
+}
+
+// ErrBatchNotYetPosted is returned for a batch beyond the latest posted one, which can be
+// fetched by retrying once it's posted
+type ErrBatchNotYetPosted struct {
+	BatchNum          uint64
+	LatestPostedBatch uint64
+}
+
+func (e *ErrBatchNotYetPosted) Error() string {
+	return fmt.Sprintf("batch %d not yet posted, latest posted batch is %d", e.BatchNum, e.LatestPostedBatch)
+}
+
+// ErrBatchPruned is returned when fetching the data of a batch older than OldestAvailable after it was pruned
+type ErrBatchPruned struct {
+	OldestAvailable uint64
+}
+
+func (e *ErrBatchPruned) Error() string {
+	return fmt.Sprintf("batch pruned, oldest available batch is %d", e.OldestAvailable)
+}
+
+// ErrRecordingStatePruned is returned when recording message Pos needs state execution already pruned.
+type ErrRecordingStatePruned struct {
+	Pos arbutil.MessageIndex
+}
+
+func (e *ErrRecordingStatePruned) Error() string {
+	return fmt.Sprintf("state needed to record message %d was pruned", e.Pos)
+}
+
+// IsBatchUnavailable returns whether err is one of the typed errors for a batch that isn't available:
+// *ErrBatchNotFound, *ErrBatchNotYetPosted or *ErrBatchPruned.
+func IsBatchUnavailable(err error) bool {
+	var notFoundErr *ErrBatchNotFound
+	var notYetPostedErr *ErrBatchNotYetPosted
+	var prunedErr *ErrBatchPruned
+	return errors.As(err, &notFoundErr) || errors.As(err, &notYetPostedErr) || errors.As(err, &prunedErr)
+}
+
+type ErrCommitDeadlineExceeded struct {
+	Pos      arbutil.MessageIndex
+	Deadline time.Time
+}
+
+func (e *ErrCommitDeadlineExceeded) Error() string {
+	return fmt.Sprintf("sequencer message %d not committed before deadline %v", e.Pos, e.Deadline)
+}
 
 // always needed
 type ExecutionClient interface {
@@ -74,32 +142,512 @@ type FullExecutionClient interface {
 	ArbOSVersionForMessageNumber(messageNum arbutil.MessageIndex) (uint64, error)
 }
 
+// The consensus interfaces below return a containers.PromiseInterface from every method but
+// ConsensusLifecycle.Close, so a consensus node can serve any call remotely. Implementations
+// serving in-process, like arbnode.Node, mostly return promises that are already ready.
+// A ctx argument bounds the work of the call, while the caller bounds its wait with Await.
+
 // not implemented in execution, used as input
 // BatchFetcher is required for any execution node
 type BatchFetcher interface {
-	FetchBatch(ctx context.Context, batchNum uint64) ([]byte, common.Hash, error)
-	FindInboxBatchContainingMessage(message arbutil.MessageIndex) (uint64, bool, error)
-	GetBatchParentChainBlock(seqNum uint64) (uint64, error)
+	FetchBatch(ctx context.Context, batchNum uint64) containers.PromiseInterface[FetchedBatch]
+	// FetchBatchChunk returns up to length bytes of the batch's data starting at offset,
+	// or ErrBatchOffsetOutOfRange if offset isn't within the data.
+	FetchBatchChunk(ctx context.Context, batchNum uint64, offset, length uint64) containers.PromiseInterface[[]byte]
+	// GetBatchSize returns the byte length of the batch's data, usually without fetching it.
+	GetBatchSize(ctx context.Context, batchNum uint64) containers.PromiseInterface[uint64]
+	GetBatchCount() containers.PromiseInterface[uint64]
+	// FindInboxBatchContainingMessage reports Found false, without an error, for a message not yet batched.
+	FindInboxBatchContainingMessage(message arbutil.MessageIndex) containers.PromiseInterface[BatchLookup]
+	GetBatchParentChainBlock(seqNum uint64) containers.PromiseInterface[uint64]
+	// GetBatchParentChainBlocks returns the parent chain block of each batch first through last,
+	// which must be at most MaxBatchParentChainBlocksRange batches. Batches GetBatchParentChainBlock
+	// fails for with an error matched by IsBatchUnavailable are reported as not found.
+	GetBatchParentChainBlocks(first, last uint64) containers.PromiseInterface[BatchParentChainBlocks]
+	// GetBatchMessageRange returns the messages batch batchNum contains, from the batch metadata
+	// rather than its data. It fails like FetchBatch for batches that aren't available.
+	GetBatchMessageRange(batchNum uint64) containers.PromiseInterface[MessageRange]
+	// GetMessageL1Info returns the batch the message was posted in and the parent chain block
+	// that included it, using the parent chain reader's cached headers where possible.
+	GetMessageL1Info(ctx context.Context, pos arbutil.MessageIndex) containers.PromiseInterface[L1Info]
+	// FindBatchesInParentChainRange returns the sequence numbers of batches posted within
+	// the inclusive parent chain block range, or an empty slice if there are none.
+	FindBatchesInParentChainRange(firstBlock, lastBlock uint64) containers.PromiseInterface[[]uint64]
+	// PrefetchBatches hints that batches first through last will be fetched soon.
+	// The returned promise is ready once the prefetch is scheduled, not when it completes.
+	PrefetchBatches(first, last uint64) containers.PromiseInterface[struct{}]
+}
+
+// PrunableBatchStore is a BatchFetcher that can drop the data of batches older than a retention
+// horizon, including any copy of it the store cached. FetchBatch, FetchBatchChunk and GetBatchSize fail with *ErrBatchPruned for pruned
+// batches, and PrefetchBatches skips them, while their metadata stays available: lookups like
+// FindInboxBatchContainingMessage, GetBatchParentChainBlock and GetBatchMessageRange still
+// answer for them.
+type PrunableBatchStore interface {
+	BatchFetcher
+	// PruneBatchesBefore prunes the data of all batches before batchNum. The latest batch can't be pruned.
+	PruneBatchesBefore(batchNum uint64) containers.PromiseInterface[struct{}]
+	OldestAvailableBatch() containers.PromiseInterface[uint64]
+}
+
+type LagSeverity uint8
+
+const (
+	LagSeverityWarn LagSeverity = iota
+	LagSeverityCritical
+)
+
+func (s LagSeverity) String() string {
+	switch s {
+	case LagSeverityWarn:
+		return "warn"
+	case LagSeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("LagSeverity(%d)", uint8(s))
+	}
+}
+
+type SyncConfidence uint8
+
+const (
+	// The sync target is only backed by the local message count or the feed
+	SyncConfidenceUnconfirmed SyncConfidence = iota
+	// The sync target is backed by the sequencer coordinator
+	SyncConfidencePeerMajority
+	// The sync target is backed by a batch posted to the parent chain
+	SyncConfidenceL1Confirmed
+)
+
+func (c SyncConfidence) String() string {
+	switch c {
+	case SyncConfidenceUnconfirmed:
+		return "unconfirmed"
+	case SyncConfidencePeerMajority:
+		return "peer-majority"
+	case SyncConfidenceL1Confirmed:
+		return "l1-confirmed"
+	default:
+		return fmt.Sprintf("SyncConfidence(%d)", uint8(c))
+	}
+}
+
+// SyncTarget is the message count a node is syncing to. Confidence is that of the most trusted
+// source reporting at least Count messages, and SourcePeers the number of feed connections and
+// coordinators among those sources.
+// Established is false during startup, before the node has determined a target. The zero Count it
+// reports then doesn't mean there's nothing to sync, so a node must not be considered synced to it.
+type SyncTarget struct {
+	Established bool                 `json:"established"`
+	Count       arbutil.MessageIndex `json:"count"`
+	Confidence  SyncConfidence       `json:"confidence"`
+	SourcePeers int                  `json:"sourcePeers"`
+}
+
+// ComponentHealth is the health of one of the components reported in a HealthStatus.
+// Detail explains why the component is unhealthy, or notes that it isn't configured.
+type ComponentHealth struct {
+	Healthy bool
+	Detail  string
+}
+
+type HealthStatus struct {
+	Healthy         bool
+	ParentChain     ComponentHealth
+	Feed            ComponentHealth
+	MessageDelivery ComponentHealth
+	Batches         ComponentHealth
+	// TimeSinceLastBatch is zero if no batch was seen yet
+	TimeSinceLastBatch time.Duration
+}
+
+// SyncProgressSnapshotVersion is bumped whenever fields of SyncProgressSnapshot change meaning or are removed.
+// Version 2 replaced the "uninitialized", "syncing" and "synced" states of SyncMode with the typed SyncMode.
+const SyncProgressSnapshotVersion = 2
+
+// SyncMode is how a node is catching up with its sync target. It's SyncModeNone once the node is
+// synced, and before it's initialized.
+type SyncMode string
+
+const (
+	// SyncModeFull is syncing by reading every batch from the parent chain
+	SyncModeFull SyncMode = "full"
+	// SyncModeFast is syncing from a trusted source ahead of the parent chain. No consensus node
+	// reports it yet.
+	SyncModeFast SyncMode = "fast"
+	// SyncModeSnap is syncing from a snapshot, before the node first catches up
+	SyncModeSnap SyncMode = "snap"
+	SyncModeNone SyncMode = "none"
+)
+
+// SyncProgressSnapshot is a stable view of sync progress, meant for monitoring.
+// SafeMsgCount and FinalizedMsgCount are zero if the parent chain doesn't provide finality data.
+// MsgThroughput and BatchThroughput are messages processed and batches read per second, averaged
+// over a sliding window. EstimatedTimeToSync is nil if processing doesn't outpace the sync target.
+type SyncProgressSnapshot struct {
+	Version             int                  `json:"version"`
+	SyncMode            SyncMode             `json:"syncMode"`
+	ProcessedMsgCount   arbutil.MessageIndex `json:"processedMsgCount"`
+	TargetMsgCount      arbutil.MessageIndex `json:"targetMsgCount"`
+	RemainingMsgCount   arbutil.MessageIndex `json:"remainingMsgCount"`
+	SafeMsgCount        arbutil.MessageIndex `json:"safeMsgCount"`
+	FinalizedMsgCount   arbutil.MessageIndex `json:"finalizedMsgCount"`
+	L1Block             uint64               `json:"l1Block"`
+	MsgThroughput       float64              `json:"msgThroughput"`
+	BatchThroughput     float64              `json:"batchThroughput"`
+	EstimatedTimeToSync *time.Duration       `json:"estimatedTimeToSync,omitempty"`
+	PostingLag          *PostingLag          `json:"postingLag,omitempty"`
+}
+
+// Map is the snapshot keyed by its JSON field names, for the deprecated sync progress maps.
+// Numbers are decoded as json.Number, so they keep their precision.
+func (s SyncProgressSnapshot) Map() (map[string]interface{}, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var res map[string]interface{}
+	if err := decoder.Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CatchUpEstimate is derived from the processed message count, with Rate in messages per second
+// averaged over a sliding window. TimeToSync is zero if nothing remains, and also if processing
+// doesn't outpace the sync target.
+type CatchUpEstimate struct {
+	MessagesRemaining arbutil.MessageIndex `json:"messagesRemaining"`
+	Rate              float64              `json:"rate"`
+	TimeToSync        time.Duration        `json:"timeToSync"`
+}
+
+// BatchCompressionStats covers the batches a batch poster posted since it started.
+// Ratios are uncompressed over compressed size, and EncodingBreakdown counts batches by how
+// their data was posted.
+type BatchCompressionStats struct {
+	TotalBatchesPosted     uint64            `json:"totalBatchesPosted"`
+	TotalUncompressedBytes uint64            `json:"totalUncompressedBytes"`
+	TotalCompressedBytes   uint64            `json:"totalCompressedBytes"`
+	AverageRatio           float64           `json:"averageRatio"`
+	LastBatchRatio         float64           `json:"lastBatchRatio"`
+	EncodingBreakdown      map[string]uint64 `json:"encodingBreakdown"`
+}
+
+// PostingLag is how far batch posting is behind the message count. UnpostedMessages are the
+// messages after PostedMsgCount, the message count of the latest posted batch, and
+// OldestUnpostedAge is the age of the first of them, by its timestamp, or zero if there are none
+// or it has no timestamp.
+// PosterEnabled is whether this node posts batches; the lag is reported either way, as batches
+// posted by another node are read from the parent chain.
+type PostingLag struct {
+	PosterEnabled     bool                 `json:"posterEnabled"`
+	MessageCount      arbutil.MessageIndex `json:"messageCount"`
+	PostedMsgCount    arbutil.MessageIndex `json:"postedMsgCount"`
+	UnpostedMessages  arbutil.MessageIndex `json:"unpostedMessages"`
+	OldestUnpostedAge time.Duration        `json:"oldestUnpostedAge"`
+}
+
+// FetchedBatch is the data of a batch, and the hash of the parent chain block that included it.
+type FetchedBatch struct {
+	Data      []byte      `json:"data"`
+	BlockHash common.Hash `json:"blockHash"`
+}
+
+// BatchLookup is the batch containing a message. Found is false, and Batch zero, if the message
+// isn't in a batch yet.
+type BatchLookup struct {
+	Batch uint64 `json:"batch"`
+	Found bool   `json:"found"`
+}
+
+// L1Info locates a message on the parent chain. If Pending is set, the message isn't in a
+// batch yet and the other fields are zero, so callers can poll until it's posted.
+type L1Info struct {
+	Pending     bool        `json:"pending"`
+	BatchNum    uint64      `json:"batchNum"`
+	L1Block     uint64      `json:"l1Block"`
+	L1BlockTime uint64      `json:"l1BlockTime"`
+	L1BlockHash common.Hash `json:"l1BlockHash"`
+}
+
+// MsgCountWithHash is a message count and the block hash consensus stored for message Count-1.
+// BlockHash is nil if Count is zero or consensus has no block hash stored for the message, e.g.
+// because it was read from the parent chain rather than the feed.
+type MsgCountWithHash struct {
+	Count     arbutil.MessageIndex `json:"count"`
+	BlockHash *common.Hash         `json:"blockHash,omitempty"`
+}
+
+// SafeMsgInfo is the safe message count, with the latest safe parent chain block when consensus
+// first saw the count at its current value, and when that was. UpdatedAtL1Block is zero and
+// UpdatedAt is the zero time if consensus hasn't seen a safe message count yet.
+type SafeMsgInfo struct {
+	Count            arbutil.MessageIndex `json:"count"`
+	UpdatedAtL1Block uint64               `json:"updatedAtL1Block"`
+	UpdatedAt        time.Time            `json:"updatedAt"`
+}
+
+// FinalizedMsgInfo is the finalized message count, updated like SafeMsgInfo.
+type FinalizedMsgInfo SafeMsgInfo
+
+// MessageRange is the messages from Start up to End, exclusive.
+type MessageRange struct {
+	Start arbutil.MessageIndex `json:"start"`
+	End   arbutil.MessageIndex `json:"end"`
+}
+
+// CheckpointInfo is a finalized message and its result. A new execution node can be bootstrapped
+// from a snapshot of the state after Pos instead of executing every message from genesis.
+type CheckpointInfo struct {
+	Pos       arbutil.MessageIndex `json:"pos"`
+	BlockHash common.Hash          `json:"blockHash"`
+	SendRoot  common.Hash          `json:"sendRoot"`
+}
+
+// PostingReportInfo is the batch posting report ArbOS charges the poster of BatchNum from, which
+// the poster's parent chain transaction added to the delayed inbox. If Pending is set, the report
+// isn't in the message stream yet and only BatchNum is filled in, so callers can poll until it is.
+type PostingReportInfo struct {
+	Pending  bool   `json:"pending"`
+	BatchNum uint64 `json:"batchNum"`
+	// Pos is the message the report was sequenced as, and DelayedSeqNum its delayed inbox sequence number
+	Pos           arbutil.MessageIndex `json:"pos"`
+	DelayedSeqNum uint64               `json:"delayedSeqNum"`
+	BatchPoster   common.Address       `json:"batchPoster"`
+	// DataGas includes the extra gas of the report
+	DataGas   uint64   `json:"dataGas"`
+	L1BaseFee *big.Int `json:"l1BaseFee"`
+	// L1Block is the parent chain block the report was posted in
+	L1Block uint64 `json:"l1Block"`
+}
+
+// ReportedCost is the cost ArbOS charges for the batch, before any L1 pricing adjustments
+func (r *PostingReportInfo) ReportedCost() *big.Int {
+	if r.L1BaseFee == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(r.DataGas), r.L1BaseFee)
+}
+
+// BatchParentChainBlocks is the parent chain block of each batch from First on. Found[i] is false,
+// and Blocks[i] zero, if batch First+i isn't available.
+type BatchParentChainBlocks struct {
+	First  uint64   `json:"first"`
+	Blocks []uint64 `json:"blocks"`
+	Found  []bool   `json:"found"`
+}
+
+// MaxBatchParentChainBlocksRange is the most batches GetBatchParentChainBlocks returns the blocks of
+const MaxBatchParentChainBlocksRange = 4096
+
+// MaxFindBatchesContainingKindRange is the most batches FindBatchesContainingKind searches
+const MaxFindBatchesContainingKindRange = 1024
+
+// MaxBatchPostingReportRange is the most batches GetBatchPostingReports returns the reports of
+const MaxBatchPostingReportRange = 256
+
+// ConsensusProtocolVersion is bumped whenever consensus interface methods are added or change meaning
+const ConsensusProtocolVersion = 17
+
+type ConsensusCapability string
+
+// Optional consensus features. Methods a consensus node lacks the capability for may fail,
+// so clients should fall back to the one-at-a-time methods.
+const (
+	// FetchBatchChunk and GetBatchSize
+	CapabilityBatchChunks ConsensusCapability = "batchChunks"
+	// FindBatchesInParentChainRange and PrefetchBatches
+	CapabilityBatchRanges ConsensusCapability = "batchRanges"
+	// PrunableBatchStore
+	CapabilityBatchPruning ConsensusCapability = "batchPruning"
+	// WriteMessageFromSequencerWithDeadline and SequencerWriteBacklog
+	CapabilitySequencerDeadlines ConsensusCapability = "sequencerDeadlines"
+	// Sequencer writes for positions ahead of the message count are queued rather than rejected
+	CapabilitySequencerPipeline ConsensusCapability = "sequencerPipeline"
+	// GetBatchCompressionStats
+	CapabilityCompressionStats ConsensusCapability = "compressionStats"
+)
+
+type CapabilitySet struct {
+	ProtocolVersion uint64                `json:"protocolVersion"`
+	Capabilities    []ConsensusCapability `json:"capabilities"`
+}
+
+func (s CapabilitySet) Has(capability ConsensusCapability) bool {
+	for _, c := range s.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// PingResult is the message count and wall clock of a consensus node when it answered a Ping.
+type PingResult struct {
+	MessageCount arbutil.MessageIndex `json:"messageCount"`
+	Time         time.Time            `json:"time"`
+}
+
+// ChainSpec identifies the chain a consensus node follows.
+// ParentChainID is zero if the node doesn't read the parent chain.
+type ChainSpec struct {
+	ChainID         uint64 `json:"chainId"`
+	ParentChainID   uint64 `json:"parentChainId"`
+	GenesisBlockNum uint64 `json:"genesisBlockNum"`
 }
 
 type ConsensusInfo interface {
-	Synced() bool
-	FullSyncProgressMap() map[string]interface{}
-	SyncTargetMessageCount() arbutil.MessageIndex
+	Capabilities() containers.PromiseInterface[CapabilitySet]
+	Synced() containers.PromiseInterface[bool]
+	// Healthy only reads local state, so it's cheap enough to call on every health probe.
+	Healthy() containers.PromiseInterface[HealthStatus]
+	// Ping only reads local state, so it's cheap enough to call as a heartbeat.
+	Ping(ctx context.Context) containers.PromiseInterface[PingResult]
+	SyncProgressSnapshot(ctx context.Context) containers.PromiseInterface[SyncProgressSnapshot]
+	// FullSyncProgressMap is SyncProgressSnapshot as a map, keyed by its JSON field names.
+	//
+	// Deprecated: use SyncProgressSnapshot.
+	FullSyncProgressMap() containers.PromiseInterface[map[string]interface{}]
+	SyncTargetMessageCount() containers.PromiseInterface[SyncTarget]
+	CatchUpEstimate() containers.PromiseInterface[CatchUpEstimate]
+	// GetBatchCompressionStats fails with ErrBatchPosterNotEnabled if this node doesn't post batches.
+	GetBatchCompressionStats() containers.PromiseInterface[BatchCompressionStats]
+	GetBatchPostingLag() containers.PromiseInterface[PostingLag]
+	GetSyncMode() containers.PromiseInterface[SyncMode]
+	GetChainSpec(ctx context.Context) containers.PromiseInterface[ChainSpec]
+	// MessageIndexToBlockNumber and BlockNumberToMessageIndex account for the genesis block number,
+	// and fail for messages beyond the message count and blocks before genesis.
+	MessageIndexToBlockNumber(pos arbutil.MessageIndex) containers.PromiseInterface[uint64]
+	BlockNumberToMessageIndex(block uint64) containers.PromiseInterface[arbutil.MessageIndex]
+	// GetMessageAccHash returns the hash of the message at pos, as MessageWithMetadata.Hash computes it
+	// with the chain ID, so execution can compare it against the message it executed to detect divergence.
+	// It fails like MessageIndexToBlockNumber for positions beyond the message count, including ones reorged away.
+	GetMessageAccHash(pos arbutil.MessageIndex) containers.PromiseInterface[common.Hash]
+	// GetCheckpointInfo returns the latest finalized message to bootstrap execution from.
+	GetCheckpointInfo(ctx context.Context) containers.PromiseInterface[CheckpointInfo]
+	// VerifyExecutionCheckpoint checks the state of an execution node bootstrapped from a snapshot,
+	// which claims to have executed message pos producing blockHash, before it starts executing the
+	// messages after pos. A block hash other than consensus has for pos fails with
+	// *ErrCheckpointMismatch, and pos beyond the message count or so old that the messages after it
+	// were pruned fails with *ErrMessageBeyondHead or *ErrSnapshotTooOld (of the consensus package).
+	VerifyExecutionCheckpoint(pos arbutil.MessageIndex, blockHash common.Hash) containers.PromiseInterface[struct{}]
+	// GetBatchPostingReport returns the posting report of batch seqNum, and fails like FetchBatch
+	// for batches not posted yet. Batch 0 is posted without a report.
+	GetBatchPostingReport(seqNum uint64) containers.PromiseInterface[PostingReportInfo]
+	// GetBatchPostingReports returns the posting reports of batches first through last, which
+	// must be at most MaxBatchPostingReportRange batches.
+	GetBatchPostingReports(first, last uint64) containers.PromiseInterface[[]PostingReportInfo]
+	// FindBatchesContainingKind returns the batches of first through last, which must be at most
+	// MaxFindBatchesContainingKindRange batches, with at least one message whose header has kind,
+	// one of the arbostypes.L1MessageType kinds. Batches are matched by the messages consensus
+	// stored for them, so it fails for batches whose messages were pruned, fails like
+	// GetBatchMessageRange for batches not yet posted, and the batch data format doesn't matter.
+	FindBatchesContainingKind(first, last uint64, kind uint8) containers.PromiseInterface[[]uint64]
 
 	// TODO: switch from pulling to pushing safe/finalized
-	GetSafeMsgCount(ctx context.Context) (arbutil.MessageIndex, error)
-	GetFinalizedMsgCount(ctx context.Context) (arbutil.MessageIndex, error)
-	ValidatedMessageCount() (arbutil.MessageIndex, error)
+	// GetSafeMsgCount and GetFinalizedMsgCount also return when the count last changed, so callers
+	// can tell a stuck safe or finalized head from one that doesn't need to advance.
+	GetSafeMsgCount(ctx context.Context) containers.PromiseInterface[SafeMsgInfo]
+	GetFinalizedMsgCount(ctx context.Context) containers.PromiseInterface[FinalizedMsgInfo]
+	// GetSafeMsgCountWithHash and GetFinalizedMsgCountWithHash also return the block hash consensus
+	// stored for the last of the messages, so execution can check it's on the same chain.
+	GetSafeMsgCountWithHash(ctx context.Context) containers.PromiseInterface[MsgCountWithHash]
+	GetFinalizedMsgCountWithHash(ctx context.Context) containers.PromiseInterface[MsgCountWithHash]
+	ValidatedMessageCount() containers.PromiseInterface[arbutil.MessageIndex]
+
+	// SetLagThreshold sets the threshold for the given severity, replacing any previous one.
+	// onExceed is called once when the lag between the sync target and the processed message count
+	// first exceeds messages, and onRecovery (if not nil) once the lag drops back to or below it.
+	// The callbacks are called one at a time in the order fired, and may block without holding up sync.
+	SetLagThreshold(severity LagSeverity, messages arbutil.MessageIndex, onExceed func(lag arbutil.MessageIndex), onRecovery func()) containers.PromiseInterface[struct{}]
+	// ClearLagThreshold removes the thresholds of all severities.
+	ClearLagThreshold() containers.PromiseInterface[struct{}]
+}
+
+// BacklogStatus describes the sequencer writes accepted but not yet committed, and the size of
+// their L2 messages. WriteLatency is the average time recent writes took to commit, or zero if
+// none committed yet.
+type BacklogStatus struct {
+	PendingWrites int
+	PendingBytes  int64
+	WriteLatency  time.Duration
 }
 
+// DrainResult covers the sequencer writes committed while draining, and the size of their L2 messages.
+type DrainResult struct {
+	MessagesDrained int           `json:"messagesDrained"`
+	BytesFlushed    int64         `json:"bytesFlushed"`
+	Duration        time.Duration `json:"duration"`
+}
+
+// MessageFeeEstimate is the parent chain cost of posting a message, estimated from the latest parent
+// chain base fee. L1DataFee prices its CompressedBytes as calldata, and BatchAmortizedFee adds the
+// message's share of the fixed cost of the batch posting transaction, split evenly over the
+// messages of the latest batch.
+type MessageFeeEstimate struct {
+	L1DataFee         *big.Int `json:"l1DataFee"`
+	CompressedBytes   int      `json:"compressedBytes"`
+	UncompressedBytes int      `json:"uncompressedBytes"`
+	BatchAmortizedFee *big.Int `json:"batchAmortizedFee"`
+}
+
+// ConsensusSequencer writes are positional: WriteMessageFromSequencer for pos is only applied
+// when pos is the current message count, and its promise is only ready once the message was
+// written. A single client awaiting each write before issuing the next therefore has them
+// applied in call order.
+// Concurrent calls are not queued by the TransactionStreamer; a call made while another write
+// is in progress fails with ErrSequencerInsertLockTaken, and a call for any pos other than the
+// message count fails without writing. Clients that need to issue writes concurrently should
+// enable the sequencer pipeline, which commits them in strict position order.
+// ConsensusInfo and BatchFetcher reads aren't ordered with respect to in-flight writes: they
+// reflect whatever had been committed when the read was served.
 type ConsensusSequencer interface {
-	WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult MessageResult) error
-	ExpectChosenSequencer() error
+	// WriteMessageFromSequencer succeeds only once the message is committed: the message, its
+	// block hash and the new message count were written to the consensus database in one batch,
+	// so they survive a crash together or not at all. The batch write is synced to disk with the
+	// pebble database, while with leveldb it survives a crash of the node but not of the machine.
+	// The message is only broadcast to the feed after it's committed. There is no separate stage
+	// where a write is accepted but not committed: a write pending in the sequencer pipeline isn't
+	// ready yet, and a failed write leaves nothing to recover.
+	WriteMessageFromSequencer(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult MessageResult) containers.PromiseInterface[struct{}]
+	// WriteMessageFromSequencerWithDeadline is WriteMessageFromSequencer, but fails with
+	// *ErrCommitDeadlineExceeded, without writing, if the write can't start before deadline.
+	// A write that starts before deadline is committed even if it finishes after it,
+	// so callers should compare the returned commit time against their latency target.
+	WriteMessageFromSequencerWithDeadline(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult MessageResult, deadline time.Time) containers.PromiseInterface[time.Time]
+	// WriteMessageFromSequencerIdempotent is WriteMessageFromSequencer, but tags the write with key,
+	// so it's safe to retry: repeating a successful write with the same key, position and message
+	// succeeds without writing again, while reusing the key for a different position or message
+	// fails with ErrIdempotencyConflict. Keys are only remembered for a bounded number of recent
+	// writes and until the next reorg; retries after that fail like a plain repeated write.
+	WriteMessageFromSequencerIdempotent(pos arbutil.MessageIndex, msgWithMeta arbostypes.MessageWithMetadata, msgResult MessageResult, key string) containers.PromiseInterface[struct{}]
+	ExpectChosenSequencer() containers.PromiseInterface[struct{}]
+	// SequencerWriteBacklog lets the sequencer throttle message production before writes fail
+	// with ErrBackpressure. It's cheap enough to poll.
+	SequencerWriteBacklog() containers.PromiseInterface[BacklogStatus]
+	// DrainSequencerQueue fails new writes with ErrDraining until the writes already accepted are
+	// committed or failed, or ctx is cancelled, after which writes are accepted again.
+	// The promise fails with ctx's error if ctx is cancelled first.
+	DrainSequencerQueue(ctx context.Context) containers.PromiseInterface[DrainResult]
+	// ComputeMessageL1Fee estimates the cost of posting the message without writing it.
+	ComputeMessageL1Fee(msgWithMeta arbostypes.MessageWithMetadata) containers.PromiseInterface[MessageFeeEstimate]
+}
+
+// ConsensusLifecycle releases what a consensus client holds once it's no longer used.
+// Close fails the calls in flight with ErrClientClosed, and after it every method fails with
+// ErrClientClosed without blocking. Closing again does nothing.
+// Implementations serving in-process, like arbnode.Node, hold nothing to release, but still fail
+// the calls in flight.
+type ConsensusLifecycle interface {
+	Close() error
 }
 
 type FullConsensusClient interface {
 	BatchFetcher
 	ConsensusInfo
 	ConsensusSequencer
+	ConsensusLifecycle
 }
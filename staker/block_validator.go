@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/offchainlabs/nitro/arbnode/resourcemanager"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/util/containers"
 	"github.com/offchainlabs/nitro/util/rpcclient"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
@@ -73,6 +74,7 @@ type BlockValidator struct {
 
 	createNodesChan         chan struct{}
 	sendRecordChan          chan struct{}
+	recordingSlots          chan struct{} // bounds the recordings running at once
 	progressValidationsChan chan struct{}
 
 	chosenValidator map[common.Hash]validator.ValidationSpawner
@@ -97,6 +99,7 @@ type BlockValidatorConfig struct {
 	ValidationServerConfigs     []rpcclient.ClientConfig      `koanf:"validation-server-configs"`
 	ValidationPoll              time.Duration                 `koanf:"validation-poll" reload:"hot"`
 	PrerecordedBlocks           uint64                        `koanf:"prerecorded-blocks" reload:"hot"`
+	MaxConcurrentRecordings     int                           `koanf:"max-concurrent-recordings"`
 	ForwardBlocks               uint64                        `koanf:"forward-blocks" reload:"hot"`
 	CurrentModuleRoot           string                        `koanf:"current-module-root"`         // TODO(magic) requires reinitialization on hot reload
 	PendingUpgradeModuleRoot    string                        `koanf:"pending-upgrade-module-root"` // TODO(magic) requires StatelessBlockValidator recreation on hot reload
@@ -118,6 +121,11 @@ func (c *BlockValidatorConfig) Validate() error {
 		}
 		c.memoryFreeLimit = limit
 	}
+	if c.MaxConcurrentRecordings == 0 {
+		c.MaxConcurrentRecordings = DefaultBlockValidatorConfig.MaxConcurrentRecordings
+	} else if c.MaxConcurrentRecordings < 0 {
+		return errors.New("block-validator max-concurrent-recordings must not be negative")
+	}
 	streamsEnabled := c.RedisValidationClientConfig.Enabled()
 	if len(c.ValidationServerConfigs) == 0 {
 		c.ValidationServerConfigs = []rpcclient.ClientConfig{c.ValidationServer}
@@ -151,6 +159,7 @@ func BlockValidatorConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Duration(prefix+".validation-poll", DefaultBlockValidatorConfig.ValidationPoll, "poll time to check validations")
 	f.Uint64(prefix+".forward-blocks", DefaultBlockValidatorConfig.ForwardBlocks, "prepare entries for up to that many blocks ahead of validation (small footprint)")
 	f.Uint64(prefix+".prerecorded-blocks", DefaultBlockValidatorConfig.PrerecordedBlocks, "record that many blocks ahead of validation (larger footprint)")
+	f.Int(prefix+".max-concurrent-recordings", DefaultBlockValidatorConfig.MaxConcurrentRecordings, "maximum number of blocks recorded by execution at once (0 = one per CPU)")
 	f.String(prefix+".current-module-root", DefaultBlockValidatorConfig.CurrentModuleRoot, "current wasm module root ('current' read from chain, 'latest' from machines/latest dir, or provide hash)")
 	f.String(prefix+".pending-upgrade-module-root", DefaultBlockValidatorConfig.PendingUpgradeModuleRoot, "pending upgrade wasm module root to additionally validate (hash, 'latest' or empty)")
 	f.Bool(prefix+".failure-is-fatal", DefaultBlockValidatorConfig.FailureIsFatal, "failing a validation is treated as a fatal error")
@@ -170,6 +179,7 @@ var DefaultBlockValidatorConfig = BlockValidatorConfig{
 	ValidationPoll:              time.Second,
 	ForwardBlocks:               1024,
 	PrerecordedBlocks:           uint64(2 * runtime.NumCPU()),
+	MaxConcurrentRecordings:     runtime.NumCPU(),
 	CurrentModuleRoot:           "current",
 	PendingUpgradeModuleRoot:    "latest",
 	FailureIsFatal:              true,
@@ -185,6 +195,7 @@ var TestBlockValidatorConfig = BlockValidatorConfig{
 	ValidationPoll:              100 * time.Millisecond,
 	ForwardBlocks:               128,
 	PrerecordedBlocks:           uint64(2 * runtime.NumCPU()),
+	MaxConcurrentRecordings:     runtime.NumCPU(),
 	CurrentModuleRoot:           "latest",
 	PendingUpgradeModuleRoot:    "latest",
 	FailureIsFatal:              true,
@@ -234,6 +245,7 @@ func NewBlockValidator(
 		StatelessBlockValidator: statelessBlockValidator,
 		createNodesChan:         make(chan struct{}, 1),
 		sendRecordChan:          make(chan struct{}, 1),
+		recordingSlots:          make(chan struct{}, config().MaxConcurrentRecordings),
 		progressValidationsChan: make(chan struct{}, 1),
 		config:                  config,
 		fatalErr:                fatalErr,
@@ -445,12 +457,25 @@ func (v *BlockValidator) sendRecord(s *validationStatus) error {
 		return fmt.Errorf("failed status check for send record. Status: %v", s.getStatus())
 	}
 	v.LaunchThread(func(ctx context.Context) {
+		select {
+		case v.recordingSlots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
 		err := v.ValidationEntryRecord(ctx, s.Entry)
+		<-v.recordingSlots
 		if ctx.Err() != nil {
 			return
 		}
 		if err != nil {
 			s.replaceStatus(RecordSent, RecordFailed) // after that - could be removed from validations map
+			var prunedErr *execution.ErrRecordingStatePruned
+			if errors.As(err, &prunedErr) {
+				// Retrying can't help, execution must keep more state or validation must be reset past this message
+				log.Error("Execution pruned the state needed to record block for validation", "pos", prunedErr.Pos, "err", err)
+				v.possiblyFatal(err)
+				return
+			}
 			log.Error("Error while recording", "err", err, "status", s.getStatus())
 			return
 		}
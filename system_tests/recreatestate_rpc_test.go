@@ -20,6 +20,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
+	"github.com/offchainlabs/nitro/execution"
 	"github.com/offchainlabs/nitro/execution/gethexec"
 	"github.com/offchainlabs/nitro/util"
 )
@@ -324,6 +325,52 @@ func TestRecreateStateForRPCBlockNotFoundWhileRecreating(t *testing.T) {
 	}
 }
 
+func TestRecordBlockCreationStatePruned(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	execConfig := gethexec.ConfigDefaultTest()
+	execConfig.Sequencer.MaxBlockSpeed = 0
+	execConfig.Sequencer.MaxTxDataSize = 150 // 1 test tx ~= 110
+	execConfig.Caching.Archive = true
+	// disable trie/Database.cleans cache, so as states removed from ChainDb won't be cached there
+	execConfig.Caching.TrieCleanCache = 0
+	execConfig.Caching.MaxNumberOfBlocksToSkipStateSaving = 0
+	execConfig.Caching.MaxAmountOfGasToSkipStateSaving = 0
+	builder, cancelNode := prepareNodeWithHistory(t, ctx, execConfig, 16)
+	execNode, l2client := builder.L2.ExecNode, builder.L2.Client
+	defer cancelNode()
+	bc := execNode.Backend.ArbInterface().BlockChain()
+	db := execNode.Backend.ChainDb()
+
+	lastBlock, err := l2client.BlockNumber(ctx)
+	Require(t, err)
+	// The state of the block before the recorded one can't be found, nor recreated without the removed body
+	removeStatesFromDb(t, bc, db, lastBlock-4, lastBlock)
+	blockBodyToRemove := lastBlock - 3
+	rawdb.DeleteBody(db, rawdb.ReadCanonicalHash(db, blockBodyToRemove), blockBodyToRemove)
+
+	pos, err := execNode.ExecEngine.BlockNumberToMessageIndex(lastBlock)
+	Require(t, err)
+	msg, err := builder.L2.ConsensusNode.TxStreamer.GetMessage(pos)
+	Require(t, err)
+	_, err = execNode.RecordBlockCreation(ctx, pos, msg)
+	var prunedErr *execution.ErrRecordingStatePruned
+	if !errors.As(err, &prunedErr) {
+		Fatal(t, "expected ErrRecordingStatePruned recording block", lastBlock, "got", err)
+	}
+	if prunedErr.Pos != pos {
+		Fatal(t, "unexpected message of the pruned state, want:", pos, "have:", prunedErr.Pos)
+	}
+
+	// Recording a block whose previous state is still around isn't affected
+	pos, err = execNode.ExecEngine.BlockNumberToMessageIndex(lastBlock - 5)
+	Require(t, err)
+	msg, err = builder.L2.ConsensusNode.TxStreamer.GetMessage(pos)
+	Require(t, err)
+	_, err = execNode.RecordBlockCreation(ctx, pos, msg)
+	Require(t, err)
+}
+
 func testSkippingSavingStateAndRecreatingAfterRestart(t *testing.T, cacheConfig *gethexec.CachingConfig, txCount int) {
 	maxRecreateStateDepth := int64(30 * 1000 * 1000)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,226 @@
+// Copyright 2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrNoPromises = errors.New("no promises given")
+
+// PromiseTimeoutError is produced by a promise created with WithTimeout when the
+// source promise didn't resolve in time. It matches context.DeadlineExceeded with errors.Is.
+type PromiseTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *PromiseTimeoutError) Error() string {
+	return fmt.Sprintf("promise timed out after %v", e.Timeout)
+}
+
+func (e *PromiseTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+type indexedResult[T any] struct {
+	index int
+	value T
+	err   error
+}
+
+// linkedPromise is a promise derived from one or more source promises by helper goroutines.
+// Cancelling it before it's ready cancels all sources. quit is closed once it's cancelled or its
+// result was produced. The helpers exit then, or once the context given to the combinator is
+// done, in which case the sources are cancelled and the promise fails with the context's error.
+// So the helpers don't outlive the context, even if a source never resolves.
+type linkedPromise[R any] struct {
+	Promise[R]
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+func newLinkedPromise[R any](cancelSources func()) *linkedPromise[R] {
+	p := &linkedPromise[R]{
+		quit: make(chan struct{}),
+	}
+	p.Promise = NewPromise[R](func() {
+		p.stop()
+		cancelSources()
+	})
+	return p
+}
+
+func (p *linkedPromise[R]) stop() {
+	p.quitOnce.Do(func() { close(p.quit) })
+}
+
+func (p *linkedPromise[R]) produce(value R, err error) {
+	if err != nil {
+		_ = p.ProduceErrorSafe(err)
+	} else {
+		_ = p.ProduceSafe(value)
+	}
+	p.stop()
+}
+
+func cancelAll[T any](promises []PromiseInterface[T]) func() {
+	return func() {
+		for _, promise := range promises {
+			promise.Cancel()
+		}
+	}
+}
+
+// forwardResults sends the result of each promise to the returned channel as soon as it's ready.
+// The channel is buffered so forwarding goroutines never block, and they exit once quit is closed.
+// Combinators produce their promise once their context is done, so quit is closed then too.
+func forwardResults[T any](promises []PromiseInterface[T], quit <-chan struct{}) <-chan indexedResult[T] {
+	results := make(chan indexedResult[T], len(promises))
+	for i, promise := range promises {
+		go func(i int, promise PromiseInterface[T]) {
+			select {
+			case <-promise.ReadyChan():
+				value, err := promise.Current()
+				results <- indexedResult[T]{index: i, value: value, err: err}
+			case <-quit:
+			}
+		}(i, promise)
+	}
+	return results
+}
+
+// All returns a promise that resolves with the results of all promises, in the order given.
+// It fails as soon as any of the promises fails, cancelling the remaining ones.
+// Cancelling the returned promise cancels all of the given promises.
+// ctx is required because the combinator waits on the promises in goroutines, which would leak
+// if a promise never resolved: once ctx is done, the given promises are cancelled, the goroutines
+// exit and the returned promise fails with ctx.Err().
+func All[T any](ctx context.Context, promises ...PromiseInterface[T]) PromiseInterface[[]T] {
+	cancelSources := cancelAll(promises)
+	combined := newLinkedPromise[[]T](cancelSources)
+	if len(promises) == 0 {
+		combined.produce([]T{}, nil)
+		return combined
+	}
+	results := forwardResults(promises, combined.quit)
+	go func() {
+		values := make([]T, len(promises))
+		for range promises {
+			select {
+			case res := <-results:
+				if res.err != nil {
+					cancelSources()
+					combined.produce(nil, res.err)
+					return
+				}
+				values[res.index] = res.value
+			case <-ctx.Done():
+				cancelSources()
+				combined.produce(nil, ctx.Err())
+				return
+			case <-combined.quit:
+				combined.produce(nil, context.Canceled)
+				return
+			}
+		}
+		combined.produce(values, nil)
+	}()
+	return combined
+}
+
+// Any returns a promise that resolves with the first successful result among the promises,
+// cancelling the remaining ones. If all of the promises fail, it fails with all of their errors joined.
+// Cancelling the returned promise cancels all of the given promises. As with All, ctx bounds how
+// long the promises are waited on: once it's done they're cancelled and the returned promise fails
+// with ctx.Err().
+func Any[T any](ctx context.Context, promises ...PromiseInterface[T]) PromiseInterface[T] {
+	cancelSources := cancelAll(promises)
+	combined := newLinkedPromise[T](cancelSources)
+	var empty T
+	if len(promises) == 0 {
+		combined.produce(empty, ErrNoPromises)
+		return combined
+	}
+	results := forwardResults(promises, combined.quit)
+	go func() {
+		errs := make([]error, len(promises))
+		for range promises {
+			select {
+			case res := <-results:
+				if res.err == nil {
+					cancelSources()
+					combined.produce(res.value, nil)
+					return
+				}
+				errs[res.index] = res.err
+			case <-ctx.Done():
+				cancelSources()
+				combined.produce(empty, ctx.Err())
+				return
+			case <-combined.quit:
+				combined.produce(empty, context.Canceled)
+				return
+			}
+		}
+		combined.produce(empty, errors.Join(errs...))
+	}()
+	return combined
+}
+
+// Map returns a promise that resolves with f applied to the result of p.
+// Errors from p are passed through without calling f.
+// Cancelling the returned promise cancels p. The goroutine waiting on p exits once ctx is done,
+// cancelling p and failing the returned promise with ctx.Err(), so context.Background() is only
+// safe for a p that always resolves.
+func Map[T, U any](ctx context.Context, p PromiseInterface[T], f func(T) (U, error)) PromiseInterface[U] {
+	mapped := newLinkedPromise[U](p.Cancel)
+	go func() {
+		var empty U
+		select {
+		case <-p.ReadyChan():
+			value, err := p.Current()
+			if err != nil {
+				mapped.produce(empty, err)
+				return
+			}
+			mapped.produce(f(value))
+		case <-ctx.Done():
+			p.Cancel()
+			mapped.produce(empty, ctx.Err())
+		case <-mapped.quit:
+			mapped.produce(empty, context.Canceled)
+		}
+	}()
+	return mapped
+}
+
+// WithTimeout returns a promise that resolves with the result of p, or fails with a
+// *PromiseTimeoutError if p isn't ready within d, in which case p is cancelled.
+// Cancelling the returned promise cancels p. ctx lets the wait end before d like the other
+// combinators: once it's done p is cancelled and the returned promise fails with ctx.Err().
+func WithTimeout[T any](ctx context.Context, p PromiseInterface[T], d time.Duration) PromiseInterface[T] {
+	limited := newLinkedPromise[T](p.Cancel)
+	go func() {
+		var empty T
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-p.ReadyChan():
+			limited.produce(p.Current())
+		case <-timer.C:
+			p.Cancel()
+			limited.produce(empty, &PromiseTimeoutError{Timeout: d})
+		case <-ctx.Done():
+			p.Cancel()
+			limited.produce(empty, ctx.Err())
+		case <-limited.quit:
+			limited.produce(empty, context.Canceled)
+		}
+	}()
+	return limited
+}
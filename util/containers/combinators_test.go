@@ -0,0 +1,239 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingPromise[T any](cancelCalled *atomic.Int64) *Promise[T] {
+	promise := NewPromise[T](func() { cancelCalled.Add(1) })
+	return &promise
+}
+
+func TestPromiseAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var cancelCalled atomic.Int64
+	first := newCountingPromise[int](&cancelCalled)
+	second := newCountingPromise[int](&cancelCalled)
+	all := All[int](ctx, first, second)
+	second.Produce(2)
+	if all.Ready() {
+		t.Fatal("All ready before all promises resolved")
+	}
+	first.Produce(1)
+	res, err := all.Await(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || res[0] != 1 || res[1] != 2 {
+		t.Fatal("unexpected All result", res)
+	}
+
+	res, err = All[int](ctx).Await(ctx)
+	if err != nil || len(res) != 0 {
+		t.Fatal("unexpected All result without promises", res, err)
+	}
+
+	errProduced := errors.New("err produced")
+	first = newCountingPromise[int](&cancelCalled)
+	second = newCountingPromise[int](&cancelCalled)
+	all = All[int](ctx, first, second)
+	second.ProduceError(errProduced)
+	_, err = all.Await(ctx)
+	if !errors.Is(err, errProduced) {
+		t.Fatal("unexpected All error", err)
+	}
+	if cancelCalled.Load() != 1 {
+		t.Fatal("All didn't cancel unresolved promise on failure")
+	}
+}
+
+func TestPromiseAny(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var cancelCalled atomic.Int64
+	errFirst := errors.New("first failed")
+	errSecond := errors.New("second failed")
+	first := newCountingPromise[int](&cancelCalled)
+	second := newCountingPromise[int](&cancelCalled)
+	third := newCountingPromise[int](&cancelCalled)
+	anyPromise := Any[int](ctx, first, second, third)
+	first.ProduceError(errFirst)
+	second.Produce(2)
+	res, err := anyPromise.Await(ctx)
+	if err != nil || res != 2 {
+		t.Fatal("unexpected Any result", res, err)
+	}
+	if cancelCalled.Load() != 1 {
+		t.Fatal("Any didn't cancel unresolved promise on success")
+	}
+
+	first = newCountingPromise[int](&cancelCalled)
+	second = newCountingPromise[int](&cancelCalled)
+	anyPromise = Any[int](ctx, first, second)
+	first.ProduceError(errFirst)
+	second.ProduceError(errSecond)
+	_, err = anyPromise.Await(ctx)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatal("unexpected Any error", err)
+	}
+
+	_, err = Any[int](ctx).Await(ctx)
+	if !errors.Is(err, ErrNoPromises) {
+		t.Fatal("unexpected Any error without promises", err)
+	}
+}
+
+func TestPromiseMap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	source := NewPromise[int](nil)
+	mapped := Map[int, string](ctx, &source, func(val int) (string, error) {
+		return strconv.Itoa(val), nil
+	})
+	source.Produce(7)
+	res, err := mapped.Await(ctx)
+	if err != nil || res != "7" {
+		t.Fatal("unexpected Map result", res, err)
+	}
+
+	errMapped := errors.New("map failed")
+	source = NewPromise[int](nil)
+	mapped = Map[int, string](ctx, &source, func(val int) (string, error) {
+		return "", errMapped
+	})
+	source.Produce(7)
+	_, err = mapped.Await(ctx)
+	if !errors.Is(err, errMapped) {
+		t.Fatal("unexpected Map error", err)
+	}
+
+	var cancelCalled atomic.Int64
+	cancelled := newCountingPromise[int](&cancelCalled)
+	mapped = Map[int, string](ctx, cancelled, func(val int) (string, error) {
+		t.Error("map function called after cancel")
+		return "", nil
+	})
+	mapped.Cancel()
+	_, err = mapped.Await(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("unexpected Map error after cancel", err)
+	}
+	if cancelCalled.Load() != 1 {
+		t.Fatal("Map didn't propagate cancel to source")
+	}
+}
+
+func TestPromiseWithTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var cancelCalled atomic.Int64
+	source := newCountingPromise[int](&cancelCalled)
+	limited := WithTimeout[int](ctx, source, time.Millisecond*10)
+	_, err := limited.Await(ctx)
+	var timeoutErr *PromiseTimeoutError
+	if !errors.As(err, &timeoutErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("unexpected WithTimeout error", err)
+	}
+	if cancelCalled.Load() != 1 {
+		t.Fatal("WithTimeout didn't cancel source on timeout")
+	}
+
+	source = newCountingPromise[int](&cancelCalled)
+	limited = WithTimeout[int](ctx, source, time.Minute)
+	source.Produce(3)
+	res, err := limited.Await(ctx)
+	if err != nil || res != 3 {
+		t.Fatal("unexpected WithTimeout result", res, err)
+	}
+	if cancelCalled.Load() != 1 {
+		t.Fatal("WithTimeout cancelled resolved source")
+	}
+}
+
+func TestPromiseCombinatorsContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var cancelCalled atomic.Int64
+	combinators := map[string]func(context.Context, PromiseInterface[int]) error{
+		"All": func(combinatorCtx context.Context, source PromiseInterface[int]) error {
+			_, err := All(combinatorCtx, source).Await(ctx)
+			return err
+		},
+		"Any": func(combinatorCtx context.Context, source PromiseInterface[int]) error {
+			_, err := Any(combinatorCtx, source).Await(ctx)
+			return err
+		},
+		"Map": func(combinatorCtx context.Context, source PromiseInterface[int]) error {
+			_, err := Map(combinatorCtx, source, func(val int) (int, error) { return val, nil }).Await(ctx)
+			return err
+		},
+		"WithTimeout": func(combinatorCtx context.Context, source PromiseInterface[int]) error {
+			_, err := WithTimeout(combinatorCtx, source, time.Minute).Await(ctx)
+			return err
+		},
+	}
+	for name, combine := range combinators {
+		// The source never resolves, so only the context stops the combinator
+		combinatorCtx, combinatorCancel := context.WithCancel(ctx)
+		source := newCountingPromise[int](&cancelCalled)
+		before := cancelCalled.Load()
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			combinatorCancel()
+		}()
+		if err := combine(combinatorCtx, source); !errors.Is(err, context.Canceled) {
+			t.Fatal(name, "didn't fail once its context was done", err)
+		}
+		if cancelCalled.Load() == before {
+			t.Fatal(name, "didn't cancel its source once its context was done")
+		}
+	}
+}
+
+func TestPromiseCombinatorsConcurrentAwait(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	sources := make([]PromiseInterface[int], 10)
+	producers := make([]*Promise[int], len(sources))
+	for i := range sources {
+		promise := NewPromise[int](nil)
+		producers[i] = &promise
+		sources[i] = &promise
+	}
+	sum := Map[[]int, int](ctx, WithTimeout(ctx, All(ctx, sources...), time.Minute), func(vals []int) (int, error) {
+		total := 0
+		for _, val := range vals {
+			total += val
+		}
+		return total, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := sum.Await(ctx)
+			if err != nil || res != 45 {
+				t.Error("unexpected concurrent Await result", res, err)
+			}
+		}()
+	}
+	for i, producer := range producers {
+		go producer.Produce(i)
+	}
+	wg.Wait()
+}